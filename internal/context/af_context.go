@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
 	"github.com/free5gc/openapi/models_nef"
 	"github.com/sirupsen/logrus"
 )
@@ -16,8 +17,14 @@ type AfData struct {
 	NumTransID uint64
 	Subs       map[string]*AfSubscription
 	PfdTrans   map[string]*AfPfdTransaction
-	Mu         sync.RWMutex
-	Log        *logrus.Entry
+	MeSubs     map[string]*AfMeSubscription
+	QosSubs    map[string]*AfQosSubscription
+	TrigTrans  map[string]*AfTriggerTransaction
+	Mu         sync.RWMutex  `json:"-"`
+	Log        *logrus.Entry `json:"-"`
+	// PfdMu serializes the PFD management of the AF. It is held across the UDR requests,
+	// while Mu is only held to access the PFD transactions. Lock PfdMu before Mu.
+	PfdMu sync.Mutex `json:"-"`
 }
 
 func (a *AfData) NewSub(numCorreID uint64, tiSub *models_nef.TrafficInfluSub) *AfSubscription {
@@ -32,6 +39,36 @@ func (a *AfData) NewSub(numCorreID uint64, tiSub *models_nef.TrafficInfluSub) *A
 	return &sub
 }
 
+func (a *AfData) NewMeSub(
+	numCorreID uint64,
+	meSub *nef_models.MonitoringEventSubscription,
+) *AfMeSubscription {
+	a.NumSubscID++
+	sub := AfMeSubscription{
+		NotifCorreID: strconv.FormatUint(numCorreID, 10),
+		SubID:        strconv.FormatUint(a.NumSubscID, 10),
+		MeSub:        meSub,
+		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("MESUB:%d", a.NumSubscID)),
+	}
+	sub.Log.Infoln("New monitoring event subscription")
+	return &sub
+}
+
+func (a *AfData) NewQosSub(
+	numCorreID uint64,
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+) *AfQosSubscription {
+	a.NumSubscID++
+	sub := AfQosSubscription{
+		NotifCorreID: strconv.FormatUint(numCorreID, 10),
+		SubID:        strconv.FormatUint(a.NumSubscID, 10),
+		QosSub:       qosSub,
+		Log:          a.Log.WithField(logger.FieldSubID, fmt.Sprintf("QOSSUB:%d", a.NumSubscID)),
+	}
+	sub.Log.Infoln("New AS session with QoS subscription")
+	return &sub
+}
+
 func (a *AfData) NewPfdTrans() *AfPfdTransaction {
 	a.NumTransID++
 	pfdTr := AfPfdTransaction{
@@ -43,6 +80,25 @@ func (a *AfData) NewPfdTrans() *AfPfdTransaction {
 	return &pfdTr
 }
 
+func (a *AfData) NewTrigTrans(trigger *nef_models.DeviceTriggering) *AfTriggerTransaction {
+	a.NumTransID++
+	trigTr := AfTriggerTransaction{
+		TransID: strconv.FormatUint(a.NumTransID, 10),
+		Trigger: trigger,
+		Log:     a.Log.WithField(logger.FieldTrigTransID, fmt.Sprintf("TRIGT:%d", a.NumTransID)),
+	}
+	trigTr.Log.Infoln("New device triggering transaction")
+	return &trigTr
+}
+
+// NewTrigMsgRef assigns a new reference for each message of the transaction handed over to
+// TriggerDeliverer, so that the late report of a recalled message never matches its replacement
+func (a *AfData) NewTrigMsgRef(trigTr *AfTriggerTransaction) string {
+	trigTr.NumMsgs++
+	trigTr.MsgRef = a.AfID + "/" + trigTr.TransID + "/" + strconv.FormatUint(trigTr.NumMsgs, 10)
+	return trigTr.MsgRef
+}
+
 func (a *AfData) IsAppIDExisted(appID string) (string, bool) {
 	for _, pfdTrans := range a.PfdTrans {
 		if _, ok := pfdTrans.ExtAppIDs[appID]; ok {
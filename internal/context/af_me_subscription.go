@@ -0,0 +1,23 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type AfMeSubscription struct {
+	SubID        string
+	MeSub        *nef_models.MonitoringEventSubscription
+	NotifCorreID string
+	AmfSubID     string // Namf_EventExposure subscription
+	UdmSubID     string // Nudm_EventExposure subscription
+	UeIdentity   string // ueIdentity of Nudm_EventExposure subscription
+	NumReports   int32
+	Log          *logrus.Entry `json:"-"`
+}
+
+// IsReportsExhausted reports whether the maximumNumberOfReports requested by AF is reached
+func (s *AfMeSubscription) IsReportsExhausted() bool {
+	return s.MeSub.MaximumNumberOfReports > 0 &&
+		s.NumReports >= s.MeSub.MaximumNumberOfReports
+}
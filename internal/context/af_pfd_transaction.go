@@ -7,7 +7,7 @@ import (
 type AfPfdTransaction struct {
 	TransID   string
 	ExtAppIDs map[string]struct{}
-	Log       *logrus.Entry
+	Log       *logrus.Entry `json:"-"`
 }
 
 func (a *AfPfdTransaction) GetExtAppIDs() []string {
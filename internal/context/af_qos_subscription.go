@@ -0,0 +1,15 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type AfQosSubscription struct {
+	SubID        string
+	QosSub       *nef_models.AsSessionWithQoSSubscription
+	AppSessID    string
+	PcfUri       string // the PCF holding the app session, empty if unknown
+	NotifCorreID string
+	Log          *logrus.Entry `json:"-"`
+}
@@ -9,9 +9,12 @@ type AfSubscription struct {
 	SubID        string
 	TiSub        *models_nef.TrafficInfluSub
 	AppSessID    string // use in single UE case
+	PcfUri       string // the PCF holding the app session in single UE case, empty if unknown
 	InfluID      string // use in multiple UE case
 	NotifCorreID string
-	Log          *logrus.Entry
+	AfAck        *models_nef.AfAckInfo // the latest acknowledgement of UP path change from AF
+	SmfAckUri    string                // the URI of SMF waiting for the AF acknowledgement, empty if none
+	Log          *logrus.Entry         `json:"-"`
 }
 
 func (s *AfSubscription) PatchTiSubData(tiSubPatch *models_nef.TrafficInfluSubPatch) {
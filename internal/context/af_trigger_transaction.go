@@ -0,0 +1,19 @@
+package context
+
+import (
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type AfTriggerTransaction struct {
+	TransID string
+	Trigger *nef_models.DeviceTriggering
+	MsgRef  string        // reference of the message handed over to TriggerDeliverer
+	NumMsgs uint64        // number of messages handed over to TriggerDeliverer
+	Log     *logrus.Entry `json:"-"`
+}
+
+// IsPending reports whether the delivery result of the trigger is not known yet
+func (t *AfTriggerTransaction) IsPending() bool {
+	return t.Trigger.DeliveryResult == ""
+}
@@ -0,0 +1,356 @@
+package context
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+)
+
+const (
+	fileStoreOpPut    = "put"
+	fileStoreOpDelete = "del"
+
+	// The file is compacted when it grows to twice the size after the last compaction,
+	// and is larger than fileStoreCompactMinSize
+	fileStoreCompactMinSize = 4 * 1024 * 1024
+	// The appended entries are flushed to disk per fileStoreSyncInterval in the background,
+	// so that the callers holding the AF lock don't wait for the disk
+	fileStoreSyncInterval = 100 * time.Millisecond
+)
+
+type fileStoreEntry struct {
+	Op     string `json:"op"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+}
+
+// FileStore is an append-only file backend. Every change is appended to the file
+// and the whole file is replayed into memory on open, then compacted. The file is also
+// compacted when it grows too much at runtime. Only the unterminated last entry, which is
+// partially written by a crash, is dropped on open, any other corrupted entry fails the open.
+//
+// The changes are written to the file immediately, which survive the crash of NEF.
+// They are synced to disk in the background, so the changes within the last
+// fileStoreSyncInterval may be lost if the host crashes.
+type FileStore struct {
+	*MemStore
+
+	path string
+	file *os.File
+	mu   sync.Mutex
+
+	size           int64 // size of the file
+	compactedSize  int64 // size of the file after the last compaction
+	compactMinSize int64
+	dirty          bool // there are entries not synced to disk
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		MemStore:       NewMemStore(),
+		path:           path,
+		compactMinSize: fileStoreCompactMinSize,
+		done:           make(chan struct{}),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o775); err != nil {
+		return nil, fmt.Errorf("NewFileStore err: %+v", err)
+	}
+	if err := s.replay(); err != nil {
+		return nil, fmt.Errorf("NewFileStore err: %+v", err)
+	}
+	f, size, err := s.compact()
+	if err != nil {
+		return nil, fmt.Errorf("NewFileStore err: %+v", err)
+	}
+	s.file = f
+	s.size = size
+	s.compactedSize = size
+
+	s.wg.Add(1)
+	go s.runSync()
+	logger.CtxLog.Infof("Open file store [%s]", path)
+	return s, nil
+}
+
+// reopen opens the file again after it's closed by a failed append,
+// and drops the partially written entry after the last good offset if any
+func (s *FileStore) reopen() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil && info.Size() > s.size {
+		err = f.Truncate(s.size)
+	}
+	if err != nil {
+		if closeErr := f.Close(); closeErr != nil {
+			logger.CtxLog.Errorf("File store cannot close: %+v", closeErr)
+		}
+		return err
+	}
+	s.file = f
+	return nil
+}
+
+func (s *FileStore) replay() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			logger.CtxLog.Errorf("File store cannot close: %+v", closeErr)
+		}
+	}()
+
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(b) > 0 {
+				// A partially written tail entry is expected after a crash, it's dropped by the compaction
+				logger.CtxLog.Warnf("Skip unterminated entry at line %d of [%s]", line, s.path)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var entry fileStoreEntry
+		if err = json.Unmarshal(b, &entry); err != nil {
+			return fmt.Errorf("corrupted entry at line %d of [%s]: %+v", line, s.path, err)
+		}
+		switch entry.Op {
+		case fileStoreOpPut:
+			err = s.MemStore.Put(entry.Bucket, entry.Key, entry.Value)
+		case fileStoreOpDelete:
+			err = s.MemStore.Delete(entry.Bucket, entry.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compact rewrites the file with the current values, which is synced to disk.
+// It returns the new file opened for appending and its size. The new file is written
+// to a temporary path and renamed, so the handle stays valid and no reopen is needed.
+func (s *FileStore) compact() (*os.File, int64, error) {
+	tmpPath := s.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_APPEND|os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	w := bufio.NewWriter(f)
+	var size int64
+	s.MemStore.mu.RLock()
+	for bucket, values := range s.MemStore.buckets {
+		for key, value := range values {
+			var n int
+			if n, err = writeFileStoreEntry(w, &fileStoreEntry{
+				Op:     fileStoreOpPut,
+				Bucket: bucket,
+				Key:    key,
+				Value:  value,
+			}); err != nil {
+				break
+			}
+			size += int64(n)
+		}
+	}
+	s.MemStore.mu.RUnlock()
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		if closeErr := f.Close(); closeErr != nil {
+			logger.CtxLog.Errorf("File store cannot close: %+v", closeErr)
+		}
+		if rmErr := os.Remove(tmpPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			logger.CtxLog.Errorf("Remove [%s] err: %+v", tmpPath, rmErr)
+		}
+		return nil, 0, err
+	}
+	return f, size, nil
+}
+
+// compactIfNeeded compacts the file if it grows too much, then appends to the compacted file.
+// The current file is kept if the compaction fails.
+func (s *FileStore) compactIfNeeded() {
+	if s.file == nil || s.size < s.compactMinSize || s.size < 2*s.compactedSize {
+		return
+	}
+
+	f, size, err := s.compact()
+	if err != nil {
+		logger.CtxLog.Errorf("Compact file store [%s] err: %+v", s.path, err)
+		return
+	}
+	if err = s.file.Close(); err != nil {
+		logger.CtxLog.Errorf("File store cannot close: %+v", err)
+	}
+	s.file = f
+	s.size = size
+	s.compactedSize = size
+	// The compacted file has been synced
+	s.dirty = false
+	logger.CtxLog.Infof("File store [%s] is compacted to %d bytes", s.path, s.size)
+}
+
+// runSync syncs the appended entries to disk per fileStoreSyncInterval until the store is closed
+func (s *FileStore) runSync() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(fileStoreSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.sync()
+		}
+	}
+}
+
+// sync flushes the file without holding s.mu, so the writers are not blocked by the disk
+func (s *FileStore) sync() {
+	s.mu.Lock()
+	f := s.file
+	dirty := s.dirty
+	s.dirty = false
+	s.mu.Unlock()
+
+	if f == nil || !dirty {
+		return
+	}
+	if err := f.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+		// The file may be closed by compaction, whose new file has been synced
+		logger.CtxLog.Errorf("Sync file store [%s] err: %+v", s.path, err)
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+	}
+}
+
+func (s *FileStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(&fileStoreEntry{
+		Op:     fileStoreOpPut,
+		Bucket: bucket,
+		Key:    key,
+		Value:  value,
+	}); err != nil {
+		return err
+	}
+	if err := s.MemStore.Put(bucket, key, value); err != nil {
+		return err
+	}
+	s.compactIfNeeded()
+	return nil
+}
+
+func (s *FileStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(&fileStoreEntry{
+		Op:     fileStoreOpDelete,
+		Bucket: bucket,
+		Key:    key,
+	}); err != nil {
+		return err
+	}
+	if err := s.MemStore.Delete(bucket, key); err != nil {
+		return err
+	}
+	s.compactIfNeeded()
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+func (s *FileStore) append(entry *fileStoreEntry) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("File store [%s] is closed", s.path)
+	default:
+	}
+	if s.file == nil {
+		if err := s.reopen(); err != nil {
+			return fmt.Errorf("Reopen file store [%s] err: %+v", s.path, err)
+		}
+	}
+
+	n, err := writeFileStoreEntry(s.file, entry)
+	if err == nil {
+		s.size += int64(n)
+		s.dirty = true
+		return nil
+	}
+	if n > 0 {
+		// Drop the partially written entry, otherwise the next entry is appended to the same line
+		if truncErr := s.file.Truncate(s.size); truncErr != nil {
+			logger.CtxLog.Errorf("Truncate file store [%s] err: %+v", s.path, truncErr)
+			// Truncated by reopen on the next append
+			if closeErr := s.file.Close(); closeErr != nil {
+				logger.CtxLog.Errorf("File store cannot close: %+v", closeErr)
+			}
+			s.file = nil
+		}
+	}
+	return err
+}
+
+func writeFileStoreEntry(w io.Writer, entry *fileStoreEntry) (int, error) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(append(b, '\n'))
+}
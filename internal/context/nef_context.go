@@ -2,8 +2,12 @@ package context
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/pkg/factory"
@@ -20,24 +24,117 @@ type NefContext struct {
 	nef
 
 	nfInstID       string // NF Instance ID
-	pcfPaUri       string
-	udrDrUri       string
+	heartBeatTimer int32  // Heartbeat interval in seconds provided by NRF
 	numCorreID     uint64
-	OAuth2Required bool
+	oAuth2Required bool
 	afs            map[string]*AfData
+	store          Store
 	mu             sync.RWMutex
+
+	// Discovered NF instances by the service
+	nfCandidates map[models.ServiceName]*nfCandidates
+}
+
+const storeKeyCorreID = "numCorreID"
+
+// NfCandidate is a discovered NF instance providing a service
+type NfCandidate struct {
+	NfInstID string
+	Uri      string
 }
 
+// nfCandidates is the cached discovery result of a service in order of preference
+type nfCandidates struct {
+	list   []NfCandidate
+	expiry time.Time // Zero if the discovery result doesn't expire
+}
+
+// DefaultHeartBeatTimer is the heartbeat interval to NRF if NRF doesn't provide heartBeatTimer
+const DefaultHeartBeatTimer = 60 * time.Second
+
 func NewContext(nef nef) (*NefContext, error) {
 	c := &NefContext{
 		nef:      nef,
 		nfInstID: uuid.New().String(),
 	}
 	c.afs = make(map[string]*AfData)
+	c.nfCandidates = make(map[models.ServiceName]*nfCandidates)
 	logger.CtxLog.Infof("New nfInstID: [%s]", c.nfInstID)
+
+	var err error
+	if c.store, err = NewStore(nef.Config().StoreType(), nef.Config().StorePath()); err != nil {
+		return nil, err
+	}
+	if err = c.restore(); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
+func (c *NefContext) restore() error {
+	afValues, err := c.store.Load(StoreBucketAf)
+	if err != nil {
+		return fmt.Errorf("Restore AFs err: %+v", err)
+	}
+	for afID, value := range afValues {
+		af := c.NewAf(afID)
+		if err = json.Unmarshal(value, af); err != nil {
+			return fmt.Errorf("Restore AF[%s] err: %+v", afID, err)
+		}
+		for _, sub := range af.Subs {
+			sub.Log = af.Log.WithField(logger.FieldSubID, fmt.Sprintf("SUB:%s", sub.SubID))
+		}
+		if af.MeSubs == nil {
+			af.MeSubs = make(map[string]*AfMeSubscription)
+		}
+		for _, meSub := range af.MeSubs {
+			meSub.Log = af.Log.WithField(logger.FieldSubID, fmt.Sprintf("MESUB:%s", meSub.SubID))
+		}
+		if af.QosSubs == nil {
+			af.QosSubs = make(map[string]*AfQosSubscription)
+		}
+		for _, qosSub := range af.QosSubs {
+			qosSub.Log = af.Log.WithField(logger.FieldSubID, fmt.Sprintf("QOSSUB:%s", qosSub.SubID))
+		}
+		if af.TrigTrans == nil {
+			af.TrigTrans = make(map[string]*AfTriggerTransaction)
+		}
+		for _, trigTr := range af.TrigTrans {
+			trigTr.Log = af.Log.WithField(logger.FieldTrigTransID, fmt.Sprintf("TRIGT:%s", trigTr.TransID))
+		}
+		for _, pfdTr := range af.PfdTrans {
+			pfdTr.Log = af.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%s", pfdTr.TransID))
+			if pfdTr.ExtAppIDs == nil {
+				pfdTr.ExtAppIDs = make(map[string]struct{})
+			}
+		}
+		c.afs[afID] = af
+		af.Log.Infof("AF is restored with %d subscriptions, %d monitoring event subscriptions, "+
+			"%d QoS subscriptions, %d PFD transactions and %d device triggering transactions",
+			len(af.Subs), len(af.MeSubs), len(af.QosSubs), len(af.PfdTrans), len(af.TrigTrans))
+	}
+
+	counters, err := c.store.Load(StoreBucketCounter)
+	if err != nil {
+		return fmt.Errorf("Restore counters err: %+v", err)
+	}
+	if value, ok := counters[storeKeyCorreID]; ok {
+		if c.numCorreID, err = strconv.ParseUint(string(value), 10, 64); err != nil {
+			return fmt.Errorf("Restore numCorreID err: %+v", err)
+		}
+	}
+	return nil
+}
+
+func (c *NefContext) Close() error {
+	return c.store.Close()
+}
+
+// Store returns the persistence backend, which is shared with the other NEF components
+func (c *NefContext) Store() Store {
+	return c.store
+}
+
 func (c *NefContext) NfInstID() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -51,59 +148,198 @@ func (c *NefContext) SetNfInstID(id string) {
 	logger.CtxLog.Infof("Set nfInstID: [%s]", c.nfInstID)
 }
 
-func (c *NefContext) PcfPaUri() string {
+// HeartBeatTimer returns the heartbeat interval to NRF, DefaultHeartBeatTimer if NRF doesn't provide it
+// OAuth2Required reports whether NRF requires OAuth2 for the NF services, which is updated on registration
+func (c *NefContext) OAuth2Required() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.oAuth2Required
+}
+
+func (c *NefContext) SetOAuth2Required(required bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.oAuth2Required = required
+	logger.CtxLog.Infof("Set oAuth2Required: [%t]", c.oAuth2Required)
+}
+
+func (c *NefContext) HeartBeatTimer() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.pcfPaUri
+	if c.heartBeatTimer <= 0 {
+		return DefaultHeartBeatTimer
+	}
+	return time.Duration(c.heartBeatTimer) * time.Second
 }
 
-func (c *NefContext) SetPcfPaUri(uri string) {
+func (c *NefContext) SetHeartBeatTimer(seconds int32) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.pcfPaUri = uri
-	logger.CtxLog.Infof("Set pcfPaUri: [%s]", c.pcfPaUri)
+	c.heartBeatTimer = seconds
+	logger.CtxLog.Infof("Set heartBeatTimer: [%d]", c.heartBeatTimer)
 }
 
-func (c *NefContext) UdrDrUri() string {
+// NfCandidates returns the cached NF instances of the service in order of preference,
+// and whether the discovery result is still valid. The expired result can be used if NRF is unavailable.
+func (c *NefContext) NfCandidates(srvName models.ServiceName) ([]NfCandidate, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.udrDrUri
+	cands, ok := c.nfCandidates[srvName]
+	if !ok {
+		return nil, false
+	}
+	valid := cands.expiry.IsZero() || time.Now().Before(cands.expiry)
+	return append([]NfCandidate(nil), cands.list...), valid
+}
+
+// SetNfCandidates caches the NF instances of the service for the validity period, without expiry if it's 0.
+// The cache of the service is cleared if list is empty.
+func (c *NefContext) SetNfCandidates(srvName models.ServiceName, list []NfCandidate, validity time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(list) == 0 {
+		delete(c.nfCandidates, srvName)
+		logger.CtxLog.Infof("Clear NF candidates of %s", srvName)
+		return
+	}
+	cands := &nfCandidates{
+		list: append([]NfCandidate(nil), list...),
+	}
+	if validity > 0 {
+		cands.expiry = time.Now().Add(validity)
+	}
+	c.nfCandidates[srvName] = cands
+	logger.CtxLog.Infof("Set NF candidates of %s: %+v, validity[%s]", srvName, cands.list, validity)
+}
+
+// ExpireNfCandidates expires the cached NF instances of the service, so that they are discovered again
+func (c *NefContext) ExpireNfCandidates(srvName models.ServiceName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cands, ok := c.nfCandidates[srvName]; ok {
+		cands.expiry = time.Now()
+		logger.CtxLog.Infof("Expire NF candidates of %s", srvName)
+	}
+}
+
+// DemoteNfCandidate moves the NF instance URI which fails to the end of the candidates of the service,
+// so that the following requests try the next candidate first
+func (c *NefContext) DemoteNfCandidate(srvName models.ServiceName, uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cands, ok := c.nfCandidates[srvName]
+	if !ok {
+		return
+	}
+	for i, cand := range cands.list {
+		if cand.Uri == uri {
+			cands.list = append(append(cands.list[:i:i], cands.list[i+1:]...), cand)
+			logger.CtxLog.Infof("Demote NF candidate of %s: [%s] of nfInstID[%s]", srvName, uri, cand.NfInstID)
+			return
+		}
+	}
 }
 
-func (c *NefContext) SetUdrDrUri(uri string) {
+// InvalidateNfInstance removes the NF instance from the cached candidates, e.g. when it deregisters from NRF,
+// so that another instance is used for the next request
+func (c *NefContext) InvalidateNfInstance(nfInstID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.udrDrUri = uri
-	logger.CtxLog.Infof("Set udrDrUri: [%s]", c.udrDrUri)
+	for srvName, cands := range c.nfCandidates {
+		list := cands.list[:0]
+		for _, cand := range cands.list {
+			if cand.NfInstID == nfInstID {
+				logger.CtxLog.Infof("Invalidate NF candidate of %s: [%s] of nfInstID[%s]", srvName, cand.Uri, nfInstID)
+				continue
+			}
+			list = append(list, cand)
+		}
+		if len(list) == 0 {
+			delete(c.nfCandidates, srvName)
+			continue
+		}
+		cands.list = list
+	}
 }
 
 func (c *NefContext) NewAf(afID string) *AfData {
 	af := &AfData{
-		AfID:     afID,
-		Subs:     make(map[string]*AfSubscription),
-		PfdTrans: make(map[string]*AfPfdTransaction),
-		Log:      logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", afID)),
+		AfID:      afID,
+		Subs:      make(map[string]*AfSubscription),
+		PfdTrans:  make(map[string]*AfPfdTransaction),
+		MeSubs:    make(map[string]*AfMeSubscription),
+		QosSubs:   make(map[string]*AfQosSubscription),
+		TrigTrans: make(map[string]*AfTriggerTransaction),
+		Log:       logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", afID)),
 	}
 	return af
 }
 
+// AddAf registers and persists the AF. The caller should hold af.Mu, which is always
+// locked before c.mu: c.mu is never held while waiting for af.Mu.
 func (c *NefContext) AddAf(af *AfData) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.afs[af.AfID] = af
+	c.SaveAf(af)
 	af.Log.Infoln("AF is added")
 }
 
+// SaveAf persists the AF with its subscriptions and PFD transactions.
+// The caller should hold af.Mu.
+func (c *NefContext) SaveAf(af *AfData) {
+	value, err := json.Marshal(af)
+	if err != nil {
+		af.Log.Errorf("Marshal AF err: %+v", err)
+		return
+	}
+	if err = c.store.Put(StoreBucketAf, af.AfID, value); err != nil {
+		af.Log.Errorf("Save AF err: %+v", err)
+	}
+}
+
 func (c *NefContext) GetAf(afID string) *AfData {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.afs[afID]
 }
 
+// GetAfs returns all AFs sorted by AF ID
+func (c *NefContext) GetAfs() []*AfData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	afs := make([]*AfData, 0, len(c.afs))
+	for _, af := range c.afs {
+		afs = append(afs, af)
+	}
+	sort.Slice(afs, func(i, j int) bool {
+		return afs[i].AfID < afs[j].AfID
+	})
+	return afs
+}
+
+// ActiveCounts returns the number of AFs, TI subscriptions and PFD transactions
+func (c *NefContext) ActiveCounts() (numAfs, numSubs, numPfdTrans int) {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	afs := c.GetAfs()
+	numAfs = len(afs)
+	for _, af := range afs {
+		af.Mu.RLock()
+		numSubs += len(af.Subs)
+		numPfdTrans += len(af.PfdTrans)
+		af.Mu.RUnlock()
+	}
+	return numAfs, numSubs, numPfdTrans
+}
+
 func (c *NefContext) DeleteAf(afID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.afs, afID)
+	if err := c.store.Delete(StoreBucketAf, afID); err != nil {
+		logger.CtxLog.Errorf("Delete AF[%s] from store err: %+v", afID, err)
+	}
 	logger.CtxLog.Infof("AF[%s] is deleted", afID)
 }
 
@@ -112,6 +348,13 @@ func (c *NefContext) NewCorreID() uint64 {
 	defer c.mu.Unlock()
 
 	c.numCorreID++
+	c.saveCorreID()
+	return c.numCorreID
+}
+
+func (c *NefContext) CorreID() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.numCorreID
 }
 
@@ -119,12 +362,19 @@ func (c *NefContext) ResetCorreID() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.numCorreID = 0
+	c.saveCorreID()
+}
+
+func (c *NefContext) saveCorreID() {
+	value := []byte(strconv.FormatUint(c.numCorreID, 10))
+	if err := c.store.Put(StoreBucketCounter, storeKeyCorreID, value); err != nil {
+		logger.CtxLog.Errorf("Save numCorreID err: %+v", err)
+	}
 }
 
 func (c *NefContext) IsAppIDExisted(appID string) (string, string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	for _, af := range c.afs {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	for _, af := range c.GetAfs() {
 		af.Mu.RLock()
 		if transID, ok := af.IsAppIDExisted(appID); ok {
 			defer af.Mu.RUnlock()
@@ -136,9 +386,8 @@ func (c *NefContext) IsAppIDExisted(appID string) (string, string, bool) {
 }
 
 func (c *NefContext) FindAfSub(CorrID string) (*AfData, *AfSubscription) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	for _, af := range c.afs {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	for _, af := range c.GetAfs() {
 		af.Mu.RLock()
 		for _, sub := range af.Subs {
 			if sub.NotifCorreID == CorrID {
@@ -151,12 +400,57 @@ func (c *NefContext) FindAfSub(CorrID string) (*AfData, *AfSubscription) {
 	return nil, nil
 }
 
+func (c *NefContext) FindAfMeSub(CorrID string) (*AfData, *AfMeSubscription) {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	for _, af := range c.GetAfs() {
+		af.Mu.RLock()
+		for _, sub := range af.MeSubs {
+			if sub.NotifCorreID == CorrID {
+				defer af.Mu.RUnlock()
+				return af, sub
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
+func (c *NefContext) FindAfQosSub(CorrID string) (*AfData, *AfQosSubscription) {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	for _, af := range c.GetAfs() {
+		af.Mu.RLock()
+		for _, sub := range af.QosSubs {
+			if sub.NotifCorreID == CorrID {
+				defer af.Mu.RUnlock()
+				return af, sub
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
+func (c *NefContext) FindAfTrigTrans(msgRef string) (*AfData, *AfTriggerTransaction) {
+	// Take a snapshot first, so that c.mu is not held while waiting for af.Mu
+	for _, af := range c.GetAfs() {
+		af.Mu.RLock()
+		for _, trigTr := range af.TrigTrans {
+			if trigTr.MsgRef == msgRef {
+				defer af.Mu.RUnlock()
+				return af, trigTr
+			}
+		}
+		af.Mu.RUnlock()
+	}
+	return nil, nil
+}
+
 func (c *NefContext) GetTokenCtx(serviceName models.ServiceName, targetNF models.NfType) (
 	context.Context, *models.ProblemDetails, error,
 ) {
-	if !c.OAuth2Required {
+	if !c.OAuth2Required() {
 		return context.TODO(), nil, nil
 	}
 	return oauth.GetTokenCtx(models.NfType_NEF, targetNF,
-		c.nfInstID, c.Config().NrfUri(), string(serviceName))
+		c.NfInstID(), c.Config().NrfUri(), string(serviceName))
 }
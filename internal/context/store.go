@@ -0,0 +1,83 @@
+package context
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+const (
+	StoreBucketAf      = "afs"
+	StoreBucketCounter = "counters"
+	StoreBucketPfdSub  = "pfdSubs"
+)
+
+// Store is the persistence backend of NefContext.
+// Values are organized as key-value pairs grouped by bucket.
+type Store interface {
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	Load(bucket string) (map[string][]byte, error)
+	Close() error
+}
+
+func NewStore(storeType, path string) (Store, error) {
+	switch storeType {
+	case factory.NefStoreTypeMemory:
+		return NewMemStore(), nil
+	case factory.NefStoreTypeFile:
+		return NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("Unsupported store type[%s]", storeType)
+	}
+}
+
+type MemStore struct {
+	buckets map[string]map[string][]byte
+	mu      sync.RWMutex
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+func (s *MemStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		b = make(map[string][]byte)
+		s.buckets[bucket] = b
+	}
+	b[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *MemStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[bucket]; ok {
+		delete(b, key)
+	}
+	return nil
+}
+
+func (s *MemStore) Load(bucket string) (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(map[string][]byte, len(s.buckets[bucket]))
+	for k, v := range s.buckets[bucket] {
+		values[k] = append([]byte(nil), v...)
+	}
+	return values, nil
+}
+
+func (s *MemStore) Close() error {
+	return nil
+}
@@ -0,0 +1,225 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models_nef"
+	"github.com/stretchr/testify/require"
+)
+
+type nefTestApp struct {
+	cfg *factory.Config
+}
+
+func (a *nefTestApp) Config() *factory.Config {
+	return a.cfg
+}
+
+func newTestConfig(storePath string) *factory.Config {
+	return &factory.Config{
+		Configuration: &factory.Configuration{
+			Store: &factory.Store{
+				Type: factory.NefStoreTypeFile,
+				Path: storePath,
+			},
+		},
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nef.db")
+
+	s, err := NewFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Put("b1", "k1", []byte("v1")))
+	require.NoError(t, s.Put("b1", "k2", []byte("v2")))
+	require.NoError(t, s.Put("b1", "k1", []byte("v3")))
+	require.NoError(t, s.Delete("b1", "k2"))
+	require.NoError(t, s.Put("b2", "k1", []byte("v4")))
+	require.NoError(t, s.Close())
+
+	s, err = NewFileStore(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	values, err := s.Load("b1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k1": []byte("v3")}, values)
+
+	values, err = s.Load("b2")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k1": []byte("v4")}, values)
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nef.db")
+
+	s, err := NewFileStore(path)
+	require.NoError(t, err)
+	s.compactMinSize = 1024
+
+	value := strings.Repeat("v", 100)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, s.Put("b1", "k1", []byte(value+strconv.Itoa(i))))
+	}
+	require.NoError(t, s.Put("b1", "k2", []byte(value)))
+	require.NoError(t, s.Delete("b1", "k2"))
+
+	// The file is compacted at runtime instead of growing with every change
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, info.Size(), int64(2*1024))
+
+	// The changes are synced in the background
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return !s.dirty
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, s.Close())
+
+	s, err = NewFileStore(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+	values, err := s.Load("b1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k1": []byte(value + "99")}, values)
+}
+
+func TestFileStoreCorruption(t *testing.T) {
+	entry1 := `{"op":"put","bucket":"b1","key":"k1","value":"djE="}` + "\n"
+	entry2 := `{"op":"put","bucket":"b1","key":"k2","value":"djI="}` + "\n"
+
+	testCases := []struct {
+		description    string
+		content        string
+		expectedErr    bool
+		expectedValues map[string][]byte
+	}{
+		{
+			description: "TC1: Unterminated last entry, should be dropped",
+			content:     entry1 + entry2[:20],
+			expectedValues: map[string][]byte{
+				"k1": []byte("v1"),
+			},
+		},
+		{
+			description: "TC2: Corrupted entry in the middle, should fail to open",
+			content:     entry1 + entry2[:20] + entry2,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nef.db")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			s, err := NewFileStore(path)
+			if tc.expectedErr {
+				require.Error(t, err)
+				// The file is not compacted, so the entries can be recovered manually
+				content, readErr := os.ReadFile(path)
+				require.NoError(t, readErr)
+				require.Equal(t, tc.content, string(content))
+				return
+			}
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, s.Close())
+			}()
+			values, err := s.Load("b1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedValues, values)
+		})
+	}
+}
+
+func TestFileStoreRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nef.db")
+
+	s, err := NewFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Put("b1", "k1", []byte("v1")))
+
+	// A failed append leaves a partial entry and closes the file, the next append reopens
+	// the file and drops the partial entry
+	s.mu.Lock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"put","bucket":"b1"`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, s.file.Close())
+	s.file = nil
+	s.mu.Unlock()
+	require.NoError(t, s.Put("b1", "k2", []byte("v2")))
+
+	// A failed compaction keeps appending to the current file
+	require.NoError(t, os.Mkdir(path+".tmp", 0o700))
+	s.compactMinSize = 1
+	require.NoError(t, s.Put("b1", "k3", []byte("v3")))
+	require.NoError(t, s.Delete("b1", "k1"))
+	require.NoError(t, s.Close())
+	require.Error(t, s.Put("b1", "k4", []byte("v4")))
+	require.NoError(t, os.Remove(path+".tmp"))
+
+	s, err = NewFileStore(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+	values, err := s.Load("b1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k2": []byte("v2"), "k3": []byte("v3")}, values)
+}
+
+func TestNefContextRestore(t *testing.T) {
+	app := &nefTestApp{
+		cfg: newTestConfig(filepath.Join(t.TempDir(), "nef.db")),
+	}
+
+	c, err := NewContext(app)
+	require.NoError(t, err)
+
+	af := c.NewAf("af1")
+	af.Mu.Lock()
+	sub := af.NewSub(c.NewCorreID(), &models_nef.TrafficInfluSub{
+		AfAppId:  "app1",
+		AnyUeInd: true,
+	})
+	sub.InfluID = "influ1"
+	af.Subs[sub.SubID] = sub
+	pfdTr := af.NewPfdTrans()
+	pfdTr.AddExtAppID("app1")
+	af.PfdTrans[pfdTr.TransID] = pfdTr
+	c.AddAf(af)
+	af.Mu.Unlock()
+	require.NoError(t, c.Close())
+
+	c, err = NewContext(app)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, c.Close())
+	}()
+
+	restoredAf := c.GetAf("af1")
+	require.NotNil(t, restoredAf)
+	require.Equal(t, uint64(1), restoredAf.NumSubscID)
+	require.Equal(t, uint64(1), restoredAf.NumTransID)
+	require.Equal(t, "influ1", restoredAf.Subs[sub.SubID].InfluID)
+	require.Equal(t, sub.TiSub, restoredAf.Subs[sub.SubID].TiSub)
+	require.NotNil(t, restoredAf.Subs[sub.SubID].Log)
+	require.Equal(t, []string{"app1"}, restoredAf.PfdTrans[pfdTr.TransID].GetExtAppIDs())
+	require.Equal(t, uint64(2), c.NewCorreID())
+}
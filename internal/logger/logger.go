@@ -20,13 +20,20 @@ var (
 	TrafInfluLog *logrus.Entry
 	PFDManageLog *logrus.Entry
 	PFDFLog      *logrus.Entry
+	MonEvtLog    *logrus.Entry
+	AsQosLog     *logrus.Entry
+	DevTrigLog   *logrus.Entry
+	DnaiMapLog   *logrus.Entry
 	OamLog       *logrus.Entry
+	NotifierLog  *logrus.Entry
+	MetricsLog   *logrus.Entry
 )
 
 const (
-	FieldAFID       string = "AFID"
-	FieldSubID      string = "SubID"
-	FieldPfdTransID string = "PfdTRID"
+	FieldAFID        string = "AFID"
+	FieldSubID       string = "SubID"
+	FieldPfdTransID  string = "PfdTRID"
+	FieldTrigTransID string = "TrigTRID"
 )
 
 func init() {
@@ -36,6 +43,7 @@ func init() {
 		FieldAFID,
 		FieldSubID,
 		FieldPfdTransID,
+		FieldTrigTransID,
 	}
 	Log = logger_util.New(fieldsOrder)
 	NfLog = Log.WithField(logger_util.FieldNF, "NEF")
@@ -51,5 +59,11 @@ func init() {
 	TrafInfluLog = NfLog.WithField(logger_util.FieldCategory, "TraffInfl")
 	PFDManageLog = NfLog.WithField(logger_util.FieldCategory, "PFDMng")
 	PFDFLog = NfLog.WithField(logger_util.FieldCategory, "PFDF")
+	MonEvtLog = NfLog.WithField(logger_util.FieldCategory, "MonEvt")
+	AsQosLog = NfLog.WithField(logger_util.FieldCategory, "AsQoS")
+	DevTrigLog = NfLog.WithField(logger_util.FieldCategory, "DevTrig")
+	DnaiMapLog = NfLog.WithField(logger_util.FieldCategory, "DnaiMap")
 	OamLog = NfLog.WithField(logger_util.FieldCategory, "OAM")
+	NotifierLog = NfLog.WithField(logger_util.FieldCategory, "Notifier")
+	MetricsLog = NfLog.WithField(logger_util.FieldCategory, "Metrics")
 }
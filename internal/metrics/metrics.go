@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "nef"
+
+const (
+	PfdNotifySuccess = "success"
+	PfdNotifyFailure = "failure"
+)
+
+// ActiveCounts is the number of resources currently held by NEF
+type ActiveCounts struct {
+	Afs      int
+	TiSubs   int
+	PfdTrans int
+}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	sbiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "sbi",
+		Name:      "requests_total",
+		Help:      "Number of SBI requests handled by NEF.",
+	}, []string{"method", "route", "status"})
+
+	sbiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "sbi",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of SBI requests handled by NEF.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	consumerRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "requests_total",
+		Help:      "Number of requests sent by NEF to other NFs.",
+	}, []string{"service", "operation", "status"})
+
+	consumerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests sent by NEF to other NFs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "operation"})
+
+	pfdNotificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pfd",
+		Name:      "notifications_total",
+		Help:      "Number of PFD change notifications delivered to the subscribers.",
+	}, []string{"result"})
+
+	activeDescs = map[string]*prometheus.Desc{
+		"afs": prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_afs"),
+			"Number of AFs.", nil, nil),
+		"tiSubs": prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_ti_subscriptions"),
+			"Number of traffic influence subscriptions.", nil, nil),
+		"pfdTrans": prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_pfd_transactions"),
+			"Number of PFD management transactions.", nil, nil),
+	}
+
+	activeMu        sync.RWMutex
+	activeCountFunc func() ActiveCounts
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		sbiRequestsTotal,
+		sbiRequestDuration,
+		consumerRequestsTotal,
+		consumerRequestDuration,
+		pfdNotificationsTotal,
+		activeCollector{},
+	)
+}
+
+// SetActiveCountFunc sets the function collecting the active counts on scrape
+func SetActiveCountFunc(f func() ActiveCounts) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	activeCountFunc = f
+}
+
+type activeCollector struct{}
+
+func (activeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range activeDescs {
+		ch <- desc
+	}
+}
+
+func (activeCollector) Collect(ch chan<- prometheus.Metric) {
+	activeMu.RLock()
+	f := activeCountFunc
+	activeMu.RUnlock()
+	if f == nil {
+		return
+	}
+
+	counts := f()
+	ch <- prometheus.MustNewConstMetric(activeDescs["afs"], prometheus.GaugeValue, float64(counts.Afs))
+	ch <- prometheus.MustNewConstMetric(activeDescs["tiSubs"], prometheus.GaugeValue, float64(counts.TiSubs))
+	ch <- prometheus.MustNewConstMetric(activeDescs["pfdTrans"], prometheus.GaugeValue, float64(counts.PfdTrans))
+}
+
+// GinMiddleware returns a middleware counting the requests and their latency per route
+func GinMiddleware() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		start := time.Now()
+		gc.Next()
+
+		// Use the route pattern instead of the path to keep the cardinality bounded
+		route := gc.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := gc.Request.Method
+		sbiRequestsTotal.WithLabelValues(method, route, strconv.Itoa(gc.Writer.Status())).Inc()
+		sbiRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+type consumerOpKey struct{}
+
+type consumerOp struct {
+	service   string
+	operation string
+}
+
+// WithConsumerOp tags the context of a request to another NF with its service and operation.
+// Only the tagged requests are recorded by the instrumented HTTP client.
+func WithConsumerOp(ctx context.Context, service, operation string) context.Context {
+	return context.WithValue(ctx, consumerOpKey{}, consumerOp{service: service, operation: operation})
+}
+
+// InstrumentClient records the status and latency of the tagged requests sent by the client
+func InstrumentClient(client *http.Client) {
+	if _, ok := client.Transport.(*instrumentedTransport); ok {
+		return
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &instrumentedTransport{next: next}
+}
+
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op, ok := req.Context().Value(consumerOpKey{}).(consumerOp)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	rsp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(rsp.StatusCode)
+	}
+	consumerRequestsTotal.WithLabelValues(op.service, op.operation, status).Inc()
+	consumerRequestDuration.WithLabelValues(op.service, op.operation).Observe(time.Since(start).Seconds())
+	return rsp, err
+}
+
+func IncPfdNotification(result string) {
+	pfdNotificationsTotal.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/3gpp-traffic-influence/v1/:afID/subscriptions", func(gc *gin.Context) {
+		gc.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{
+		"/3gpp-traffic-influence/v1/af1/subscriptions",
+		"/3gpp-traffic-influence/v1/af2/subscriptions",
+		"/unknown",
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, float64(2), testutil.ToFloat64(sbiRequestsTotal.WithLabelValues(
+		http.MethodGet, "/3gpp-traffic-influence/v1/:afID/subscriptions", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(sbiRequestsTotal.WithLabelValues(
+		http.MethodGet, "unmatched", "404")))
+}
+
+func TestInstrumentClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{}
+	InstrumentClient(client)
+	InstrumentClient(client)
+
+	send := func(ctx context.Context) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, srv.URL, nil)
+		require.NoError(t, err)
+		rsp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, rsp.Body.Close())
+	}
+	send(WithConsumerOp(context.Background(), "nudr-dr", "AppDataPfdsAppIdDelete"))
+	// The request without operation is not recorded
+	send(context.Background())
+
+	require.Equal(t, float64(1), testutil.ToFloat64(consumerRequestsTotal.WithLabelValues(
+		"nudr-dr", "AppDataPfdsAppIdDelete", "204")))
+	require.Equal(t, 1, testutil.CollectAndCount(consumerRequestsTotal))
+}
+
+func TestActiveCollector(t *testing.T) {
+	SetActiveCountFunc(func() ActiveCounts {
+		return ActiveCounts{Afs: 2, TiSubs: 3, PfdTrans: 1}
+	})
+	defer SetActiveCountFunc(nil)
+
+	require.Equal(t, 3, testutil.CollectAndCount(activeCollector{}))
+}
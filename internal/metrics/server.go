@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+type Server struct {
+	httpServer *http.Server
+}
+
+// NewServer creates the server exposing the metrics at /metrics
+func NewServer(bindAddr string) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              bindAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+	}
+}
+
+func (s *Server) Run() {
+	logger.MetricsLog.Infof("Start metrics server (listen on %s)", s.httpServer.Addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.MetricsLog.Errorf("Metrics server error: %+v", err)
+	}
+	logger.MetricsLog.Warnf("Metrics server (listen on %s) stopped", s.httpServer.Addr)
+}
+
+func (s *Server) Stop() {
+	if err := s.httpServer.Close(); err != nil {
+		logger.MetricsLog.Errorf("Could not close metrics server: %#v", err)
+	}
+}
@@ -0,0 +1,18 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type Accuracy string
+
+// List of Accuracy
+const (
+	Accuracy_CGI_ECGI Accuracy = "CGI_ECGI"
+	Accuracy_TA_RA    Accuracy = "TA_RA"
+	Accuracy_PLMN     Accuracy = "PLMN"
+)
@@ -0,0 +1,22 @@
+/*
+ * Nsmf_EventExposure
+ *
+ * Session Management Event Exposure Service. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.3
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models_nef"
+)
+
+type AckOfNotify struct {
+	// Notification correlation ID of the acknowledged notification
+	NotifId string `json:"notifId" bson:"notifId"`
+
+	AckResult *models_nef.AfResultInfo `json:"ackResult" bson:"ackResult"`
+
+	Gpsi string `json:"gpsi,omitempty" bson:"gpsi"`
+}
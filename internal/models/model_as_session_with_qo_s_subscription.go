@@ -0,0 +1,41 @@
+/*
+ * 3gpp-as-session-with-qos
+ *
+ * API for setting up an AS session with required QoS. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+type AsSessionWithQoSSubscription struct {
+	Self string `json:"self,omitempty" bson:"self"`
+
+	SupportedFeatures string `json:"supportedFeatures,omitempty" bson:"supportedFeatures"`
+
+	Dnn string `json:"dnn,omitempty" bson:"dnn"`
+
+	Snssai *models.Snssai `json:"snssai,omitempty" bson:"snssai"`
+
+	NotificationDestination string `json:"notificationDestination" bson:"notificationDestination"`
+
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty" bson:"flowInfo"`
+
+	EthFlowInfo []models.EthFlowDescription `json:"ethFlowInfo,omitempty" bson:"ethFlowInfo"`
+
+	QosReference string `json:"qosReference,omitempty" bson:"qosReference"`
+
+	UeIpv4Addr string `json:"ueIpv4Addr,omitempty" bson:"ueIpv4Addr"`
+
+	UeIpv6Addr string `json:"ueIpv6Addr,omitempty" bson:"ueIpv6Addr"`
+
+	MacAddr string `json:"macAddr,omitempty" bson:"macAddr"`
+
+	UsageThreshold *models.UsageThreshold `json:"usageThreshold,omitempty" bson:"usageThreshold"`
+
+	RequestTestNotification bool `json:"requestTestNotification,omitempty" bson:"requestTestNotification"`
+}
@@ -0,0 +1,25 @@
+/*
+ * 3gpp-as-session-with-qos
+ *
+ * API for setting up an AS session with required QoS. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+type AsSessionWithQoSSubscriptionPatch struct {
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty" bson:"flowInfo"`
+
+	EthFlowInfo []models.EthFlowDescription `json:"ethFlowInfo,omitempty" bson:"ethFlowInfo"`
+
+	QosReference string `json:"qosReference,omitempty" bson:"qosReference"`
+
+	UsageThreshold *models.UsageThreshold `json:"usageThreshold,omitempty" bson:"usageThreshold"`
+
+	NotificationDestination string `json:"notificationDestination,omitempty" bson:"notificationDestination"`
+}
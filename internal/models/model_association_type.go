@@ -0,0 +1,17 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type AssociationType string
+
+// List of AssociationType
+const (
+	AssociationType_IMEI   AssociationType = "IMEI"
+	AssociationType_IMEISV AssociationType = "IMEISV"
+)
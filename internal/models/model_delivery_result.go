@@ -0,0 +1,23 @@
+/*
+ * 3gpp-device-triggering
+ *
+ * API for device trigger. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type DeliveryResult string
+
+// List of DeliveryResult
+const (
+	DeliveryResult_SUCCESS     DeliveryResult = "SUCCESS"
+	DeliveryResult_UNKNOWN     DeliveryResult = "UNKNOWN"
+	DeliveryResult_FAILURE     DeliveryResult = "FAILURE"
+	DeliveryResult_TRIGGERED   DeliveryResult = "TRIGGERED"
+	DeliveryResult_EXPIRED     DeliveryResult = "EXPIRED"
+	DeliveryResult_UNCONFIRMED DeliveryResult = "UNCONFIRMED"
+	DeliveryResult_REPLACED    DeliveryResult = "REPLACED"
+	DeliveryResult_TERMINATE   DeliveryResult = "TERMINATE"
+)
@@ -0,0 +1,44 @@
+/*
+ * 3gpp-device-triggering
+ *
+ * API for device trigger. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type DeviceTriggering struct {
+	// Link to the resource "Individual Device Triggering Transaction".
+	Self string `json:"self,omitempty" bson:"self"`
+
+	SupportedFeatures string `json:"supportedFeatures,omitempty" bson:"supportedFeatures"`
+
+	// Identifies a user
+	ExternalId string `json:"externalId,omitempty" bson:"externalId"`
+
+	// Identifies the MS internal PSTN/ISDN number allocated for a UE.
+	Msisdn string `json:"msisdn,omitempty" bson:"msisdn"`
+
+	// URI of a notification destination that the T8 message shall be delivered to.
+	NotificationDestination string `json:"notificationDestination" bson:"notificationDestination"`
+
+	// Set to true by the SCS/AS to request the NEF to send a test notification.
+	RequestTestNotification bool `json:"requestTestNotification,omitempty" bson:"requestTestNotification"`
+
+	// Unit: Second.
+	ValidityPeriod int32 `json:"validityPeriod" bson:"validityPeriod"`
+
+	Priority Priority `json:"priority" bson:"priority"`
+
+	// Identifies the application port number of the triggering application.
+	ApplicationPortId int32 `json:"applicationPortId" bson:"applicationPortId"`
+
+	// Identifies the application port number of the originating application.
+	AppSrcPortId int32 `json:"appSrcPortId,omitempty" bson:"appSrcPortId"`
+
+	// Base64-encoded device triggering payload.
+	TriggerPayload string `json:"triggerPayload" bson:"triggerPayload"`
+
+	DeliveryResult DeliveryResult `json:"deliveryResult,omitempty" bson:"deliveryResult"`
+}
@@ -0,0 +1,16 @@
+/*
+ * 3gpp-device-triggering
+ *
+ * API for device trigger. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type DeviceTriggeringDeliveryReportNotification struct {
+	// Link to the transaction resource to which this notification is related.
+	Transaction string `json:"transaction" bson:"transaction"`
+
+	Result DeliveryResult `json:"result" bson:"result"`
+}
@@ -0,0 +1,25 @@
+/*
+ * 3gpp-device-triggering
+ *
+ * API for device trigger. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type DeviceTriggeringPatch struct {
+	// Unit: Second.
+	ValidityPeriod int32 `json:"validityPeriod,omitempty" bson:"validityPeriod"`
+
+	Priority Priority `json:"priority,omitempty" bson:"priority"`
+
+	// Identifies the application port number of the triggering application.
+	ApplicationPortId int32 `json:"applicationPortId,omitempty" bson:"applicationPortId"`
+
+	// Base64-encoded device triggering payload.
+	TriggerPayload string `json:"triggerPayload,omitempty" bson:"triggerPayload"`
+
+	// URI of a notification destination that the T8 message shall be delivered to.
+	NotificationDestination string `json:"notificationDestination,omitempty" bson:"notificationDestination"`
+}
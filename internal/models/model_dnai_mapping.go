@@ -0,0 +1,17 @@
+package models
+
+// DnaiMapping is provided to AF by 3gpp-dnai-mapping, which is modeled after Nnef_DNAIMapping (TS 29.591).
+// It describes the DNAI of the edge application servers (EAS) configured by the operator.
+type DnaiMapping struct {
+	// Identifies the data network access where the EASs are deployed.
+	Dnai string `json:"dnai" bson:"dnai"`
+
+	// IPv4 address ranges and IPv6 prefixes of the EASs in CIDR notation.
+	EasIpAddrRanges []string `json:"easIpAddrRanges,omitempty" bson:"easIpAddrRanges"`
+
+	// FQDNs of the EASs.
+	EasFqdns []string `json:"easFqdns,omitempty" bson:"easFqdns"`
+
+	// Geographic zones served by the DNAI.
+	GeoZoneIds []string `json:"geoZoneIds,omitempty" bson:"geoZoneIds"`
+}
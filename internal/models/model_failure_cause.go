@@ -0,0 +1,25 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type FailureCause struct {
+	BssgpCause int32 `json:"bssgpCause,omitempty" bson:"bssgpCause"`
+
+	CauseType int32 `json:"causeType,omitempty" bson:"causeType"`
+
+	GmmCause int32 `json:"gmmCause,omitempty" bson:"gmmCause"`
+
+	RanapCause int32 `json:"ranapCause,omitempty" bson:"ranapCause"`
+
+	RanNasCause string `json:"ranNasCause,omitempty" bson:"ranNasCause"`
+
+	S1ApCause int32 `json:"s1ApCause,omitempty" bson:"s1ApCause"`
+
+	SmCause int32 `json:"smCause,omitempty" bson:"smCause"`
+}
@@ -0,0 +1,17 @@
+/*
+ * 3gpp-as-session-with-qos
+ *
+ * API for setting up an AS session with required QoS. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type FlowInfo struct {
+	// Indicates the IP flow.
+	FlowId int32 `json:"flowId" bson:"flowId"`
+
+	// Indicates the packet filters of the IP flow.
+	FlowDescriptions []string `json:"flowDescriptions,omitempty" bson:"flowDescriptions"`
+}
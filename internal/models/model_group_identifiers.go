@@ -0,0 +1,17 @@
+/*
+ * Nudm_SDM
+ *
+ * Nudm Subscriber Data Management Service. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 2.1.5
+ */
+
+package models
+
+type GroupIdentifiers struct {
+	ExtGroupId string `json:"extGroupId,omitempty" bson:"extGroupId"`
+
+	IntGroupId string `json:"intGroupId,omitempty" bson:"intGroupId"`
+
+	UeIdList []UeId `json:"ueIdList,omitempty" bson:"ueIdList"`
+}
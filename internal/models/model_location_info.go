@@ -0,0 +1,27 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+type LocationInfo struct {
+	AgeOfLocationInfo int32 `json:"ageOfLocationInfo,omitempty" bson:"ageOfLocationInfo"`
+
+	CellId string `json:"cellId,omitempty" bson:"cellId"`
+
+	EnodeBId string `json:"enodeBId,omitempty" bson:"enodeBId"`
+
+	RoutingAreaId string `json:"routingAreaId,omitempty" bson:"routingAreaId"`
+
+	TrackingAreaId string `json:"trackingAreaId,omitempty" bson:"trackingAreaId"`
+
+	PlmnId *models.PlmnId `json:"plmnId,omitempty" bson:"plmnId"`
+}
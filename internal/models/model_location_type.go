@@ -0,0 +1,17 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type LocationType string
+
+// List of LocationType
+const (
+	LocationType_CURRENT_LOCATION    LocationType = "CURRENT_LOCATION"
+	LocationType_LAST_KNOWN_LOCATION LocationType = "LAST_KNOWN_LOCATION"
+)
@@ -0,0 +1,37 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/free5gc/openapi/models"
+)
+
+type MonitoringEventReport struct {
+	ImeiChange AssociationType `json:"imeiChange,omitempty" bson:"imeiChange"`
+
+	ExternalId string `json:"externalId,omitempty" bson:"externalId"`
+
+	LocationInfo *LocationInfo `json:"locationInfo,omitempty" bson:"locationInfo"`
+
+	Msisdn string `json:"msisdn,omitempty" bson:"msisdn"`
+
+	MonitoringType MonitoringType `json:"monitoringType" bson:"monitoringType"`
+
+	PlmnId *models.PlmnId `json:"plmnId,omitempty" bson:"plmnId"`
+
+	ReachabilityType ReachabilityType `json:"reachabilityType,omitempty" bson:"reachabilityType"`
+
+	RoamingStatus bool `json:"roamingStatus,omitempty" bson:"roamingStatus"`
+
+	FailureCause *FailureCause `json:"failureCause,omitempty" bson:"failureCause"`
+
+	EventTime *time.Time `json:"eventTime,omitempty" bson:"eventTime"`
+}
@@ -0,0 +1,60 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"time"
+)
+
+type MonitoringEventSubscription struct {
+	MtcProviderId string `json:"mtcProviderId,omitempty" bson:"mtcProviderId"`
+
+	ExternalId string `json:"externalId,omitempty" bson:"externalId"`
+
+	Msisdn string `json:"msisdn,omitempty" bson:"msisdn"`
+
+	ExternalGroupId string `json:"externalGroupId,omitempty" bson:"externalGroupId"`
+
+	Ipv4Addr string `json:"ipv4Addr,omitempty" bson:"ipv4Addr"`
+
+	Ipv6Addr string `json:"ipv6Addr,omitempty" bson:"ipv6Addr"`
+
+	NotificationDestination string `json:"notificationDestination" bson:"notificationDestination"`
+
+	RequestTestNotification bool `json:"requestTestNotification,omitempty" bson:"requestTestNotification"`
+
+	Self string `json:"self,omitempty" bson:"self"`
+
+	SupportedFeatures string `json:"supportedFeatures,omitempty" bson:"supportedFeatures"`
+
+	MonitoringType MonitoringType `json:"monitoringType" bson:"monitoringType"`
+
+	MaximumNumberOfReports int32 `json:"maximumNumberOfReports,omitempty" bson:"maximumNumberOfReports"`
+
+	MonitorExpireTime *time.Time `json:"monitorExpireTime,omitempty" bson:"monitorExpireTime"`
+
+	RepPeriod int32 `json:"repPeriod,omitempty" bson:"repPeriod"`
+
+	MaximumDetectionTime int32 `json:"maximumDetectionTime,omitempty" bson:"maximumDetectionTime"`
+
+	ReachabilityType ReachabilityType `json:"reachabilityType,omitempty" bson:"reachabilityType"`
+
+	MaximumLatency int32 `json:"maximumLatency,omitempty" bson:"maximumLatency"`
+
+	MaximumResponseTime int32 `json:"maximumResponseTime,omitempty" bson:"maximumResponseTime"`
+
+	LocationType LocationType `json:"locationType,omitempty" bson:"locationType"`
+
+	Accuracy Accuracy `json:"accuracy,omitempty" bson:"accuracy"`
+
+	MonitoringEventReport *MonitoringEventReport `json:"monitoringEventReport,omitempty" bson:"monitoringEventReport"`
+
+	// Identifies the additional monitoring event reports, e.g. the immediate reports of more than one event.
+	AddnMonEventReports []MonitoringEventReport `json:"addnMonEventReports,omitempty" bson:"addnMonEventReports"`
+}
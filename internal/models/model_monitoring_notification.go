@@ -0,0 +1,19 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type MonitoringNotification struct {
+	// Link to the subscription resource to which this notification is related.
+	Subscription string `json:"subscription" bson:"subscription"`
+
+	MonitoringEventReports []MonitoringEventReport `json:"monitoringEventReports,omitempty" bson:"monitoringEventReports"`
+
+	// Set to true to indicate that the monitoring subscription is cancelled by the NEF.
+	CancelInd bool `json:"cancelInd,omitempty" bson:"cancelInd"`
+}
@@ -0,0 +1,22 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type MonitoringType string
+
+// List of MonitoringType
+const (
+	MonitoringType_LOSS_OF_CONNECTIVITY            MonitoringType = "LOSS_OF_CONNECTIVITY"
+	MonitoringType_UE_REACHABILITY                 MonitoringType = "UE_REACHABILITY"
+	MonitoringType_LOCATION_REPORTING              MonitoringType = "LOCATION_REPORTING"
+	MonitoringType_CHANGE_OF_IMSI_IMEI_ASSOCIATION MonitoringType = "CHANGE_OF_IMSI_IMEI_ASSOCIATION"
+	MonitoringType_ROAMING_STATUS                  MonitoringType = "ROAMING_STATUS"
+	MonitoringType_COMMUNICATION_FAILURE           MonitoringType = "COMMUNICATION_FAILURE"
+	MonitoringType_AVAILABILITY_AFTER_DDN_FAILURE  MonitoringType = "AVAILABILITY_AFTER_DDN_FAILURE"
+)
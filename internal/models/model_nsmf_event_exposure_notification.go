@@ -0,0 +1,24 @@
+/*
+ * Nsmf_EventExposure
+ *
+ * Session Management Event Exposure Service. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.3
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+type NsmfEventExposureNotification struct {
+	// Notification correlation ID
+	NotifId string `json:"notifId" bson:"notifId"`
+
+	// Notifications about Individual Events
+	EventNotifs []models.EventNotification `json:"eventNotifs" bson:"eventNotifs"`
+
+	// Notification URI used to send the acknowledgement of the notification, present if it's expected
+	AckUri string `json:"ackUri,omitempty" bson:"ackUri"`
+}
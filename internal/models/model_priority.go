@@ -0,0 +1,17 @@
+/*
+ * 3gpp-device-triggering
+ *
+ * API for device trigger. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type Priority string
+
+// List of Priority
+const (
+	Priority_NO_PRIORITY Priority = "NO_PRIORITY"
+	Priority_PRIORITY    Priority = "PRIORITY"
+)
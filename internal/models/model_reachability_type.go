@@ -0,0 +1,17 @@
+/*
+ * 3gpp-monitoring-event
+ *
+ * API for Monitoring Event. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type ReachabilityType string
+
+// List of ReachabilityType
+const (
+	ReachabilityType_SMS  ReachabilityType = "SMS"
+	ReachabilityType_DATA ReachabilityType = "DATA"
+)
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// TrafficInfluExpiryNotification is sent by NEF to the notificationDestination of a traffic influence
+// subscription which is removed at the end of its temporal validities. It's not defined in TS 29.522.
+type TrafficInfluExpiryNotification struct {
+	// Identifies an NEF Northbound interface transaction, generated by the AF.
+	AfTransId string `json:"afTransId,omitempty" bson:"afTransId"`
+
+	// Link to the removed subscription resource.
+	Subscription string `json:"subscription" bson:"subscription"`
+
+	// The end of the latest temporal validity.
+	ExpiryTime *time.Time `json:"expiryTime" bson:"expiryTime"`
+}
@@ -0,0 +1,15 @@
+/*
+ * Nudm_SDM
+ *
+ * Nudm Subscriber Data Management Service. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 2.1.5
+ */
+
+package models
+
+type UeId struct {
+	Supi string `json:"supi" bson:"supi"`
+
+	GpsiList []string `json:"gpsiList,omitempty" bson:"gpsiList"`
+}
@@ -0,0 +1,28 @@
+/*
+ * 3gpp-as-session-with-qos
+ *
+ * API for setting up an AS session with required QoS. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+import (
+	"github.com/free5gc/openapi/models"
+)
+
+type UserPlaneEventReport struct {
+	Event UserPlaneEvent `json:"event" bson:"event"`
+
+	AccumulatedUsage *models.AccumulatedUsage `json:"accumulatedUsage,omitempty" bson:"accumulatedUsage"`
+
+	// Identifies the affected flows that were sent during event subscription.
+	FlowIds []int32 `json:"flowIds,omitempty" bson:"flowIds"`
+
+	AppliedQosRef string `json:"appliedQosRef,omitempty" bson:"appliedQosRef"`
+
+	PlmnId *models.PlmnId `json:"plmnId,omitempty" bson:"plmnId"`
+
+	RatType models.RatType `json:"ratType,omitempty" bson:"ratType"`
+}
@@ -0,0 +1,16 @@
+/*
+ * 3gpp-as-session-with-qos
+ *
+ * API for setting up an AS session with required QoS. © 2021, 3GPP Organizational Partners (ARIB, ATIS, CCSA, ETSI, TSDSI, TTA, TTC). All rights reserved.
+ *
+ * API version: 1.1.0
+ */
+
+package models
+
+type UserPlaneNotificationData struct {
+	// Link to the transaction resource to which this notification is related.
+	Transaction string `json:"transaction" bson:"transaction"`
+
+	EventReports []UserPlaneEventReport `json:"eventReports" bson:"eventReports"`
+}
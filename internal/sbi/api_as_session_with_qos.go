@@ -0,0 +1,115 @@
+package sbi
+
+import (
+	"net/http"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getAsSessionWithQoSEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/subscriptions",
+			APIFunc: s.apiGetAsSessionWithQoSSubscriptions,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:scsAsID/subscriptions",
+			APIFunc: s.apiPostAsSessionWithQoSSubscription,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiGetIndividualAsSessionWithQoSSubscription,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiPutIndividualAsSessionWithQoSSubscription,
+		},
+		{
+			Method:  http.MethodPatch,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiPatchIndividualAsSessionWithQoSSubscription,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiDeleteIndividualAsSessionWithQoSSubscription,
+		},
+	}
+}
+
+func (s *Server) apiGetAsSessionWithQoSSubscriptions(gc *gin.Context) {
+	hdlRsp := s.Processor().GetAsSessionWithQoSSubscriptions(
+		gc.Param("scsAsID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostAsSessionWithQoSSubscription(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var qosSub nef_models.AsSessionWithQoSSubscription
+	if err := s.deserializeData(gc, &qosSub, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PostAsSessionWithQoSSubscription(
+		gc.Param("scsAsID"), &qosSub)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetIndividualAsSessionWithQoSSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().GetIndividualAsSessionWithQoSSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPutIndividualAsSessionWithQoSSubscription(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var qosSub nef_models.AsSessionWithQoSSubscription
+	if err := s.deserializeData(gc, &qosSub, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PutIndividualAsSessionWithQoSSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"), &qosSub)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPatchIndividualAsSessionWithQoSSubscription(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var qosSubPatch nef_models.AsSessionWithQoSSubscriptionPatch
+	if err := s.deserializeData(gc, &qosSubPatch, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PatchIndividualAsSessionWithQoSSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"), &qosSubPatch)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiDeleteIndividualAsSessionWithQoSSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().DeleteIndividualAsSessionWithQoSSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
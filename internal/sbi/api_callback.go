@@ -3,7 +3,9 @@ package sbi
 import (
 	"net/http"
 
+	nef_models "github.com/free5gc/nef/internal/models"
 	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/models_nef"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,6 +16,42 @@ func (s *Server) getCallbackEndpoints() []Endpoint {
 			Pattern: "/notification/smf",
 			APIFunc: s.apiPostSmfNotification,
 		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/amf",
+			APIFunc: s.apiPostAmfNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/udm/:notifCorreID",
+			APIFunc: s.apiPostUdmNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/pcf/:notifCorreID/notify",
+			APIFunc: s.apiPostPcfEventNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/pcf/:notifCorreID/terminate",
+			APIFunc: s.apiPostPcfTerminationNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/nrf",
+			APIFunc: s.apiPostNfStatusNotification,
+		},
+	}
+}
+
+// getAfAckEndpoints returns the callback endpoints used by AF, which are authorized by authorizeAfAck
+func (s *Server) getAfAckEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:notifCorreID",
+			APIFunc: s.apiPostAfAck,
+		},
 	}
 }
 
@@ -23,7 +61,7 @@ func (s *Server) apiPostSmfNotification(gc *gin.Context) {
 		return
 	}
 
-	var eeNotif models.NsmfEventExposureNotification
+	var eeNotif nef_models.NsmfEventExposureNotification
 	if err := s.deserializeData(gc, &eeNotif, contentType); err != nil {
 		return
 	}
@@ -32,3 +70,99 @@ func (s *Server) apiPostSmfNotification(gc *gin.Context) {
 
 	s.buildAndSendHttpResponse(gc, hdlRsp, false)
 }
+
+func (s *Server) apiPostAfAck(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var afAck models_nef.AfAckInfo
+	if err := s.deserializeData(gc, &afAck, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PostAfAck(gc.Param("notifCorreID"), &afAck)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostAmfNotification(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var amfNotif models.AmfEventNotification
+	if err := s.deserializeData(gc, &amfNotif, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().AmfNotification(&amfNotif)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostUdmNotification(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var monReports []models.MonitoringReport
+	if err := s.deserializeData(gc, &monReports, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().UdmNotification(gc.Param("notifCorreID"), monReports)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostPcfEventNotification(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var evNotif models.EventsNotification
+	if err := s.deserializeData(gc, &evNotif, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PcfEventNotification(gc.Param("notifCorreID"), &evNotif)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostPcfTerminationNotification(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var termInfo models.TerminationInfo
+	if err := s.deserializeData(gc, &termInfo, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PcfTerminationNotification(gc.Param("notifCorreID"), &termInfo)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostNfStatusNotification(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var notifData models.NotificationData
+	if err := s.deserializeData(gc, &notifData, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().NfStatusNotification(&notifData)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
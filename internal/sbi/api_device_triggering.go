@@ -0,0 +1,115 @@
+package sbi
+
+import (
+	"net/http"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getDeviceTriggeringEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/transactions",
+			APIFunc: s.apiGetDeviceTriggeringTransactions,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:scsAsID/transactions",
+			APIFunc: s.apiPostDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiGetIndividualDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiPutIndividualDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodPatch,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiPatchIndividualDeviceTriggeringTransaction,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/:scsAsID/transactions/:transID",
+			APIFunc: s.apiDeleteIndividualDeviceTriggeringTransaction,
+		},
+	}
+}
+
+func (s *Server) apiGetDeviceTriggeringTransactions(gc *gin.Context) {
+	hdlRsp := s.Processor().GetDeviceTriggeringTransactions(
+		gc.Param("scsAsID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostDeviceTriggeringTransaction(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var trigger nef_models.DeviceTriggering
+	if err := s.deserializeData(gc, &trigger, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PostDeviceTriggeringTransaction(
+		gc.Param("scsAsID"), &trigger)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	hdlRsp := s.Processor().GetIndividualDeviceTriggeringTransaction(
+		gc.Param("scsAsID"), gc.Param("transID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPutIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var trigger nef_models.DeviceTriggering
+	if err := s.deserializeData(gc, &trigger, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PutIndividualDeviceTriggeringTransaction(
+		gc.Param("scsAsID"), gc.Param("transID"), &trigger)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPatchIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var triggerPatch nef_models.DeviceTriggeringPatch
+	if err := s.deserializeData(gc, &triggerPatch, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PatchIndividualDeviceTriggeringTransaction(
+		gc.Param("scsAsID"), gc.Param("transID"), &triggerPatch)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiDeleteIndividualDeviceTriggeringTransaction(gc *gin.Context) {
+	hdlRsp := s.Processor().DeleteIndividualDeviceTriggeringTransaction(
+		gc.Param("scsAsID"), gc.Param("transID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
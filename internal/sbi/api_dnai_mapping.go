@@ -0,0 +1,36 @@
+package sbi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getDnaiMappingEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:afID/mappings",
+			APIFunc: s.apiGetDnaiMappings,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:afID/mappings/:dnai",
+			APIFunc: s.apiGetIndividualDnaiMapping,
+		},
+	}
+}
+
+func (s *Server) apiGetDnaiMappings(gc *gin.Context) {
+	hdlRsp := s.Processor().GetDnaiMappings(
+		gc.Param("afID"), gc.Query("eas-ip-addr"), gc.Query("eas-fqdn"), gc.Query("geo-zone-id"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetIndividualDnaiMapping(gc *gin.Context) {
+	hdlRsp := s.Processor().GetIndividualDnaiMapping(
+		gc.Param("afID"), gc.Param("dnai"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
@@ -0,0 +1,71 @@
+package sbi
+
+import (
+	"net/http"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getMonitoringEventEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/subscriptions",
+			APIFunc: s.apiGetMonitoringEventSubscriptions,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/:scsAsID/subscriptions",
+			APIFunc: s.apiPostMonitoringEventSubscription,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiGetIndividualMonitoringEventSubscription,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/:scsAsID/subscriptions/:subID",
+			APIFunc: s.apiDeleteIndividualMonitoringEventSubscription,
+		},
+	}
+}
+
+func (s *Server) apiGetMonitoringEventSubscriptions(gc *gin.Context) {
+	hdlRsp := s.Processor().GetMonitoringEventSubscriptions(
+		gc.Param("scsAsID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostMonitoringEventSubscription(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var meSub nef_models.MonitoringEventSubscription
+	if err := s.deserializeData(gc, &meSub, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PostMonitoringEventSubscription(
+		gc.Param("scsAsID"), &meSub)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetIndividualMonitoringEventSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().GetIndividualMonitoringEventSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiDeleteIndividualMonitoringEventSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().DeleteIndividualMonitoringEventSubscription(
+		gc.Param("scsAsID"), gc.Param("subID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
@@ -13,6 +13,56 @@ func (s *Server) getOamEndpoints() []Endpoint {
 			Pattern: "/",
 			APIFunc: s.apiGetOamIndex,
 		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/afs",
+			APIFunc: s.apiGetOamAfs,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/afs/:afID",
+			APIFunc: s.apiGetOamAf,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/pfd-subscriptions",
+			APIFunc: s.apiGetOamPfdSubscriptions,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/pfd-dead-letters",
+			APIFunc: s.apiGetOamPfdDeadLetters,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/af-dead-letters",
+			APIFunc: s.apiGetOamAfDeadLetters,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/counters",
+			APIFunc: s.apiGetOamCounters,
+		},
+	}
+}
+
+func (s *Server) getOamAdminEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method:  http.MethodPost,
+			Pattern: "/config/reload",
+			APIFunc: s.apiPostOamConfigReload,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/afs/:afID",
+			APIFunc: s.apiDeleteOamAf,
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/afs/:afID/subscriptions/:subID",
+			APIFunc: s.apiDeleteOamAfSubscription,
+		},
 	}
 }
 
@@ -20,3 +70,48 @@ func (s *Server) apiGetOamIndex(gc *gin.Context) {
 	hdlRsp := s.Processor().GetOamIndex()
 	s.buildAndSendHttpResponse(gc, hdlRsp, false)
 }
+
+func (s *Server) apiGetOamAfs(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamAfs()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetOamAf(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamAf(gc.Param("afID"))
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetOamPfdSubscriptions(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamPfdSubscriptions()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetOamPfdDeadLetters(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamPfdDeadLetters()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetOamAfDeadLetters(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamAfDeadLetters()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiGetOamCounters(gc *gin.Context) {
+	hdlRsp := s.Processor().GetOamCounters()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiDeleteOamAf(gc *gin.Context) {
+	hdlRsp := s.Processor().DeleteOamAf(gc.Param("afID"))
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiDeleteOamAfSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().DeleteOamAfSubscription(gc.Param("afID"), gc.Param("subID"))
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPostOamConfigReload(gc *gin.Context) {
+	hdlRsp := s.Processor().PostOamConfigReload()
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
@@ -19,11 +19,26 @@ func (s *Server) getPFDFEndpoints() []Endpoint {
 			Pattern: "/applications/:appID",
 			APIFunc: s.apiGetIndividualApplicationPFD,
 		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/subscriptions",
+			APIFunc: s.apiGetPFDSubscriptions,
+		},
 		{
 			Method:  http.MethodPost,
 			Pattern: "/subscriptions",
 			APIFunc: s.apiPostPFDSubscriptions,
 		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/subscriptions/:subID",
+			APIFunc: s.apiGetIndividualPFDSubscription,
+		},
+		{
+			Method:  http.MethodPut,
+			Pattern: "/subscriptions/:subID",
+			APIFunc: s.apiPutIndividualPFDSubscription,
+		},
 		{
 			Method:  http.MethodDelete,
 			Pattern: "/subscriptions/:subID",
@@ -46,6 +61,12 @@ func (s *Server) apiGetIndividualApplicationPFD(gc *gin.Context) {
 	s.buildAndSendHttpResponse(gc, hdlRsp, false)
 }
 
+func (s *Server) apiGetPFDSubscriptions(gc *gin.Context) {
+	hdlRsp := s.Processor().GetPFDSubscriptions()
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
 func (s *Server) apiPostPFDSubscriptions(gc *gin.Context) {
 	contentType, err := checkContentTypeIsJSON(gc)
 	if err != nil {
@@ -62,6 +83,28 @@ func (s *Server) apiPostPFDSubscriptions(gc *gin.Context) {
 	s.buildAndSendHttpResponse(gc, hdlRsp, false)
 }
 
+func (s *Server) apiGetIndividualPFDSubscription(gc *gin.Context) {
+	hdlRsp := s.Processor().GetIndividualPFDSubscription(gc.Param("subID"))
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
+func (s *Server) apiPutIndividualPFDSubscription(gc *gin.Context) {
+	contentType, err := checkContentTypeIsJSON(gc)
+	if err != nil {
+		return
+	}
+
+	var pfdSubsc models.PfdSubscription
+	if err := s.deserializeData(gc, &pfdSubsc, contentType); err != nil {
+		return
+	}
+
+	hdlRsp := s.Processor().PutIndividualPFDSubscription(gc.Param("subID"), &pfdSubsc)
+
+	s.buildAndSendHttpResponse(gc, hdlRsp, false)
+}
+
 func (s *Server) apiDeleteIndividualPFDSubscription(gc *gin.Context) {
 	hdlRsp := s.Processor().DeleteIndividualPFDSubscription(gc.Param("subID"))
 
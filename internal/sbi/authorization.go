@@ -0,0 +1,250 @@
+package sbi
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/util"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/oauth"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// AfIdentityKey is the gin context key of the AF identity taken from the client certificate
+const AfIdentityKey = "afIdentity"
+
+// identifyAf returns a middleware taking the AF identity from the SAN of the verified
+// client certificate, and rejecting the requests whose AF ID in the URL path is another AF
+func (s *Server) identifyAf() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		afIdentity := clientCertIdentity(gc.Request.TLS)
+		if afIdentity == "" {
+			return
+		}
+		gc.Set(AfIdentityKey, afIdentity)
+
+		for _, param := range gc.Params {
+			if param.Key != "afID" && param.Key != "scsAsID" {
+				continue
+			}
+			if param.Value != afIdentity {
+				detail := fmt.Sprintf("AF ID[%s] in path does not match AF[%s] of client certificate",
+					param.Value, afIdentity)
+				logger.SBILog.Warnln(detail)
+				gc.AbortWithStatusJSON(http.StatusForbidden, util.ProblemDetailsForbidden(detail))
+				return
+			}
+		}
+	}
+}
+
+// authorizeAf returns a middleware rejecting the AFs which are not allowed to use the API
+func (s *Server) authorizeAf(api string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		cfg := s.Config()
+		if !cfg.IsAfAuthzEnabled() {
+			return
+		}
+
+		afID := gc.Param("afID")
+		if afID == "" {
+			afID = gc.Param("scsAsID")
+		}
+
+		if detail := s.afPolicyViolation(afID, api); detail != "" {
+			logger.SBILog.Warnln(detail)
+			gc.AbortWithStatusJSON(http.StatusForbidden, util.ProblemDetailsForbidden(detail))
+		}
+	}
+}
+
+// authorizeAfAck returns a middleware rejecting the AF acknowledgements of the subscriptions
+// of other AFs. The AF is resolved by the notification correlation ID, and must match
+// the AF identity of the client certificate and be allowed to use the traffic influence API.
+func (s *Server) authorizeAfAck() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		af, _ := s.Context().FindAfSub(gc.Param("notifCorreID"))
+		if af == nil {
+			// The processor responds that the subscription is not found
+			return
+		}
+
+		var detail string
+		if afIdentity := clientCertIdentity(gc.Request.TLS); afIdentity != "" && afIdentity != af.AfID {
+			detail = fmt.Sprintf("AF[%s] of client certificate is not the owner of the subscription", afIdentity)
+		} else if s.Config().IsAfAuthzEnabled() {
+			detail = s.afPolicyViolation(af.AfID, factory.ServiceTraffInflu)
+		}
+		if detail != "" {
+			logger.SBILog.Warnln(detail)
+			gc.AbortWithStatusJSON(http.StatusForbidden, util.ProblemDetailsForbidden(detail))
+		}
+	}
+}
+
+// afPolicyViolation returns why the AF is not allowed to use the API, empty if it's allowed
+func (s *Server) afPolicyViolation(afID, api string) string {
+	policy := s.Config().AfPolicy(afID)
+	if policy == nil {
+		return fmt.Sprintf("AF[%s] is not allowed", afID)
+	}
+	if !policy.IsApiAllowed(api) {
+		return fmt.Sprintf("AF[%s] is not allowed to use %s", afID, api)
+	}
+	return ""
+}
+
+// authenticateAdmin returns a middleware checking the bearer token of the OAM admin APIs
+func (s *Server) authenticateAdmin() gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		adminToken := s.Config().OamAdminToken()
+		if adminToken == "" {
+			gc.AbortWithStatusJSON(http.StatusForbidden,
+				util.ProblemDetailsForbidden("OAM admin APIs are disabled"))
+			return
+		}
+
+		authFields := strings.Fields(gc.GetHeader("Authorization"))
+		if len(authFields) != 2 || !strings.EqualFold(authFields[0], "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(authFields[1]), []byte(adminToken)) != 1 {
+			logger.SBILog.Warnf("Unauthenticated OAM admin request from %s", gc.ClientIP())
+			gc.Header("WWW-Authenticate", "Bearer")
+			gc.AbortWithStatusJSON(http.StatusUnauthorized,
+				util.ProblemDetailsUnauthorized("Invalid admin token"))
+		}
+	}
+}
+
+// authorizeNf returns a middleware validating the OAuth2 access token issued by NRF
+// if NRF requires OAuth2 for the NF-facing services
+func (s *Server) authorizeNf(serviceName models.ServiceName) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		nefCtx := s.Context()
+		if !nefCtx.OAuth2Required() {
+			return
+		}
+
+		status := http.StatusUnauthorized
+		verifyKey, err := s.nrfKey.get(s.Config().NrfCertPem())
+		if err != nil {
+			err = fmt.Errorf("load NRF certificate: %w", err)
+		} else {
+			status, err = verifyAccessToken(gc.GetHeader("Authorization"), serviceName,
+				nefCtx.NfInstID(), verifyKey)
+		}
+		if err == nil {
+			return
+		}
+
+		logger.SBILog.Warnf("Access token of %s is rejected: %+v", serviceName, err)
+		if status == http.StatusUnauthorized {
+			gc.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			gc.AbortWithStatusJSON(status, util.ProblemDetailsUnauthorized(err.Error()))
+		} else {
+			gc.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+			gc.AbortWithStatusJSON(status, util.ProblemDetailsForbidden(err.Error()))
+		}
+	}
+}
+
+// verifyAccessToken validates the bearer token (TS 33.501 13.4.1) and returns the HTTP status to reject with:
+// 401 if the token is missing, malformed, expired or not signed by NRF,
+// 403 if the scope or audience does not cover this NEF.
+func verifyAccessToken(
+	authorization string,
+	serviceName models.ServiceName,
+	nfInstID string,
+	verifyKey *rsa.PublicKey,
+) (int, error) {
+	authFields := strings.Fields(authorization)
+	if len(authFields) != 2 || !strings.EqualFold(authFields[0], "Bearer") {
+		return http.StatusUnauthorized, errors.New("missing bearer token")
+	}
+
+	claims := &models.AccessTokenClaims{}
+	_, err := jwt.ParseWithClaims(authFields[1], claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method[%v]", token.Header["alg"])
+		}
+		return verifyKey, nil
+	})
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("parse token: %w", err)
+	}
+
+	// The "exp" of AccessTokenClaims shadows the one of jwt.StandardClaims, so check it here
+	if int64(claims.Exp) <= time.Now().Unix() {
+		return http.StatusUnauthorized, errors.New("token is expired")
+	}
+	if !containsField(claims.Scope, string(serviceName)) {
+		return http.StatusForbidden, fmt.Errorf("scope[%s] does not include %s", claims.Scope, serviceName)
+	}
+	if !isAudienceMatched(claims.Aud, nfInstID) {
+		return http.StatusForbidden, fmt.Errorf("audience[%v] does not include NEF[%s]", claims.Aud, nfInstID)
+	}
+	return http.StatusOK, nil
+}
+
+// nrfPublicKey caches the public key of the NRF certificate verifying the access tokens.
+// The key is loaded again once nrfCertPem is changed by reloading the configuration.
+type nrfPublicKey struct {
+	mu   sync.RWMutex
+	path string
+	key  *rsa.PublicKey
+}
+
+func (k *nrfPublicKey) get(path string) (*rsa.PublicKey, error) {
+	k.mu.RLock()
+	if k.key != nil && k.path == path {
+		defer k.mu.RUnlock()
+		return k.key, nil
+	}
+	k.mu.RUnlock()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key != nil && k.path == path {
+		return k.key, nil
+	}
+	key, err := oauth.ParsePublicKeyFromPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	k.path, k.key = path, key
+	logger.SBILog.Infof("NRF public key is loaded from [%s]", path)
+	return key, nil
+}
+
+// isAudienceMatched checks the "aud" claim, which is either the NF type
+// or a list of NF instance IDs of the NF service producers (TS 29.510 AccessTokenClaims)
+func isAudienceMatched(aud interface{}, nfInstID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == string(models.NfType_NEF) || v == nfInstID
+	case []interface{}:
+		for _, item := range v {
+			if id, ok := item.(string); ok && id == nfInstID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsField(fields, str string) bool {
+	for _, f := range strings.Fields(fields) {
+		if f == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package sbi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const testNefInstID = "6b0d5c3e-4f1a-4c55-9d3e-2d0e8d9f7a01"
+
+func genTestNrfCert(t *testing.T) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nrf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(t.TempDir(), "nrf.pem")
+	err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	return key, certPath
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, scope string, aud interface{}, exp time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS512, models.AccessTokenClaims{
+		Iss:   "nrf",
+		Sub:   "af-nf",
+		Aud:   aud,
+		Scope: scope,
+		Exp:   int32(exp.Unix()),
+	})
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return "Bearer " + signed
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	nrfKey, certPath := genTestNrfCert(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	verifyKey, err := (&nrfPublicKey{}).get(certPath)
+	require.NoError(t, err)
+
+	validExp := time.Now().Add(time.Hour)
+	pfdScope := string(models.ServiceName_NNEF_PFDMANAGEMENT)
+
+	testCases := []struct {
+		description    string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Missing token, should return 401",
+			authorization:  "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "TC2: Token not signed by NRF, should return 401",
+			authorization:  signTestToken(t, otherKey, pfdScope, []interface{}{testNefInstID}, validExp),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "TC3: Expired token, should return 401",
+			authorization:  signTestToken(t, nrfKey, pfdScope, []interface{}{testNefInstID}, time.Now().Add(-time.Minute)),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "TC4: Scope without the service, should return 403",
+			authorization:  signTestToken(t, nrfKey, "nnef-oam", []interface{}{testNefInstID}, validExp),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC5: Audience of another NF instance, should return 403",
+			authorization:  signTestToken(t, nrfKey, pfdScope, []interface{}{"another-nf"}, validExp),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC6: Valid token for NEF instance, should pass",
+			authorization:  signTestToken(t, nrfKey, "nnef-oam "+pfdScope, []interface{}{testNefInstID}, validExp),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC7: Valid token for NEF type, should pass",
+			authorization:  signTestToken(t, nrfKey, pfdScope, string(models.NfType_NEF), validExp),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			status, err := verifyAccessToken(tc.authorization,
+				models.ServiceName_NNEF_PFDMANAGEMENT, testNefInstID, verifyKey)
+			require.Equal(t, tc.expectedStatus, status)
+			if tc.expectedStatus == http.StatusOK {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestNrfPublicKey(t *testing.T) {
+	nrfKey, certPath := genTestNrfCert(t)
+	var k nrfPublicKey
+
+	_, err := k.get(filepath.Join(t.TempDir(), "missing.pem"))
+	require.Error(t, err)
+
+	key, err := k.get(certPath)
+	require.NoError(t, err)
+	require.True(t, nrfKey.PublicKey.Equal(key))
+
+	// The certificate is parsed once, and not read again until the path is changed
+	require.NoError(t, os.Remove(certPath))
+	cached, err := k.get(certPath)
+	require.NoError(t, err)
+	require.Same(t, key, cached)
+
+	newNrfKey, newCertPath := genTestNrfCert(t)
+	key, err = k.get(newCertPath)
+	require.NoError(t, err)
+	require.True(t, newNrfKey.PublicKey.Equal(key))
+}
+
+func TestIdentifyAf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use((&Server{}).identifyAf())
+	router.GET("/:scsAsID/subscriptions", func(gc *gin.Context) {
+		gc.String(http.StatusOK, gc.GetString(AfIdentityKey))
+	})
+
+	testCases := []struct {
+		description    string
+		certDNSName    string
+		scsAsID        string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			description:    "TC1: Without client certificate, should pass",
+			scsAsID:        "af1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC2: AF ID matches client certificate, should pass with AF identity",
+			certDNSName:    "af1",
+			scsAsID:        "af1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "af1",
+		},
+		{
+			description:    "TC3: AF ID of another AF, should return 403",
+			certDNSName:    "af1",
+			scsAsID:        "af2",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.scsAsID+"/subscriptions", nil)
+			if tc.certDNSName != "" {
+				req.TLS = &tls.ConnectionState{
+					PeerCertificates: []*x509.Certificate{{DNSNames: []string{tc.certDNSName}}},
+				}
+			}
+			rsp := httptest.NewRecorder()
+			router.ServeHTTP(rsp, req)
+			require.Equal(t, tc.expectedStatus, rsp.Code)
+			if tc.expectedStatus == http.StatusOK {
+				require.Equal(t, tc.expectedBody, rsp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package consumer
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
+	"github.com/free5gc/openapi/Namf_EventExposure"
+	"github.com/free5gc/openapi/models"
+)
+
+type namfService struct {
+	consumer *Consumer
+
+	mu      sync.RWMutex
+	clients map[string]*Namf_EventExposure.APIClient
+}
+
+func (s *namfService) getClient(uri string) *Namf_EventExposure.APIClient {
+	s.mu.RLock()
+	if client, ok := s.clients[uri]; ok {
+		defer s.mu.RUnlock()
+		return client
+	} else {
+		configuration := Namf_EventExposure.NewConfiguration()
+		configuration.SetBasePath(uri)
+		cli := Namf_EventExposure.NewAPIClient(configuration)
+
+		s.mu.RUnlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.clients[uri] = cli
+		return cli
+	}
+}
+
+func (s *namfService) AmfEventSubscriptionCreate(
+	amfSub *models.AmfCreateEventSubscription,
+) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  models.AmfCreatedEventSubscription
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NAMF_EVTS)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NAMF_EVTS, models.NfType_AMF)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NAMF_EVTS), "AmfEventSubscriptionCreate")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NAMF_EVTS, http.MethodPost, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).SubscriptionsCollectionDocumentApi.CreateSubscription(ctx, *amfSub)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusCreated {
+			logger.ConsumerLog.Debugf("AmfEventSubscriptionCreate RspData: %+v", result)
+			rspBody = &result
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+func (s *namfService) AmfEventSubscriptionDelete(subID string) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NAMF_EVTS)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NAMF_EVTS, models.NfType_AMF)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NAMF_EVTS), "AmfEventSubscriptionDelete")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NAMF_EVTS, http.MethodDelete, cands,
+		func(uri string) (*http.Response, error) {
+			rsp, err = s.getClient(uri).IndividualSubscriptionDocumentApi.DeleteSubscription(ctx, subID)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusNoContent && err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
@@ -0,0 +1,129 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/antihax/optional"
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
+	"github.com/free5gc/openapi/Nbsf_Management"
+	"github.com/free5gc/openapi/models"
+)
+
+type nbsfService struct {
+	consumer *Consumer
+
+	mu      sync.RWMutex
+	clients map[string]*Nbsf_Management.APIClient
+}
+
+func (s *nbsfService) getClient(uri string) *Nbsf_Management.APIClient {
+	s.mu.RLock()
+	if client, ok := s.clients[uri]; ok {
+		defer s.mu.RUnlock()
+		return client
+	} else {
+		configuration := Nbsf_Management.NewConfiguration()
+		configuration.SetBasePath(uri)
+		cli := Nbsf_Management.NewAPIClient(configuration)
+
+		s.mu.RUnlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.clients[uri] = cli
+		return cli
+	}
+}
+
+// GetPcfBinding retrieves the PCF binding of the PDU session of the UE, TS 29.521 clause 5.2.2.2.3.
+// The UE is identified by ipv4Addr, ipv6Addr or gpsi. The body is nil if no binding is found (204).
+func (s *nbsfService) GetPcfBinding(
+	ipv4Addr, ipv6Addr, gpsi, dnn string,
+	snssai *models.Snssai,
+) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  models.PcfBinding
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NBSF_MANAGEMENT)
+	if err != nil {
+		return rspCode, rspBody
+	}
+
+	param := &Nbsf_Management.GetPCFBindingsParamOpts{}
+	if ipv4Addr != "" {
+		param.Ipv4Addr = optional.NewString(ipv4Addr)
+	} else if ipv6Addr != "" {
+		// The IPv6 address is provided as a prefix of length 128
+		param.Ipv6Prefix = optional.NewString(ipv6Addr + "/128")
+	} else {
+		param.Gpsi = optional.NewString(gpsi)
+	}
+	if dnn != "" {
+		param.Dnn = optional.NewString(dnn)
+	}
+	if snssai != nil {
+		// The S-NSSAI query parameter is JSON encoded
+		snssaiJson, jsonErr := json.Marshal(snssai)
+		if jsonErr != nil {
+			logger.ConsumerLog.Errorf("GetPcfBinding: marshal snssai err: %+v", jsonErr)
+			return rspCode, rspBody
+		}
+		param.Snssai = optional.NewInterface(string(snssaiJson))
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NBSF_MANAGEMENT, models.NfType_BSF)
+	if err != nil {
+		return rspCode, rspBody
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NBSF_MANAGEMENT), "GetPcfBinding")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NBSF_MANAGEMENT, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).PCFBindingsCollectionApi.GetPCFBindings(ctx, param)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusOK {
+			rspBody = &result
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+// PcfBindingUri returns the Npcf_PolicyAuthorization URI of the bound PCF,
+// empty if the binding provides neither FQDN nor IPv4 end point
+func (s *nbsfService) PcfBindingUri(binding *models.PcfBinding) string {
+	scheme := models.UriScheme(s.consumer.Config().SbiScheme())
+	if binding.PcfFqdn != "" {
+		return string(scheme) + "://" + binding.PcfFqdn
+	}
+	for _, point := range binding.PcfIpEndPoints {
+		if point.Ipv4Address != "" {
+			return getUriFromIpEndPoint(scheme, point.Ipv4Address, point.Port)
+		}
+	}
+	return ""
+}
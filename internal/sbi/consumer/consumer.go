@@ -5,11 +5,16 @@ import (
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/Namf_EventExposure"
+	"github.com/free5gc/openapi/Nbsf_Management"
 	"github.com/free5gc/openapi/Nnrf_NFDiscovery"
 	"github.com/free5gc/openapi/Nnrf_NFManagement"
 	"github.com/free5gc/openapi/Npcf_PolicyAuthorization"
+	"github.com/free5gc/openapi/Nudm_EventExposure"
+	"github.com/free5gc/openapi/Nudm_SubscriberDataManagement"
 	"github.com/free5gc/openapi/Nudr_DataRepository"
 	"github.com/free5gc/openapi/models"
 )
@@ -26,6 +31,10 @@ type Consumer struct {
 	*nnrfService
 	*npcfService
 	*nudrService
+	*namfService
+	*nudmService
+	*nbsfService
+	*nsmfService
 }
 
 func NewConsumer(nef nef) (*Consumer, error) {
@@ -37,6 +46,7 @@ func NewConsumer(nef nef) (*Consumer, error) {
 		consumer:        c,
 		nfDiscClients:   make(map[string]*Nnrf_NFDiscovery.APIClient),
 		nfMngmntClients: make(map[string]*Nnrf_NFManagement.APIClient),
+		nfStatusSubs:    make(map[models.NfType]*nfStatusSub),
 	}
 
 	c.npcfService = &npcfService{
@@ -48,6 +58,30 @@ func NewConsumer(nef nef) (*Consumer, error) {
 		consumer: c,
 		clients:  make(map[string]*Nudr_DataRepository.APIClient),
 	}
+
+	c.namfService = &namfService{
+		consumer: c,
+		clients:  make(map[string]*Namf_EventExposure.APIClient),
+	}
+
+	c.nudmService = &nudmService{
+		consumer:   c,
+		eeClients:  make(map[string]*Nudm_EventExposure.APIClient),
+		sdmClients: make(map[string]*Nudm_SubscriberDataManagement.APIClient),
+	}
+
+	c.nbsfService = &nbsfService{
+		consumer: c,
+		clients:  make(map[string]*Nbsf_Management.APIClient),
+	}
+
+	c.nsmfService = &nsmfService{
+		consumer: c,
+	}
+
+	// The generated API clients send requests through the shared HTTP clients of openapi
+	metrics.InstrumentClient(openapi.GetHttpClient())
+	metrics.InstrumentClient(openapi.GetHttpsClient())
 	return c, nil
 }
 
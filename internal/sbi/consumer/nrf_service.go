@@ -2,16 +2,22 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/antihax/optional"
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
+	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/Nnrf_NFDiscovery"
 	"github.com/free5gc/openapi/Nnrf_NFManagement"
@@ -20,8 +26,13 @@ import (
 
 const (
 	RetryRegisterNrfDuration = 2 * time.Second
+	// NfStatusSubscriptionValidity is the validity requested to NRF when the NF status subscription is renewed
+	NfStatusSubscriptionValidity = 24 * time.Hour
 )
 
+// ErrNfInstanceNotFound is returned by heartbeat if NRF doesn't know the NF instance, NEF should re-register
+var ErrNfInstanceNotFound = errors.New("NF instance is not found in NRF")
+
 var serviceNfType map[models.ServiceName]models.NfType
 
 func init() {
@@ -70,6 +81,17 @@ type nnrfService struct {
 
 	nfMngmntMu      sync.RWMutex
 	nfMngmntClients map[string]*Nnrf_NFManagement.APIClient
+
+	// NF status subscriptions by the subscribed NF type
+	nfStatusSubsMu sync.Mutex
+	nfStatusSubs   map[models.NfType]*nfStatusSub
+}
+
+// nfStatusSub is an NF status subscription with the NRF holding it, which may be
+// different from the configured NRF after the NRF is changed by reloading config
+type nfStatusSub struct {
+	models.NrfSubscriptionData
+	nrfUri string
 }
 
 func (s *nnrfService) getNFDiscoveryClient(uri string) *Nnrf_NFDiscovery.APIClient {
@@ -108,7 +130,8 @@ func (s *nnrfService) getNFManagementClient(uri string) *Nnrf_NFManagement.APICl
 	}
 }
 
-func (s *nnrfService) RegisterNFInstance() error {
+// RegisterNFInstance registers the NF profile to NRF, retrying until it succeeds or ctx is done
+func (s *nnrfService) RegisterNFInstance(ctx context.Context) error {
 	var rsp *http.Response
 	var nf models.NfProfile
 	var err error
@@ -119,9 +142,10 @@ func (s *nnrfService) RegisterNFInstance() error {
 		return fmt.Errorf("RegisterNFInstance err: %+v", err)
 	}
 
+	reqCtx := metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "RegisterNFInstance")
 	for {
 		nf, rsp, err = client.NFInstanceIDDocumentApi.RegisterNFInstance(
-			context.TODO(), s.consumer.Context().NfInstID(), *nfProfile)
+			reqCtx, s.consumer.Context().NfInstID(), *nfProfile)
 		if rsp != nil && rsp.Body != nil {
 			if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
 				logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
@@ -130,13 +154,18 @@ func (s *nnrfService) RegisterNFInstance() error {
 
 		if err != nil || rsp == nil {
 			logger.ConsumerLog.Infof("NEF register to NRF Error[%v], sleep 2s and retry", err)
-			time.Sleep(RetryRegisterNrfDuration)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("RegisterNFInstance err: %w", ctx.Err())
+			case <-time.After(RetryRegisterNrfDuration):
+			}
 			continue
 		}
 
 		status := rsp.StatusCode
 		if status == http.StatusOK {
 			// NFUpdate
+			s.consumer.Context().SetHeartBeatTimer(nf.HeartBeatTimer)
 			logger.ConsumerLog.Infof("NFRegister Update")
 			break
 		} else if status == http.StatusCreated {
@@ -153,20 +182,212 @@ func (s *nnrfService) RegisterNFInstance() error {
 					logger.MainLog.Infoln("OAuth2 setting receive from NRF:", oauth2)
 				}
 			}
-			s.consumer.Context().OAuth2Required = oauth2
+			s.consumer.Context().SetOAuth2Required(oauth2)
 			if oauth2 && s.consumer.Context().Config().NrfCertPem() == "" {
 				logger.CfgLog.Error("OAuth2 enable but no nrfCertPem provided in config.")
 			}
+			s.consumer.Context().SetHeartBeatTimer(nf.HeartBeatTimer)
 
 			logger.ConsumerLog.Infof("NFRegister Created")
 			break
 		} else {
 			logger.ConsumerLog.Infof("NRF return wrong status: %d", status)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("RegisterNFInstance err: %w", ctx.Err())
+			case <-time.After(RetryRegisterNrfDuration):
+			}
 		}
 	}
 	return nil
 }
 
+// SendHeartbeat updates the NF status in NRF, TS 29.510 clause 5.2.2.3.2.
+// ErrNfInstanceNotFound is returned if NRF responds 404.
+func (s *nnrfService) SendHeartbeat() error {
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NfType_NRF)
+	if err != nil {
+		return fmt.Errorf("SendHeartbeat err: %+v", err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "UpdateNFInstance")
+
+	client := s.getNFManagementClient(s.consumer.Config().NrfUri())
+	patchItems := []models.PatchItem{{
+		Op:    models.PatchOperation_REPLACE,
+		Path:  "/nfStatus",
+		Value: models.NfStatus_REGISTERED,
+	}}
+	_, rsp, err := client.NFInstanceIDDocumentApi.UpdateNFInstance(
+		ctx, s.consumer.Context().NfInstID(), patchItems)
+	if rsp != nil && rsp.Body != nil {
+		if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
+			logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
+		}
+	}
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return ErrNfInstanceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("SendHeartbeat err: %+v", err)
+	}
+	return nil
+}
+
+// SubscribeNFStatus subscribes to the deregistration of the NF instances of the NF types,
+// replacing the existing subscriptions of them, e.g. after NEF re-registers to NRF
+func (s *nnrfService) SubscribeNFStatus(nfTypes ...models.NfType) error {
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NfType_NRF)
+	if err != nil {
+		return fmt.Errorf("SubscribeNFStatus err: %+v", err)
+	}
+
+	nrfUri := s.consumer.Config().NrfUri()
+	client := s.getNFManagementClient(nrfUri)
+	var errs []error
+	for _, nfType := range nfTypes {
+		s.nfStatusSubsMu.Lock()
+		old, ok := s.nfStatusSubs[nfType]
+		delete(s.nfStatusSubs, nfType)
+		s.nfStatusSubsMu.Unlock()
+		if ok {
+			if err = s.removeNFStatusSubscription(ctx, old); err != nil {
+				// The subscription may be lost by NRF, which is why NEF re-subscribes
+				logger.ConsumerLog.Warnf("Unsubscribe old NF status of %s err: %+v", nfType, err)
+			}
+		}
+
+		createCtx := metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "CreateSubscription")
+		sub, rsp, err := client.SubscriptionsCollectionApi.CreateSubscription(createCtx, models.NrfSubscriptionData{
+			NfStatusNotificationUri: s.consumer.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/nrf",
+			SubscrCond:              models.NfTypeCond{NfType: nfType},
+			ReqNotifEvents:          []models.NotificationEventType{models.NotificationEventType_DEREGISTERED},
+			ReqNfType:               models.NfType_NEF,
+		})
+		if rsp != nil && rsp.Body != nil {
+			if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
+				logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscribe NF status of %s err: %+v", nfType, err))
+			continue
+		}
+		logger.ConsumerLog.Infof("Subscribe NF status of %s: subscriptionID[%s]", nfType, sub.SubscriptionId)
+
+		s.nfStatusSubsMu.Lock()
+		s.nfStatusSubs[nfType] = &nfStatusSub{NrfSubscriptionData: sub, nrfUri: nrfUri}
+		s.nfStatusSubsMu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// RenewNFStatusSubscriptions extends the validity of the NF status subscriptions which expire before
+// the deadline, TS 29.510 clause 5.2.2.5.6. The NF type is subscribed again if NRF doesn't know the subscription.
+func (s *nnrfService) RenewNFStatusSubscriptions(deadline time.Time) error {
+	subs := make(map[models.NfType]nfStatusSub)
+	s.nfStatusSubsMu.Lock()
+	for nfType, sub := range s.nfStatusSubs {
+		if sub.ValidityTime != nil && sub.ValidityTime.Before(deadline) {
+			subs[nfType] = *sub
+		}
+	}
+	s.nfStatusSubsMu.Unlock()
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NfType_NRF)
+	if err != nil {
+		return fmt.Errorf("RenewNFStatusSubscriptions err: %+v", err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "UpdateSubscription")
+
+	var errs []error
+	var lost []models.NfType
+	for nfType, sub := range subs {
+		validityTime := time.Now().Add(NfStatusSubscriptionValidity)
+		client := s.getNFManagementClient(sub.nrfUri)
+		updated, rsp, err := client.SubscriptionIDDocumentApi.UpdateSubscription(ctx, sub.SubscriptionId,
+			[]models.PatchItem{{
+				Op:    models.PatchOperation_REPLACE,
+				Path:  "/validityTime",
+				Value: validityTime,
+			}})
+		if rsp != nil && rsp.Body != nil {
+			if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
+				logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
+			}
+		}
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			lost = append(lost, nfType)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("renew NF status subscription of %s err: %+v", nfType, err))
+			continue
+		}
+		// NRF may grant a different validity, which is returned with 200
+		if rsp.StatusCode == http.StatusOK && updated.ValidityTime != nil {
+			validityTime = *updated.ValidityTime
+		}
+		logger.ConsumerLog.Infof("Renew NF status subscription of %s: subscriptionID[%s], validityTime[%s]",
+			nfType, sub.SubscriptionId, validityTime)
+
+		s.nfStatusSubsMu.Lock()
+		if cur, ok := s.nfStatusSubs[nfType]; ok && cur.SubscriptionId == sub.SubscriptionId {
+			cur.ValidityTime = &validityTime
+		}
+		s.nfStatusSubsMu.Unlock()
+	}
+
+	if len(lost) != 0 {
+		logger.ConsumerLog.Warnf("NF status subscriptions of %v are not found in NRF, subscribe again", lost)
+		s.nfStatusSubsMu.Lock()
+		for _, nfType := range lost {
+			delete(s.nfStatusSubs, nfType)
+		}
+		s.nfStatusSubsMu.Unlock()
+		if err = s.SubscribeNFStatus(lost...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UnsubscribeNFStatus removes all the NF status subscriptions from NRF
+func (s *nnrfService) UnsubscribeNFStatus() error {
+	s.nfStatusSubsMu.Lock()
+	subs := s.nfStatusSubs
+	s.nfStatusSubs = make(map[models.NfType]*nfStatusSub)
+	s.nfStatusSubsMu.Unlock()
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NfType_NRF)
+	if err != nil {
+		return fmt.Errorf("UnsubscribeNFStatus err: %+v", err)
+	}
+
+	var errs []error
+	for nfType, sub := range subs {
+		if err = s.removeNFStatusSubscription(ctx, sub); err != nil {
+			errs = append(errs, fmt.Errorf("unsubscribe NF status of %s err: %+v", nfType, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *nnrfService) removeNFStatusSubscription(ctx context.Context, sub *nfStatusSub) error {
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "RemoveSubscription")
+	client := s.getNFManagementClient(sub.nrfUri)
+	rsp, err := client.SubscriptionIDDocumentApi.RemoveSubscription(ctx, sub.SubscriptionId)
+	if rsp != nil && rsp.Body != nil {
+		if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
+			logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
+		}
+	}
+	return err
+}
+
 func (s *nnrfService) buildNfProfile() (*models.NfProfile, error) {
 	profile := &models.NfProfile{
 		NfInstanceId: s.consumer.Context().NfInstID(),
@@ -191,6 +412,7 @@ func (s *nnrfService) DeregisterNFInstance() error {
 	if err != nil {
 		return nil
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_NFM), "DeregisterNFInstance")
 
 	client := s.getNFManagementClient(s.consumer.Config().NrfUri())
 
@@ -213,11 +435,13 @@ func (s *nnrfService) DeregisterNFInstance() error {
 	return nil
 }
 
+// SearchNFInstances discovers the NF instances of the service from NRF, and returns them in order of
+// preference with the validity period of the discovery result (0 if NRF doesn't provide it)
 func (s *nnrfService) SearchNFInstances(
 	nrfUri string,
 	srvName models.ServiceName,
 	param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts,
-) (*models.NfProfile, string, error) {
+) ([]nef_context.NfCandidate, time.Duration, error) {
 	if param == nil {
 		param = &Nnrf_NFDiscovery.SearchNFInstancesParamOpts{}
 	}
@@ -227,8 +451,9 @@ func (s *nnrfService) SearchNFInstances(
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NfType_NRF)
 	if err != nil {
-		return nil, "", err
+		return nil, 0, err
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NNRF_DISC), "SearchNFInstances")
 
 	res, rsp, err := client.NFInstancesStoreApi.SearchNFInstances(ctx,
 		serviceNfType[srvName], models.NfType_NEF, param)
@@ -241,73 +466,192 @@ func (s *nnrfService) SearchNFInstances(
 		err = fmt.Errorf("SearchNFInstances err: Temporary Redirect")
 	}
 	if err != nil {
-		return nil, "", err
+		return nil, 0, err
 	}
 
-	nfProf, uri, err := getProfileAndUri(res.NfInstances, srvName)
-	if err != nil {
+	cands := selectNFCandidates(res.NfInstances, srvName, s.consumer.Config().Locality())
+	if len(cands) == 0 {
+		err = fmt.Errorf("no uri for %s found", srvName)
 		logger.ConsumerLog.Errorf(err.Error())
-		return nil, "", err
+		return nil, 0, err
 	}
-	return nfProf, uri, nil
+	return cands, time.Duration(res.ValidityPeriod) * time.Second, nil
 }
 
-func getProfileAndUri(nfInstances []models.NfProfile, srvName models.ServiceName) (*models.NfProfile, string, error) {
-	// select the first ServiceName
-	// TODO: select base on other info
-	var profile *models.NfProfile
-	var uri string
-	for _, nfProfile := range nfInstances {
-		profile = &nfProfile
-		uri = searchNFServiceUri(nfProfile, srvName, models.NfServiceStatus_REGISTERED)
-		if uri != "" {
-			break
+// getNFCandidates returns the cached NF instances of the service, discovering them from NRF
+// if they are not cached or the discovery result expires. The expired ones are used if the discovery fails.
+func (s *nnrfService) getNFCandidates(srvName models.ServiceName) ([]nef_context.NfCandidate, error) {
+	cached, valid := s.consumer.Context().NfCandidates(srvName)
+	if valid {
+		return cached, nil
+	}
+	cands, validity, err := s.SearchNFInstances(s.consumer.Config().NrfUri(), srvName, nil)
+	if err != nil {
+		if len(cached) != 0 {
+			logger.ConsumerLog.Warnf("Discover %s err: %+v, use the expired NF instances", srvName, err)
+			return cached, nil
 		}
+		return nil, err
 	}
-	if uri == "" {
-		return nil, "", fmt.Errorf("no uri for %s found", srvName)
+	s.consumer.Context().SetNfCandidates(srvName, cands, validity)
+	return cands, nil
+}
+
+// sendWithFailover sends the request to the NF instances in order of preference until one of them responds
+// other than 503 Service Unavailable. The instances which fail are demoted, and the NF instances of the service
+// are discovered again by the next request if all of them fail.
+// A POST request, which is not idempotent, fails over without response only if it could not be sent,
+// since the instance may have created the resource before e.g. the request times out.
+func (s *nnrfService) sendWithFailover(srvName models.ServiceName, method string, cands []nef_context.NfCandidate,
+	send func(uri string) (*http.Response, error),
+) (*http.Response, error) {
+	var rsp *http.Response
+	var err error
+	for i, cand := range cands {
+		rsp, err = send(cand.Uri)
+		if rsp != nil && rsp.StatusCode != http.StatusServiceUnavailable {
+			return rsp, err
+		}
+		if rsp == nil && method == http.MethodPost && !isDialError(err) {
+			logger.ConsumerLog.Warnf("%s of nfInstID[%s] at [%s] has no response: %+v, %s is not retried on other instances",
+				srvName, cand.NfInstID, cand.Uri, err, method)
+			return rsp, err
+		}
+		if i == len(cands)-1 {
+			break
+		}
+		if rsp != nil && rsp.Body != nil {
+			if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
+				logger.ConsumerLog.Errorf("response body cannot close: %+v", bodyCloseErr)
+			}
+		}
+		logger.ConsumerLog.Warnf("%s of nfInstID[%s] at [%s] is unavailable: %+v, fail over to [%s]",
+			srvName, cand.NfInstID, cand.Uri, err, cands[i+1].Uri)
+		s.consumer.Context().DemoteNfCandidate(srvName, cand.Uri)
 	}
-	return profile, uri, nil
+	logger.ConsumerLog.Warnf("All NF instances of %s are unavailable", srvName)
+	s.consumer.Context().ExpireNfCandidates(srvName)
+	return rsp, err
 }
 
-// searchNFServiceUri returns NF Uri derived from NfProfile with corresponding service
-func searchNFServiceUri(nfProfile models.NfProfile, serviceName models.ServiceName,
-	nfServiceStatus models.NfServiceStatus,
-) string {
-	if nfProfile.NfServices == nil {
-		return ""
-	}
-
-	nfUri := ""
-	for _, service := range *nfProfile.NfServices {
-		if service.ServiceName == serviceName && service.NfServiceStatus == nfServiceStatus {
-			if service.Fqdn != "" {
-				nfUri = string(service.Scheme) + "://" + service.Fqdn
-			} else if nfProfile.Fqdn != "" {
-				nfUri = string(service.Scheme) + "://" + nfProfile.Fqdn
-			} else if service.ApiPrefix != "" {
-				u, err := url.Parse(service.ApiPrefix)
-				if err != nil {
-					return nfUri
-				}
-				nfUri = u.Scheme + "://" + u.Host
-			} else if len(*service.IpEndPoints) != 0 {
-				// Select the first IpEndPoint
-				// TODO: select others when failure
-				point := (*service.IpEndPoints)[0]
-				if point.Ipv4Address != "" {
-					nfUri = getUriFromIpEndPoint(service.Scheme, point.Ipv4Address, point.Port)
-				} else if len(nfProfile.Ipv4Addresses) != 0 {
-					nfUri = getUriFromIpEndPoint(service.Scheme, nfProfile.Ipv4Addresses[0], point.Port)
+// isDialError reports whether the request failed to connect to the NF instance, i.e. it was never sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// rankedNFCandidate is a service URI of an NF instance with the attributes to rank it
+type rankedNFCandidate struct {
+	nef_context.NfCandidate
+	sameLocality bool
+	priority     int32
+	capacity     int32
+	load         int32
+}
+
+// selectNFCandidates returns the URIs of the registered NF instances providing the service in order of
+// preference (TS 29.510 clause 6.1.6.2.2 and 6.1.6.2.3): the instances in the same locality as NEF,
+// then the lower priority value, the higher capacity and the lower load. The priority, capacity
+// and load of the NF service take precedence over the ones of the NF profile if present.
+func selectNFCandidates(nfInstances []models.NfProfile, srvName models.ServiceName,
+	locality string,
+) []nef_context.NfCandidate {
+	var ranked []rankedNFCandidate
+	for _, nfProfile := range nfInstances {
+		if nfProfile.NfStatus != "" && nfProfile.NfStatus != models.NfStatus_REGISTERED {
+			continue
+		}
+		if nfProfile.NfServices == nil {
+			continue
+		}
+		for _, service := range *nfProfile.NfServices {
+			if service.ServiceName != srvName || service.NfServiceStatus != models.NfServiceStatus_REGISTERED {
+				continue
+			}
+			cand := rankedNFCandidate{
+				sameLocality: locality != "" && nfProfile.Locality == locality,
+				priority:     nfProfile.Priority,
+				capacity:     nfProfile.Capacity,
+				load:         nfProfile.Load,
+			}
+			if service.Priority != 0 {
+				cand.priority = service.Priority
+			}
+			if service.Capacity != 0 {
+				cand.capacity = service.Capacity
+			}
+			if service.Load != 0 {
+				cand.load = service.Load
+			}
+			for _, uri := range searchNFServiceUris(nfProfile, service) {
+				cand.NfCandidate = nef_context.NfCandidate{
+					NfInstID: nfProfile.NfInstanceId,
+					Uri:      uri,
 				}
+				ranked = append(ranked, cand)
 			}
 		}
-		if nfUri != "" {
-			break
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.sameLocality != b.sameLocality {
+			return a.sameLocality
 		}
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		if a.capacity != b.capacity {
+			return a.capacity > b.capacity
+		}
+		return a.load < b.load
+	})
+
+	var cands []nef_context.NfCandidate
+	seen := make(map[string]struct{})
+	for _, cand := range ranked {
+		if _, ok := seen[cand.Uri]; ok {
+			continue
+		}
+		seen[cand.Uri] = struct{}{}
+		cands = append(cands, cand.NfCandidate)
 	}
+	return cands
+}
 
-	return nfUri
+// searchNFServiceUris returns the URIs of the NF service derived from NfProfile,
+// one for each IpEndPoint if there is no FQDN or apiPrefix
+func searchNFServiceUris(nfProfile models.NfProfile, service models.NfService) []string {
+	if service.Fqdn != "" {
+		return []string{string(service.Scheme) + "://" + service.Fqdn}
+	}
+	if nfProfile.Fqdn != "" {
+		return []string{string(service.Scheme) + "://" + nfProfile.Fqdn}
+	}
+	if service.ApiPrefix != "" {
+		u, err := url.Parse(service.ApiPrefix)
+		if err != nil {
+			return nil
+		}
+		return []string{u.Scheme + "://" + u.Host}
+	}
+	if service.IpEndPoints == nil {
+		return nil
+	}
+
+	var uris []string
+	for _, point := range *service.IpEndPoints {
+		var uri string
+		if point.Ipv4Address != "" {
+			uri = getUriFromIpEndPoint(service.Scheme, point.Ipv4Address, point.Port)
+		} else if len(nfProfile.Ipv4Addresses) != 0 {
+			uri = getUriFromIpEndPoint(service.Scheme, nfProfile.Ipv4Addresses[0], point.Port)
+		}
+		if uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
 }
 
 func getUriFromIpEndPoint(scheme models.UriScheme, ipv4Address string, port int32) string {
@@ -0,0 +1,373 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+type nefTestApp struct {
+	cfg    *factory.Config
+	nefCtx *nef_context.NefContext
+}
+
+func (a *nefTestApp) Config() *factory.Config {
+	return a.cfg
+}
+
+func (a *nefTestApp) Context() *nef_context.NefContext {
+	return a.nefCtx
+}
+
+func newTestNfProfile(nfInstID, ipv4 string, priority, capacity, load int32, locality string) models.NfProfile {
+	return models.NfProfile{
+		NfInstanceId: nfInstID,
+		NfType:       models.NfType_PCF,
+		NfStatus:     models.NfStatus_REGISTERED,
+		Priority:     priority,
+		Capacity:     capacity,
+		Load:         load,
+		Locality:     locality,
+		NfServices: &[]models.NfService{
+			{
+				ServiceName:     models.ServiceName_NPCF_POLICYAUTHORIZATION,
+				Scheme:          models.UriScheme_HTTP,
+				NfServiceStatus: models.NfServiceStatus_REGISTERED,
+				IpEndPoints: &[]models.IpEndPoint{
+					{
+						Ipv4Address: ipv4,
+						Port:        8000,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSelectNFCandidates(t *testing.T) {
+	srvName := models.ServiceName_NPCF_POLICYAUTHORIZATION
+
+	suspended := newTestNfProfile("pcf-suspended", "127.0.0.20", 1, 100, 0, "")
+	suspended.NfStatus = models.NfStatus_SUSPENDED
+
+	multiEndPoints := newTestNfProfile("pcf-multi", "127.0.0.21", 10, 100, 0, "")
+	(*multiEndPoints.NfServices)[0].IpEndPoints = &[]models.IpEndPoint{
+		{Ipv4Address: "127.0.0.21", Port: 8000},
+		{Ipv4Address: "127.0.0.22", Port: 8000},
+	}
+
+	servicePriority := newTestNfProfile("pcf-srv-priority", "127.0.0.23", 10, 100, 0, "")
+	(*servicePriority.NfServices)[0].Priority = 1
+
+	testCases := []struct {
+		description   string
+		nfInstances   []models.NfProfile
+		locality      string
+		expectedUris  []string
+		expectedInsts []string
+	}{
+		{
+			description: "TC1: Lower priority value first",
+			nfInstances: []models.NfProfile{
+				newTestNfProfile("pcf1", "127.0.0.11", 20, 100, 0, ""),
+				newTestNfProfile("pcf2", "127.0.0.12", 10, 100, 0, ""),
+			},
+			expectedUris:  []string{"http://127.0.0.12:8000", "http://127.0.0.11:8000"},
+			expectedInsts: []string{"pcf2", "pcf1"},
+		},
+		{
+			description: "TC2: Higher capacity and then lower load first with the same priority",
+			nfInstances: []models.NfProfile{
+				newTestNfProfile("pcf1", "127.0.0.11", 10, 100, 50, ""),
+				newTestNfProfile("pcf2", "127.0.0.12", 10, 100, 10, ""),
+				newTestNfProfile("pcf3", "127.0.0.13", 10, 200, 90, ""),
+			},
+			expectedUris:  []string{"http://127.0.0.13:8000", "http://127.0.0.12:8000", "http://127.0.0.11:8000"},
+			expectedInsts: []string{"pcf3", "pcf2", "pcf1"},
+		},
+		{
+			description: "TC3: Same locality first",
+			nfInstances: []models.NfProfile{
+				newTestNfProfile("pcf1", "127.0.0.11", 1, 100, 0, "area1"),
+				newTestNfProfile("pcf2", "127.0.0.12", 10, 100, 0, "area2"),
+			},
+			locality:      "area2",
+			expectedUris:  []string{"http://127.0.0.12:8000", "http://127.0.0.11:8000"},
+			expectedInsts: []string{"pcf2", "pcf1"},
+		},
+		{
+			description: "TC4: Skip the suspended NF instance",
+			nfInstances: []models.NfProfile{
+				suspended,
+				newTestNfProfile("pcf1", "127.0.0.11", 10, 100, 0, ""),
+			},
+			expectedUris:  []string{"http://127.0.0.11:8000"},
+			expectedInsts: []string{"pcf1"},
+		},
+		{
+			description: "TC5: All IpEndPoints are candidates, the service priority takes precedence",
+			nfInstances: []models.NfProfile{
+				multiEndPoints,
+				servicePriority,
+			},
+			expectedUris:  []string{"http://127.0.0.23:8000", "http://127.0.0.21:8000", "http://127.0.0.22:8000"},
+			expectedInsts: []string{"pcf-srv-priority", "pcf-multi", "pcf-multi"},
+		},
+		{
+			description: "TC6: No registered service",
+			nfInstances: []models.NfProfile{
+				suspended,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var expected []nef_context.NfCandidate
+			for i, uri := range tc.expectedUris {
+				expected = append(expected, nef_context.NfCandidate{
+					NfInstID: tc.expectedInsts[i],
+					Uri:      uri,
+				})
+			}
+			require.Equal(t, expected, selectNFCandidates(tc.nfInstances, srvName, tc.locality))
+		})
+	}
+}
+
+func TestNFStatusSubscriptions(t *testing.T) {
+	openapi.InterceptH2CClient()
+	defer openapi.RestoreH2CClient()
+	defer gock.Off()
+
+	nef := &nefTestApp{
+		cfg: &factory.Config{
+			Configuration: &factory.Configuration{
+				Sbi: &factory.Sbi{
+					Scheme:       "http",
+					RegisterIPv4: "127.0.0.5",
+					BindingIPv4:  "127.0.0.5",
+					Port:         8000,
+				},
+				NrfUri: "http://127.0.0.10:8000",
+			},
+		},
+	}
+	var err error
+	nef.nefCtx, err = nef_context.NewContext(nef)
+	require.NoError(t, err)
+	c, err := NewConsumer(nef)
+	require.NoError(t, err)
+
+	nrf := "http://127.0.0.10:8000"
+	validity := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	newCreateStub := func(subID string) *gock.Request {
+		req := gock.New(nrf).Post("/nnrf-nfm/v1/subscriptions$")
+		req.Reply(http.StatusCreated).
+			JSON(models.NrfSubscriptionData{SubscriptionId: subID, ValidityTime: &validity})
+		return req
+	}
+	subscriptionID := func() string {
+		c.nfStatusSubsMu.Lock()
+		defer c.nfStatusSubsMu.Unlock()
+		return c.nfStatusSubs[models.NfType_PCF].SubscriptionId
+	}
+
+	create := newCreateStub("sub1")
+	require.NoError(t, c.SubscribeNFStatus(models.NfType_PCF))
+	require.True(t, create.Mock.Done())
+	require.Equal(t, "sub1", subscriptionID())
+
+	// Not expiring before the deadline, nothing is sent
+	require.NoError(t, c.RenewNFStatusSubscriptions(time.Now()))
+
+	// The existing subscription is renewed instead of creating a new one
+	update := gock.New(nrf).Patch("/nnrf-nfm/v1/subscriptions/sub1").
+		BodyString(`"path":"/validityTime"`)
+	update.Reply(http.StatusNoContent)
+	require.NoError(t, c.RenewNFStatusSubscriptions(time.Now().Add(time.Hour)))
+	require.True(t, update.Mock.Done())
+	c.nfStatusSubsMu.Lock()
+	renewed := *c.nfStatusSubs[models.NfType_PCF].ValidityTime
+	c.nfStatusSubsMu.Unlock()
+	require.True(t, renewed.After(time.Now().Add(time.Hour)))
+
+	// The old subscription is removed before subscribing again, e.g. after re-registration
+	remove := gock.New(nrf).Delete("/nnrf-nfm/v1/subscriptions/sub1")
+	remove.Reply(http.StatusNoContent)
+	create = newCreateStub("sub2")
+	require.NoError(t, c.SubscribeNFStatus(models.NfType_PCF))
+	require.True(t, remove.Mock.Done())
+	require.True(t, create.Mock.Done())
+	require.Equal(t, "sub2", subscriptionID())
+
+	// The subscription lost by NRF is created again
+	update = gock.New(nrf).Patch("/nnrf-nfm/v1/subscriptions/sub2")
+	update.Reply(http.StatusNotFound).
+		JSON(models.ProblemDetails{Status: http.StatusNotFound})
+	create = newCreateStub("sub3")
+	require.NoError(t, c.RenewNFStatusSubscriptions(time.Now().Add(time.Hour)))
+	require.True(t, update.Mock.Done())
+	require.True(t, create.Mock.Done())
+	require.Equal(t, "sub3", subscriptionID())
+
+	remove = gock.New(nrf).Delete("/nnrf-nfm/v1/subscriptions/sub3")
+	remove.Reply(http.StatusNoContent)
+	require.NoError(t, c.UnsubscribeNFStatus())
+	require.True(t, remove.Mock.Done())
+}
+
+func TestSendWithFailover(t *testing.T) {
+	nef := &nefTestApp{
+		cfg: &factory.Config{
+			Configuration: &factory.Configuration{
+				Sbi: &factory.Sbi{
+					Scheme:       "http",
+					RegisterIPv4: "127.0.0.5",
+					BindingIPv4:  "127.0.0.5",
+					Port:         8000,
+				},
+				NrfUri: "http://127.0.0.10:8000",
+			},
+		},
+	}
+	var err error
+	nef.nefCtx, err = nef_context.NewContext(nef)
+	require.NoError(t, err)
+	c, err := NewConsumer(nef)
+	require.NoError(t, err)
+
+	srvName := models.ServiceName_NAMF_EVTS
+	cands := []nef_context.NfCandidate{
+		{NfInstID: "amf1", Uri: "http://127.0.0.11:8000"},
+		{NfInstID: "amf2", Uri: "http://127.0.0.12:8000"},
+	}
+	dialErr := &url.Error{
+		Op:  http.MethodPost,
+		URL: cands[0].Uri,
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+	}
+	timeoutErr := &url.Error{
+		Op:  http.MethodPost,
+		URL: cands[0].Uri,
+		Err: context.DeadlineExceeded,
+	}
+
+	testCases := []struct {
+		description  string
+		method       string
+		firstRsp     *http.Response
+		firstErr     error
+		expectedUris []string
+	}{
+		{
+			description:  "TC1: 503 from POST, should fail over",
+			method:       http.MethodPost,
+			firstRsp:     &http.Response{StatusCode: http.StatusServiceUnavailable},
+			expectedUris: []string{cands[0].Uri, cands[1].Uri},
+		},
+		{
+			description:  "TC2: POST failed to connect, should fail over",
+			method:       http.MethodPost,
+			firstErr:     dialErr,
+			expectedUris: []string{cands[0].Uri, cands[1].Uri},
+		},
+		{
+			description:  "TC3: POST timed out, may be created and should not fail over",
+			method:       http.MethodPost,
+			firstErr:     timeoutErr,
+			expectedUris: []string{cands[0].Uri},
+		},
+		{
+			description:  "TC4: GET timed out, should fail over",
+			method:       http.MethodGet,
+			firstErr:     timeoutErr,
+			expectedUris: []string{cands[0].Uri, cands[1].Uri},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			c.Context().SetNfCandidates(srvName, cands, time.Minute)
+
+			var sentUris []string
+			rsp, err := c.sendWithFailover(srvName, tc.method, cands,
+				func(uri string) (*http.Response, error) {
+					sentUris = append(sentUris, uri)
+					if uri == cands[0].Uri {
+						return tc.firstRsp, tc.firstErr
+					}
+					return &http.Response{StatusCode: http.StatusCreated}, nil
+				})
+			require.Equal(t, tc.expectedUris, sentUris)
+			if len(tc.expectedUris) == 1 {
+				require.Nil(t, rsp)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, http.StatusCreated, rsp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRegisterNFInstance(t *testing.T) {
+	openapi.InterceptH2CClient()
+	defer openapi.RestoreH2CClient()
+	defer gock.Off()
+
+	nef := &nefTestApp{
+		cfg: &factory.Config{
+			Info: &factory.Info{
+				Version: "1.0.3",
+			},
+			Configuration: &factory.Configuration{
+				Sbi: &factory.Sbi{
+					Scheme:       "http",
+					RegisterIPv4: "127.0.0.5",
+					BindingIPv4:  "127.0.0.5",
+					Port:         8000,
+				},
+				NrfUri: "http://127.0.0.10:8000",
+				ServiceList: []factory.Service{
+					{ServiceName: factory.ServiceNefPfd},
+				},
+			},
+		},
+	}
+	var err error
+	nef.nefCtx, err = nef_context.NewContext(nef)
+	require.NoError(t, err)
+	c, err := NewConsumer(nef)
+	require.NoError(t, err)
+
+	nrf := "http://127.0.0.10:8000"
+	register := gock.New(nrf).Put("/nnrf-nfm/v1/nf-instances/")
+	register.Reply(http.StatusCreated).
+		SetHeader("Location", nrf+"/nnrf-nfm/v1/nf-instances/"+nef.nefCtx.NfInstID()).
+		JSON(models.NfProfile{CustomInfo: map[string]interface{}{"oauth2": true}})
+	require.NoError(t, c.RegisterNFInstance(context.Background()))
+	require.True(t, register.Mock.Done())
+	require.True(t, nef.nefCtx.OAuth2Required())
+
+	// The in-flight registration is cancelled with ctx, e.g. on shutdown
+	gock.New(nrf).Put("/nnrf-nfm/v1/nf-instances/").
+		Reply(http.StatusCreated).
+		Delay(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.ErrorIs(t, c.RegisterNFInstance(ctx), context.DeadlineExceeded)
+	require.Less(t, time.Since(start), RetryRegisterNrfDuration)
+}
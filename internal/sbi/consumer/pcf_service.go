@@ -6,7 +6,9 @@ import (
 	"sync"
 
 	"github.com/antihax/optional"
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/openapi/Npcf_PolicyAuthorization"
 	"github.com/free5gc/openapi/models"
 )
@@ -36,17 +38,33 @@ func (s *npcfService) getClient(uri string) *Npcf_PolicyAuthorization.APIClient
 	}
 }
 
-func (s *npcfService) getPcfPolicyAuthUri() (string, error) {
-	uri := s.consumer.Context().PcfPaUri()
-	if uri == "" {
-		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
-			models.ServiceName_NPCF_POLICYAUTHORIZATION, nil)
-		if err == nil {
-			s.consumer.Context().SetPcfPaUri(sUri)
-		}
-		return sUri, err
+// getCandidates returns the discovered PCFs if pcfUri is not provided
+func (s *npcfService) getCandidates(pcfUri string) ([]nef_context.NfCandidate, error) {
+	if pcfUri != "" {
+		return nil, nil
+	}
+	return s.consumer.getNFCandidates(models.ServiceName_NPCF_POLICYAUTHORIZATION)
+}
+
+// sendToPcf sends the request to the PCF of pcfUri if it's provided, i.e. the PCF bound to the PDU session
+// or the one holding the app session, which never fails over to the other PCFs since they don't know
+// the session. Otherwise the request is sent to the discovered PCFs with failover.
+// The URI of the PCF which responds is returned.
+func (s *npcfService) sendToPcf(pcfUri, method string, cands []nef_context.NfCandidate,
+	send func(uri string) (*http.Response, error),
+) (*http.Response, string, error) {
+	if pcfUri != "" {
+		rsp, err := send(pcfUri)
+		return rsp, pcfUri, err
 	}
-	return uri, nil
+
+	var sentUri string
+	rsp, err := s.consumer.sendWithFailover(models.ServiceName_NPCF_POLICYAUTHORIZATION, method, cands,
+		func(uri string) (*http.Response, error) {
+			sentUri = uri
+			return send(uri)
+		})
+	return rsp, sentUri, err
 }
 
 func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
@@ -58,20 +76,22 @@ func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
 		rsp     *http.Response
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NPCF_POLICYAUTHORIZATION)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION, models.NfType_PCF)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NPCF_POLICYAUTHORIZATION), "GetAppSession")
 
-	result, rsp, err = client.IndividualApplicationSessionContextDocumentApi.
-		GetAppSession(ctx, appSessionId)
-
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NPCF_POLICYAUTHORIZATION, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).IndividualApplicationSessionContextDocumentApi.GetAppSession(ctx, appSessionId)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -96,28 +116,38 @@ func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
 	return rspCode, rspBody
 }
 
-func (s *npcfService) PostAppSessions(asc *models.AppSessionContext) (int, interface{}, string) {
+// PostAppSessions creates the app session in the PCF of pcfUri, or in a discovered PCF if it's not provided.
+// The URI of the PCF holding the created app session is returned, which should be used for the later
+// operations of the app session.
+func (s *npcfService) PostAppSessions(
+	pcfUri string, asc *models.AppSessionContext,
+) (int, interface{}, string, string) {
 	var (
 		err       error
 		rspCode   int
 		rspBody   interface{}
 		appSessID string
+		sentUri   string
 		result    models.AppSessionContext
 		rsp       *http.Response
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	cands, err := s.getCandidates(pcfUri)
 	if err != nil {
-		return rspCode, rspBody, appSessID
+		return rspCode, rspBody, appSessID, sentUri
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION, models.NfType_PCF)
 	if err != nil {
-		return rspCode, rspBody, appSessID
+		return rspCode, rspBody, appSessID, sentUri
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NPCF_POLICYAUTHORIZATION), "PostAppSessions")
 
-	result, rsp, err = client.ApplicationSessionsCollectionApi.PostAppSessions(ctx, *asc)
+	rsp, sentUri, err = s.sendToPcf(pcfUri, http.MethodPost, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).ApplicationSessionsCollectionApi.PostAppSessions(ctx, *asc)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -141,7 +171,7 @@ func (s *npcfService) PostAppSessions(asc *models.AppSessionContext) (int, inter
 		rspCode, rspBody = handleAPIServiceNoResponse(err)
 	}
 
-	return rspCode, rspBody, appSessID
+	return rspCode, rspBody, appSessID, sentUri
 }
 
 func (s *npcfService) PutAppSession(
@@ -158,20 +188,26 @@ func (s *npcfService) PutAppSession(
 		rsp       *http.Response
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NPCF_POLICYAUTHORIZATION)
 	if err != nil {
 		return rspCode, rspBody, appSessID
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION, models.NfType_PCF)
 	if err != nil {
 		return rspCode, rspBody, appSessID
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NPCF_POLICYAUTHORIZATION), "PutAppSession")
 
 	appSessID = appSessionId
-	result, rsp, err = client.IndividualApplicationSessionContextDocumentApi.
-		GetAppSession(ctx, appSessionId)
+	// The app session is modified in the PCF which responds to the query
+	var client *Npcf_PolicyAuthorization.APIClient
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NPCF_POLICYAUTHORIZATION, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			client = s.getClient(uri)
+			result, rsp, err = client.IndividualApplicationSessionContextDocumentApi.GetAppSession(ctx, appSessionId)
+			return rsp, err
+		})
 	if rsp != nil {
 		if rsp.Body != nil {
 			if bodyCloseErr := rsp.Body.Close(); bodyCloseErr != nil {
@@ -221,7 +257,9 @@ func (s *npcfService) PutAppSession(
 	return rspCode, rspBody, appSessID
 }
 
-func (s *npcfService) PatchAppSession(appSessionId string,
+// PatchAppSession modifies the app session in the PCF of pcfUri which holds it. The discovered PCFs are
+// tried only if the PCF is unknown, e.g. the subscription was restored from the store of an older version.
+func (s *npcfService) PatchAppSession(pcfUri, appSessionId string,
 	ascUpdateData *models.AppSessionContextUpdateData,
 ) (int, interface{}) {
 	var (
@@ -232,19 +270,23 @@ func (s *npcfService) PatchAppSession(appSessionId string,
 		rsp     *http.Response
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	cands, err := s.getCandidates(pcfUri)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION, models.NfType_PCF)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NPCF_POLICYAUTHORIZATION), "PatchAppSession")
 
-	result, rsp, err = client.IndividualApplicationSessionContextDocumentApi.ModAppSession(
-		ctx, appSessionId, *ascUpdateData)
+	rsp, _, err = s.sendToPcf(pcfUri, http.MethodPatch, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).IndividualApplicationSessionContextDocumentApi.ModAppSession(
+				ctx, appSessionId, *ascUpdateData)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -270,7 +312,8 @@ func (s *npcfService) PatchAppSession(appSessionId string,
 	return rspCode, rspBody
 }
 
-func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
+// DeleteAppSession deletes the app session in the PCF of pcfUri which holds it, see PatchAppSession
+func (s *npcfService) DeleteAppSession(pcfUri, appSessionId string) (int, interface{}) {
 	var (
 		err     error
 		rspCode int
@@ -279,11 +322,10 @@ func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
 		rsp     *http.Response
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	cands, err := s.getCandidates(pcfUri)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	param := &Npcf_PolicyAuthorization.DeleteAppSessionParamOpts{
 		EventsSubscReqData: optional.NewInterface(models.EventsSubscReqData{}),
@@ -293,9 +335,14 @@ func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
 	if err != nil {
 		return rspCode, rspBody
 	}
-
-	result, rsp, err = client.IndividualApplicationSessionContextDocumentApi.DeleteAppSession(
-		ctx, appSessionId, param)
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NPCF_POLICYAUTHORIZATION), "DeleteAppSession")
+
+	rsp, _, err = s.sendToPcf(pcfUri, http.MethodPost, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).IndividualApplicationSessionContextDocumentApi.DeleteAppSession(
+				ctx, appSessionId, param)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
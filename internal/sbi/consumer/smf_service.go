@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/Nsmf_EventExposure"
+	"github.com/free5gc/openapi/models"
+)
+
+type nsmfService struct {
+	consumer *Consumer
+}
+
+// PostAckOfNotify relays the acknowledgement of AF for the UP path change notification
+// to the ackUri provided by SMF in the notification (TS 29.508)
+func (s *nsmfService) PostAckOfNotify(ackUri string, ack *nef_models.AckOfNotify) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		rsp     *http.Response
+	)
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NSMF_EVENT_EXPOSURE, models.NfType_SMF)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NSMF_EVENT_EXPOSURE), "PostAckOfNotify")
+
+	rsp, err = postAckOfNotify(ctx, ackUri, ack)
+	if rsp != nil {
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusNoContent || rsp.StatusCode == http.StatusOK {
+			logger.ConsumerLog.Debugf("PostAckOfNotify to [%s] succeeded", ackUri)
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+// postAckOfNotify sends the AckOfNotify to the callback URI of SMF,
+// which is not provided by the generated Nsmf_EventExposure client.
+func postAckOfNotify(
+	ctx context.Context,
+	ackUri string, ack *nef_models.AckOfNotify,
+) (*http.Response, error) {
+	configuration := Nsmf_EventExposure.NewConfiguration()
+	headerParams := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/problem+json",
+	}
+
+	req, err := openapi.PrepareRequest(ctx, configuration, ackUri,
+		http.MethodPost, ack, headerParams, url.Values{}, url.Values{}, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := openapi.CallAPI(configuration, req)
+	if err != nil || rsp == nil {
+		return rsp, err
+	}
+
+	body, err := io.ReadAll(rsp.Body)
+	if closeErr := rsp.Body.Close(); closeErr != nil {
+		logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", closeErr)
+	}
+	if err != nil {
+		return rsp, err
+	}
+	if rsp.StatusCode < http.StatusMultipleChoices {
+		return rsp, nil
+	}
+
+	apiError := openapi.GenericOpenAPIError{
+		RawBody:     body,
+		ErrorStatus: rsp.Status,
+	}
+	var pd models.ProblemDetails
+	if err = openapi.Deserialize(&pd, body, rsp.Header.Get("Content-Type")); err != nil {
+		apiError.ErrorStatus = err.Error()
+		return rsp, apiError
+	}
+	apiError.ErrorModel = pd
+	return rsp, apiError
+}
@@ -0,0 +1,330 @@
+package consumer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/Nudm_EventExposure"
+	"github.com/free5gc/openapi/Nudm_SubscriberDataManagement"
+	"github.com/free5gc/openapi/models"
+)
+
+type nudmService struct {
+	consumer *Consumer
+
+	eeMu      sync.RWMutex
+	eeClients map[string]*Nudm_EventExposure.APIClient
+
+	sdmMu      sync.RWMutex
+	sdmClients map[string]*Nudm_SubscriberDataManagement.APIClient
+}
+
+func (s *nudmService) getEeClient(uri string) *Nudm_EventExposure.APIClient {
+	s.eeMu.RLock()
+	if client, ok := s.eeClients[uri]; ok {
+		defer s.eeMu.RUnlock()
+		return client
+	} else {
+		configuration := Nudm_EventExposure.NewConfiguration()
+		configuration.SetBasePath(uri)
+		cli := Nudm_EventExposure.NewAPIClient(configuration)
+
+		s.eeMu.RUnlock()
+		s.eeMu.Lock()
+		defer s.eeMu.Unlock()
+		s.eeClients[uri] = cli
+		return cli
+	}
+}
+
+func (s *nudmService) getSdmClient(uri string) *Nudm_SubscriberDataManagement.APIClient {
+	s.sdmMu.RLock()
+	if client, ok := s.sdmClients[uri]; ok {
+		defer s.sdmMu.RUnlock()
+		return client
+	} else {
+		configuration := Nudm_SubscriberDataManagement.NewConfiguration()
+		configuration.SetBasePath(uri)
+		cli := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
+
+		s.sdmMu.RUnlock()
+		s.sdmMu.Lock()
+		defer s.sdmMu.Unlock()
+		s.sdmClients[uri] = cli
+		return cli
+	}
+}
+
+// SdmGetIdTranslationResult translates GPSI to SUPI
+func (s *nudmService) SdmGetIdTranslationResult(gpsi string) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  models.IdTranslationResult
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDM_SDM)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NfType_UDM)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDM_SDM), "SdmGetIdTranslationResult")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDM_SDM, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getSdmClient(uri).GPSIToSUPITranslationApi.GetIdTranslationResult(ctx, gpsi, nil)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusOK {
+			logger.ConsumerLog.Debugf("SdmGetIdTranslationResult RspData: %+v", result)
+			rspBody = &result
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+// SdmGetGroupIdentifiers translates External Group ID to internal group ID
+func (s *nudmService) SdmGetGroupIdentifiers(extGroupId string) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  nef_models.GroupIdentifiers
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDM_SDM)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_SDM, models.NfType_UDM)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDM_SDM), "SdmGetGroupIdentifiers")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDM_SDM, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = getGroupIdentifiers(ctx, uri, extGroupId)
+			return rsp, err
+		})
+	if rsp != nil {
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusOK {
+			logger.ConsumerLog.Debugf("SdmGetGroupIdentifiers RspData: %+v", result)
+			rspBody = &result
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+// getGroupIdentifiers invokes Nudm_SDM GetGroupIdentifiers (TS 29.503),
+// which is not provided by the generated Nudm_SubscriberDataManagement client.
+func getGroupIdentifiers(
+	ctx context.Context,
+	uri, extGroupId string,
+) (nef_models.GroupIdentifiers, *http.Response, error) {
+	var result nef_models.GroupIdentifiers
+
+	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
+	configuration.SetBasePath(uri)
+
+	queryParams := url.Values{}
+	queryParams.Add("ext-group-id", extGroupId)
+	headerParams := map[string]string{
+		"Accept": "application/json, application/problem+json",
+	}
+
+	req, err := openapi.PrepareRequest(ctx, configuration, configuration.BasePath()+"/group-data/group-identifiers",
+		http.MethodGet, nil, headerParams, queryParams, url.Values{}, "", "", nil)
+	if err != nil {
+		return result, nil, err
+	}
+
+	rsp, err := openapi.CallAPI(configuration, req)
+	if err != nil || rsp == nil {
+		return result, rsp, err
+	}
+
+	body, err := io.ReadAll(rsp.Body)
+	if closeErr := rsp.Body.Close(); closeErr != nil {
+		logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", closeErr)
+	}
+	if err != nil {
+		return result, rsp, err
+	}
+
+	apiError := openapi.GenericOpenAPIError{
+		RawBody:     body,
+		ErrorStatus: rsp.Status,
+	}
+	contentType := rsp.Header.Get("Content-Type")
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		if err = openapi.Deserialize(&result, body, contentType); err != nil {
+			apiError.ErrorStatus = err.Error()
+			return result, rsp, apiError
+		}
+		return result, rsp, nil
+	default:
+		var pd models.ProblemDetails
+		if err = openapi.Deserialize(&pd, body, contentType); err != nil {
+			apiError.ErrorStatus = err.Error()
+			return result, rsp, apiError
+		}
+		apiError.ErrorModel = pd
+		return result, rsp, apiError
+	}
+}
+
+func (s *nudmService) EeSubscriptionCreate(
+	ueIdentity string,
+	eeSub *models.EeSubscription,
+) (int, interface{}, string) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		subID   string
+		result  models.CreatedEeSubscription
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDM_EE)
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, subID
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_EE, models.NfType_UDM)
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, subID
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDM_EE), "EeSubscriptionCreate")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDM_EE, http.MethodPost, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getEeClient(uri).CreateEESubscriptionApi.CreateEeSubscription(ctx, ueIdentity, *eeSub)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode == http.StatusCreated {
+			logger.ConsumerLog.Debugf("EeSubscriptionCreate RspData: %+v", result)
+			rspBody = &result
+			subID = getSubIDFromRspLocationHeader(rsp)
+		} else if err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody, subID
+}
+
+func (s *nudmService) EeSubscriptionDelete(ueIdentity, subID string) (int, interface{}) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		rsp     *http.Response
+	)
+
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDM_EE)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+
+	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDM_EE, models.NfType_UDM)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDM_EE), "EeSubscriptionDelete")
+
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDM_EE, http.MethodDelete, cands,
+		func(uri string) (*http.Response, error) {
+			rsp, err = s.getEeClient(uri).DeleteEESubscriptionApi.DeleteEeSubscription(ctx, ueIdentity, subID)
+			return rsp, err
+		})
+	if rsp != nil {
+		defer func() {
+			if rsp.Request.Response != nil {
+				rsp_err := rsp.Request.Response.Body.Close()
+				if rsp_err != nil {
+					logger.ConsumerLog.Errorf("ResponseBody can't be close: %+v", err)
+				}
+			}
+		}()
+
+		rspCode = rsp.StatusCode
+		if rsp.StatusCode != http.StatusNoContent && err != nil {
+			rspCode, rspBody = handleAPIServiceResponseError(rsp, err)
+		}
+	} else {
+		// API Service Internal Error or Server No Response
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+	}
+
+	return rspCode, rspBody
+}
+
+func getSubIDFromRspLocationHeader(rsp *http.Response) string {
+	subID := ""
+	loc := rsp.Header.Get("Location")
+	if strings.Contains(loc, "http") {
+		index := strings.LastIndex(loc, "/")
+		subID = loc[index+1:]
+	}
+	logger.ConsumerLog.Infof("subID=%q", subID)
+	return subID
+}
@@ -1,15 +1,22 @@
 package consumer
 
 import (
+	"context"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/antihax/optional"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/openapi/Nudr_DataRepository"
 	"github.com/free5gc/openapi/models"
 )
 
+// UdrPfdTimeout bounds each PFD request to a UDR instance, so that a slow UDR does not stall
+// the PFD management transactions of an AF
+const UdrPfdTimeout = 3 * time.Second
+
 type nudrService struct {
 	consumer *Consumer
 
@@ -35,19 +42,6 @@ func (s *nudrService) getClient(uri string) *Nudr_DataRepository.APIClient {
 	}
 }
 
-func (s *nudrService) getUdrDrUri() (string, error) {
-	uri := s.consumer.Context().UdrDrUri()
-	if uri == "" {
-		_, sUri, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
-			models.ServiceName_NUDR_DR, nil)
-		if err == nil {
-			s.consumer.Context().SetUdrDrUri(sUri)
-		}
-		return sUri, err
-	}
-	return uri, nil
-}
-
 func (s *nudrService) AppDataInfluenceDataGet(influenceIDs []string) (int, interface{}) {
 	var (
 		err     error
@@ -57,11 +51,10 @@ func (s *nudrService) AppDataInfluenceDataGet(influenceIDs []string) (int, inter
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	param := &Nudr_DataRepository.ApplicationDataInfluenceDataGetParamOpts{
 		InfluenceIds: optional.NewInterface(influenceIDs),
@@ -71,9 +64,13 @@ func (s *nudrService) AppDataInfluenceDataGet(influenceIDs []string) (int, inter
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataInfluenceDataGet")
 
-	result, rsp, err = client.InfluenceDataApi.
-		ApplicationDataInfluenceDataGet(ctx, param)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).InfluenceDataApi.ApplicationDataInfluenceDataGet(ctx, param)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -107,11 +104,10 @@ func (s *nudrService) AppDataInfluenceDataIdGet(influenceID string) (int, interf
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	param := &Nudr_DataRepository.ApplicationDataInfluenceDataGetParamOpts{
 		InfluenceIds: optional.NewInterface(influenceID),
@@ -121,9 +117,13 @@ func (s *nudrService) AppDataInfluenceDataIdGet(influenceID string) (int, interf
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataInfluenceDataIdGet")
 
-	result, rsp, err = client.InfluenceDataApi.
-		ApplicationDataInfluenceDataGet(ctx, param)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).InfluenceDataApi.ApplicationDataInfluenceDataGet(ctx, param)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -159,19 +159,23 @@ func (s *nudrService) AppDataInfluenceDataPut(influenceID string,
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataInfluenceDataPut")
 
-	result, rsp, err = client.IndividualInfluenceDataDocumentApi.
-		ApplicationDataInfluenceDataInfluenceIdPut(ctx, influenceID, *tiData)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodPut, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).IndividualInfluenceDataDocumentApi.
+				ApplicationDataInfluenceDataInfluenceIdPut(ctx, influenceID, *tiData)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -206,11 +210,10 @@ func (s *nudrService) AppDataPfdsGet(appIDs []string) (int, interface{}) {
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	param := &Nudr_DataRepository.ApplicationDataPfdsGetParamOpts{
 		AppId: optional.NewInterface(appIDs),
@@ -220,8 +223,15 @@ func (s *nudrService) AppDataPfdsGet(appIDs []string) (int, interface{}) {
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataPfdsGet")
 
-	result, rsp, err = client.DefaultApi.ApplicationDataPfdsGet(ctx, param)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, UdrPfdTimeout)
+			defer cancel()
+			result, rsp, err = s.getClient(uri).DefaultApi.ApplicationDataPfdsGet(ctx, param)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -256,18 +266,24 @@ func (s *nudrService) AppDataPfdsAppIdPut(appID string, pfdDataForApp *models.Pf
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataPfdsAppIdPut")
 
-	result, rsp, err = client.DefaultApi.ApplicationDataPfdsAppIdPut(ctx, appID, *pfdDataForApp)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodPut, cands,
+		func(uri string) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, UdrPfdTimeout)
+			defer cancel()
+			result, rsp, err = s.getClient(uri).DefaultApi.ApplicationDataPfdsAppIdPut(ctx, appID, *pfdDataForApp)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -301,18 +317,24 @@ func (s *nudrService) AppDataPfdsAppIdDelete(appID string) (int, interface{}) {
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataPfdsAppIdDelete")
 
-	rsp, err = client.DefaultApi.ApplicationDataPfdsAppIdDelete(ctx, appID)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodDelete, cands,
+		func(uri string) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, UdrPfdTimeout)
+			defer cancel()
+			rsp, err = s.getClient(uri).DefaultApi.ApplicationDataPfdsAppIdDelete(ctx, appID)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -345,18 +367,24 @@ func (s *nudrService) AppDataPfdsAppIdGet(appID string) (int, interface{}) {
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataPfdsAppIdGet")
 
-	result, rsp, err = client.DefaultApi.ApplicationDataPfdsAppIdGet(ctx, appID)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodGet, cands,
+		func(uri string) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, UdrPfdTimeout)
+			defer cancel()
+			result, rsp, err = s.getClient(uri).DefaultApi.ApplicationDataPfdsAppIdGet(ctx, appID)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -392,19 +420,23 @@ func (s *nudrService) AppDataInfluenceDataPatch(
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataInfluenceDataPatch")
 
-	result, rsp, err = client.IndividualInfluenceDataDocumentApi.
-		ApplicationDataInfluenceDataInfluenceIdPatch(ctx, influenceID, *tiSubPatch)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodPatch, cands,
+		func(uri string) (*http.Response, error) {
+			result, rsp, err = s.getClient(uri).IndividualInfluenceDataDocumentApi.
+				ApplicationDataInfluenceDataInfluenceIdPatch(ctx, influenceID, *tiSubPatch)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -437,19 +469,23 @@ func (s *nudrService) AppDataInfluenceDataDelete(influenceID string) (int, inter
 		rsp     *http.Response
 	)
 
-	uri, err := s.getUdrDrUri()
+	cands, err := s.consumer.getNFCandidates(models.ServiceName_NUDR_DR)
 	if err != nil {
 		return rspCode, rspBody
 	}
-	client := s.getClient(uri)
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NUDR_DR, models.NfType_UDR)
 	if err != nil {
 		return rspCode, rspBody
 	}
+	ctx = metrics.WithConsumerOp(ctx, string(models.ServiceName_NUDR_DR), "AppDataInfluenceDataDelete")
 
-	rsp, err = client.IndividualInfluenceDataDocumentApi.
-		ApplicationDataInfluenceDataInfluenceIdDelete(ctx, influenceID)
+	rsp, err = s.consumer.sendWithFailover(models.ServiceName_NUDR_DR, http.MethodDelete, cands,
+		func(uri string) (*http.Response, error) {
+			rsp, err = s.getClient(uri).IndividualInfluenceDataDocumentApi.
+				ApplicationDataInfluenceDataInfluenceIdDelete(ctx, influenceID)
+			return rsp, err
+		})
 	if rsp != nil {
 		defer func() {
 			if rsp.Request.Response != nil {
@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AfNotifyWorkers       = 4
+	AfNotifyQueueSize     = 1024
+	AfNotifyTimeout       = 5 * time.Second
+	AfNotifyMaxRetry      = 3
+	AfNotifyRetryInterval = 1 * time.Second
+	AfNotifyMaxDeadLetter = 1000
+)
+
+// AfNotifier delivers northbound notifications (e.g. TS 29.522 EventNotification)
+// to the notificationDestination provided by AF.
+type AfNotifier struct {
+	client *http.Client
+	pool   *notifyPool
+
+	deadLetterMu sync.RWMutex
+	deadLetters  []AfDeadLetter
+}
+
+type afNotifyJob struct {
+	client  *http.Client
+	uri     string
+	reqBody []byte
+	log     *logrus.Entry
+}
+
+// AfDeadLetter records the notification which could not be delivered to AF
+type AfDeadLetter struct {
+	NotifyUri    string          `json:"notifyUri"`
+	Notification json.RawMessage `json:"notification"`
+	Attempts     int             `json:"attempts"`
+	Reason       string          `json:"reason"`
+	Time         time.Time       `json:"time"`
+}
+
+func NewAfNotifier() (*AfNotifier, error) {
+	n := &AfNotifier{
+		client: &http.Client{},
+	}
+	n.pool = &notifyPool{
+		log:           logger.NotifierLog,
+		timeout:       AfNotifyTimeout,
+		maxRetry:      AfNotifyMaxRetry,
+		retryInterval: AfNotifyRetryInterval,
+		onSuccess: func(job notifyJob) {
+			afJob := job.(*afNotifyJob)
+			afJob.log.Infof("Notify AF[%s] success", afJob.uri)
+		},
+		onFailure: func(job notifyJob, attempts int, reason string) {
+			n.addDeadLetter(job.(*afNotifyJob), attempts, reason)
+		},
+	}
+	n.pool.start(AfNotifyWorkers, AfNotifyQueueSize)
+	return n, nil
+}
+
+// Close stops the workers, the notifications still in queue are recorded as dead letters
+func (n *AfNotifier) Close() {
+	n.pool.Close()
+}
+
+// Notify sends the notification to AF in background
+func (n *AfNotifier) Notify(uri string, body interface{}, log *logrus.Entry) {
+	if log == nil {
+		log = logger.NotifierLog
+	}
+
+	reqBody, err := openapi.Serialize(body, "application/json")
+	if err != nil {
+		log.Errorf("Serialize notification to AF[%s] err: %+v", uri, err)
+		return
+	}
+	n.pool.enqueue(&afNotifyJob{
+		client:  n.client,
+		uri:     uri,
+		reqBody: reqBody,
+		log:     log,
+	})
+}
+
+func (job *afNotifyJob) target() string {
+	return job.uri
+}
+
+func (job *afNotifyJob) post(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.uri, bytes.NewReader(job.reqBody))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := job.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if rspCloseErr := rsp.Body.Close(); rspCloseErr != nil {
+			logger.NotifierLog.Errorf("Response body cannot close: %+v", rspCloseErr)
+		}
+	}()
+
+	if _, err = io.Copy(io.Discard, rsp.Body); err != nil {
+		logger.NotifierLog.Warnf("Read response body err: %+v", err)
+	}
+	return rsp.StatusCode, nil
+}
+
+func (n *AfNotifier) addDeadLetter(job *afNotifyJob, attempts int, reason string) {
+	job.log.Errorf("Notify AF[%s] failed after %d attempts: %s", job.uri, attempts, reason)
+
+	n.deadLetterMu.Lock()
+	defer n.deadLetterMu.Unlock()
+	if len(n.deadLetters) >= AfNotifyMaxDeadLetter {
+		n.deadLetters = n.deadLetters[1:]
+	}
+	n.deadLetters = append(n.deadLetters, AfDeadLetter{
+		NotifyUri:    job.uri,
+		Notification: job.reqBody,
+		Attempts:     attempts,
+		Reason:       reason,
+		Time:         time.Now(),
+	})
+}
+
+// GetDeadLetters returns the undelivered notifications, the oldest first
+func (n *AfNotifier) GetDeadLetters() []AfDeadLetter {
+	n.deadLetterMu.RLock()
+	defer n.deadLetterMu.RUnlock()
+
+	deadLetters := make([]AfDeadLetter, len(n.deadLetters))
+	copy(deadLetters, n.deadLetters)
+	return deadLetters
+}
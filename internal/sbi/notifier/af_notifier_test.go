@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAfNotifierDelivery(t *testing.T) {
+	n, err := NewAfNotifier()
+	require.NoError(t, err)
+	defer n.Close()
+	n.pool.retryInterval = 10 * time.Millisecond
+
+	testCases := []struct {
+		description         string
+		statuses            []int
+		expectedReqs        int32
+		expectedDeadLetters int
+	}{
+		{
+			description:  "TC1: Delivered after server errors, should retry",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusNoContent},
+			expectedReqs: 2,
+		},
+		{
+			description:         "TC2: AF keeps failing, should be dead letter after retries",
+			statuses:            []int{http.StatusInternalServerError},
+			expectedReqs:        AfNotifyMaxRetry + 1,
+			expectedDeadLetters: 1,
+		},
+		{
+			description:         "TC3: Rejected by AF, should be dead letter without retry",
+			statuses:            []int{http.StatusBadRequest},
+			expectedReqs:        1,
+			expectedDeadLetters: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			uri, numReqs := newTestPfdSubscriber(t, tc.statuses...)
+			numDeadLetters := len(n.GetDeadLetters())
+
+			n.Notify(uri, map[string]string{"afTransId": "Trans1"}, nil)
+
+			require.Eventually(t, func() bool {
+				return atomic.LoadInt32(numReqs) == tc.expectedReqs &&
+					len(n.GetDeadLetters()) == numDeadLetters+tc.expectedDeadLetters
+			}, 2*time.Second, 10*time.Millisecond)
+
+			if tc.expectedDeadLetters > 0 {
+				deadLetter := n.GetDeadLetters()[numDeadLetters]
+				require.Equal(t, uri, deadLetter.NotifyUri)
+				require.Equal(t, int(tc.expectedReqs), deadLetter.Attempts)
+				var notif map[string]string
+				require.NoError(t, json.Unmarshal(deadLetter.Notification, &notif))
+				require.Equal(t, "Trans1", notif["afTransId"])
+			}
+		})
+	}
+
+	// No notification is sent once closed
+	uri, numReqs := newTestPfdSubscriber(t, http.StatusNoContent)
+	numDeadLetters := len(n.GetDeadLetters())
+	n.Close()
+	n.Notify(uri, map[string]string{"afTransId": "Trans2"}, nil)
+	require.Len(t, n.GetDeadLetters(), numDeadLetters+1)
+	require.Zero(t, atomic.LoadInt32(numReqs))
+}
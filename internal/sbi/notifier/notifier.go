@@ -1,14 +1,38 @@
 package notifier
 
+import (
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
 type Notifier struct {
 	PfdChangeNotifier *PfdChangeNotifier
+	AfNotifier        *AfNotifier
+	TriggerDeliverer  TriggerDeliverer
+	ValidityScheduler *ValidityScheduler
 }
 
-func NewNotifier() (*Notifier, error) {
+func NewNotifier(cfg *factory.Config, store nef_context.Store) (*Notifier, error) {
 	var err error
 	n := &Notifier{}
-	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(); err != nil {
+	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(store); err != nil {
+		return nil, err
+	}
+	if n.AfNotifier, err = NewAfNotifier(); err != nil {
+		return nil, err
+	}
+	if n.TriggerDeliverer, err = NewTriggerDeliverer(cfg.TriggerDeliverer(), cfg.TriggerPath()); err != nil {
+		return nil, err
+	}
+	if n.ValidityScheduler, err = NewValidityScheduler(); err != nil {
 		return nil, err
 	}
 	return n, nil
 }
+
+func (n *Notifier) Close() error {
+	n.PfdChangeNotifier.Close()
+	n.AfNotifier.Close()
+	n.ValidityScheduler.Close()
+	return n.TriggerDeliverer.Close()
+}
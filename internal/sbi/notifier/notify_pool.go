@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notifyJob is a notification delivered by notifyPool
+type notifyJob interface {
+	// target returns the URI which the notification is sent to
+	target() string
+	// post sends the notification once and returns the HTTP status of response
+	post(ctx context.Context) (int, error)
+}
+
+// notifyPool delivers the notifications from a bounded queue by a fixed number of workers,
+// and retries with exponential backoff when the receiver is unreachable or responds with
+// a server error. The undelivered notifications are handed over to onFailure.
+type notifyPool struct {
+	log           *logrus.Entry
+	timeout       time.Duration
+	maxRetry      int
+	retryInterval time.Duration
+	onSuccess     func(job notifyJob)
+	onFailure     func(job notifyJob, attempts int, reason string)
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan notifyJob
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// start runs the workers, the pool shall be configured before
+func (p *notifyPool) start(workers, queueSize int) {
+	p.queue = make(chan notifyJob, queueSize)
+	p.done = make(chan struct{})
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.runWorker()
+	}
+}
+
+// Close stops the workers, the notifications still in queue are handed over to onFailure
+func (p *notifyPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+
+	// No job is enqueued once closed, so the queue is drained here
+	for {
+		select {
+		case job := <-p.queue:
+			p.onFailure(job, 0, "notifier is closed")
+		default:
+			return
+		}
+	}
+}
+
+// enqueue hands the job over to the workers without blocking.
+// The job is handed over to onFailure if the pool is closed or the queue is full.
+func (p *notifyPool) enqueue(job notifyJob) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.onFailure(job, 0, "notifier is closed")
+		return
+	}
+	select {
+	case p.queue <- job:
+	default:
+		p.onFailure(job, 0, "notification queue is full")
+	}
+}
+
+func (p *notifyPool) runWorker() {
+	defer func() {
+		if r := recover(); r != nil {
+			p.log.Errorf("panic: %v\n%s", r, string(debug.Stack()))
+		}
+		p.wg.Done()
+	}()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case job := <-p.queue:
+			p.deliver(job)
+		}
+	}
+}
+
+// deliver sends the notification and retries with exponential backoff
+// when the receiver is unreachable or responds with a server error
+func (p *notifyPool) deliver(job notifyJob) {
+	var reason string
+	interval := p.retryInterval
+	attempts := 0
+	for attempts <= p.maxRetry {
+		if attempts > 0 {
+			select {
+			case <-p.done:
+				p.onFailure(job, attempts, "notifier is closed")
+				return
+			case <-time.After(interval):
+			}
+			interval *= 2
+		}
+		attempts++
+
+		status, err := p.post(job)
+		switch {
+		case err != nil:
+			reason = err.Error()
+		case status >= http.StatusOK && status < http.StatusMultipleChoices:
+			p.onSuccess(job)
+			return
+		case status < http.StatusInternalServerError:
+			// Client errors will not be recovered by retrying
+			p.onFailure(job, attempts, fmt.Sprintf("rejected with status[%d]", status))
+			return
+		default:
+			reason = fmt.Sprintf("status[%d]", status)
+		}
+		p.log.Warnf("Notify [%s] attempt %d failed: %s", job.target(), attempts, reason)
+	}
+	p.onFailure(job, attempts, reason)
+}
+
+func (p *notifyPool) post(job notifyJob) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	return job.post(ctx)
+}
@@ -2,36 +2,137 @@ package notifier
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"runtime/debug"
+	"fmt"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/openapi/Nnef_PFDmanagement"
 	"github.com/free5gc/openapi/models"
 )
 
+const (
+	PfdNotifyWorkers       = 4
+	PfdNotifyQueueSize     = 1024
+	PfdNotifyTimeout       = 5 * time.Second
+	PfdNotifyMaxRetry      = 3
+	PfdNotifyRetryInterval = 1 * time.Second
+	PfdNotifyMaxDeadLetter = 1000
+)
+
 type PfdChangeNotifier struct {
 	clientPfdManagement *Nnef_PFDmanagement.APIClient
 	mu                  sync.RWMutex
 
 	numPfdSubID   uint64
+	subs          map[string]*PfdSub
 	appIdToSubIDs map[string]map[string]bool
-	subIdToURI    map[string]string
+	// Subscriptions without application IDs, which monitor all applications
+	wildcardSubIDs map[string]bool
+	store          nef_context.Store
+
+	// Delivery of notifications
+	pool *notifyPool
+
+	// Notifications waiting for the allowed delay, by subscription ID
+	pendingMu sync.Mutex
+	pending   map[string]*pfdPendingNotify
+
+	deadLetterMu sync.RWMutex
+	deadLetters  []PfdDeadLetter
+}
+
+// pfdPendingNotify holds the notifications of a subscription until the deadline,
+// so that the changes within the allowed delay are delivered in one request
+type pfdPendingNotify struct {
+	notifications map[string]models.PfdChangeNotification
+	deadline      time.Time
+	timer         *time.Timer
+}
+
+type pfdNotifyJob struct {
+	client        *Nnef_PFDmanagement.APIClient
+	subID         string
+	notifyUri     string
+	notifications []models.PfdChangeNotification
 }
 
+// PfdDeadLetter records the notifications which could not be delivered to the subscriber
+type PfdDeadLetter struct {
+	SubID         string                         `json:"subId"`
+	NotifyUri     string                         `json:"notifyUri"`
+	Notifications []models.PfdChangeNotification `json:"notifications"`
+	Attempts      int                            `json:"attempts"`
+	Reason        string                         `json:"reason"`
+	Time          time.Time                      `json:"time"`
+}
+
+// PfdSub is a PFD subscription held by PfdChangeNotifier
+type PfdSub struct {
+	SubID             string   `json:"subId"`
+	NotifyUri         string   `json:"notifyUri"`
+	ApplicationIds    []string `json:"applicationIds,omitempty"`
+	SupportedFeatures string   `json:"supportedFeatures,omitempty"`
+}
+
+const storeKeyPfdSubID = "numPfdSubID"
+
 type PfdNotifyContext struct {
 	notifier             *PfdChangeNotifier
 	appIdToNotification  map[string]models.PfdChangeNotification
+	appIdToAllowedDelay  map[string]time.Duration
 	subIdToChangedAppIDs map[string][]string
 }
 
-func NewPfdChangeNotifier() (*PfdChangeNotifier, error) {
-	return &PfdChangeNotifier{
-		appIdToSubIDs: make(map[string]map[string]bool),
-		subIdToURI:    make(map[string]string),
-	}, nil
+func NewPfdChangeNotifier(store nef_context.Store) (*PfdChangeNotifier, error) {
+	n := &PfdChangeNotifier{
+		subs:           make(map[string]*PfdSub),
+		appIdToSubIDs:  make(map[string]map[string]bool),
+		wildcardSubIDs: make(map[string]bool),
+		store:          store,
+		pending:        make(map[string]*pfdPendingNotify),
+	}
+	n.initPfdManagementApiClient()
+	if err := n.restore(); err != nil {
+		return nil, err
+	}
+
+	n.pool = &notifyPool{
+		log:           logger.PFDManageLog,
+		timeout:       PfdNotifyTimeout,
+		maxRetry:      PfdNotifyMaxRetry,
+		retryInterval: PfdNotifyRetryInterval,
+		onSuccess: func(job notifyJob) {
+			metrics.IncPfdNotification(metrics.PfdNotifySuccess)
+		},
+		onFailure: func(job notifyJob, attempts int, reason string) {
+			n.addDeadLetter(job.(*pfdNotifyJob), attempts, reason)
+		},
+	}
+	n.pool.start(PfdNotifyWorkers, PfdNotifyQueueSize)
+	return n, nil
+}
+
+// Close stops the workers. The notifications still in queue or waiting for the allowed delay
+// are recorded as dead letters.
+func (n *PfdChangeNotifier) Close() {
+	n.pool.Close()
+
+	n.pendingMu.Lock()
+	pending := n.pending
+	n.pending = make(map[string]*pfdPendingNotify)
+	n.pendingMu.Unlock()
+	for subID, p := range pending {
+		p.timer.Stop()
+		// Recorded as dead letter by the closed pool
+		n.notify(subID, p.notifications)
+	}
 }
 
 func (n *PfdChangeNotifier) initPfdManagementApiClient() {
@@ -46,93 +147,372 @@ func (n *PfdChangeNotifier) initPfdManagementApiClient() {
 	n.clientPfdManagement = Nnef_PFDmanagement.NewAPIClient(config)
 }
 
-func (n *PfdChangeNotifier) AddPfdSub(pfdSub *models.PfdSubscription) string {
-	n.initPfdManagementApiClient()
+func (n *PfdChangeNotifier) restore() error {
+	values, err := n.store.Load(nef_context.StoreBucketPfdSub)
+	if err != nil {
+		return fmt.Errorf("Restore PFD subscriptions err: %+v", err)
+	}
+	for subID, value := range values {
+		sub := &PfdSub{}
+		if err = json.Unmarshal(value, sub); err != nil {
+			return fmt.Errorf("Restore PFD subscription[%s] err: %+v", subID, err)
+		}
+		n.indexPfdSub(sub)
+	}
+
+	counters, err := n.store.Load(nef_context.StoreBucketCounter)
+	if err != nil {
+		return fmt.Errorf("Restore counters err: %+v", err)
+	}
+	if value, ok := counters[storeKeyPfdSubID]; ok {
+		if n.numPfdSubID, err = strconv.ParseUint(string(value), 10, 64); err != nil {
+			return fmt.Errorf("Restore numPfdSubID err: %+v", err)
+		}
+	}
+	if len(n.subs) > 0 {
+		logger.PFDManageLog.Infof("%d PFD subscriptions are restored", len(n.subs))
+	}
+	return nil
+}
 
+func (n *PfdChangeNotifier) AddPfdSub(pfdSub *models.PfdSubscription) string {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	n.numPfdSubID++
-	subID := strconv.FormatUint(n.numPfdSubID, 10)
-	n.subIdToURI[subID] = pfdSub.NotifyUri
-	// TODO: If pfdSub.ApplicationIds is empty, it may means monitoring all appIDs
-	for _, appID := range pfdSub.ApplicationIds {
-		if _, exist := n.appIdToSubIDs[appID]; !exist {
-			n.appIdToSubIDs[appID] = make(map[string]bool)
-		}
-		n.appIdToSubIDs[appID][subID] = true
+	value := []byte(strconv.FormatUint(n.numPfdSubID, 10))
+	if err := n.store.Put(nef_context.StoreBucketCounter, storeKeyPfdSubID, value); err != nil {
+		logger.PFDManageLog.Errorf("Save numPfdSubID err: %+v", err)
 	}
 
-	return subID
+	sub := newPfdSub(strconv.FormatUint(n.numPfdSubID, 10), pfdSub)
+	n.indexPfdSub(sub)
+	n.savePfdSub(sub)
+	return sub.SubID
+}
+
+// UpdatePfdSub replaces the notify URI, application IDs and supported features of the subscription
+func (n *PfdChangeNotifier) UpdatePfdSub(subID string, pfdSub *models.PfdSubscription) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, exist := n.subs[subID]; !exist {
+		return errors.New("Subscription not found")
+	}
+	n.unindexPfdSub(subID)
+	sub := newPfdSub(subID, pfdSub)
+	n.indexPfdSub(sub)
+	n.savePfdSub(sub)
+	return nil
 }
 
 func (n *PfdChangeNotifier) DeletePfdSub(subID string) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if _, exist := n.subIdToURI[subID]; !exist {
+	if _, exist := n.subs[subID]; !exist {
 		return errors.New("Subscription not found")
 	}
-	delete(n.subIdToURI, subID)
-	for _, subIDs := range n.appIdToSubIDs {
-		delete(subIDs, subID)
+	n.unindexPfdSub(subID)
+	if err := n.store.Delete(nef_context.StoreBucketPfdSub, subID); err != nil {
+		logger.PFDManageLog.Errorf("Delete PFD subscription[%s] from store err: %+v", subID, err)
 	}
 	return nil
 }
 
+func (n *PfdChangeNotifier) GetPfdSub(subID string) (PfdSub, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	sub, exist := n.subs[subID]
+	if !exist {
+		return PfdSub{}, errors.New("Subscription not found")
+	}
+	return sub.clone(), nil
+}
+
+// GetPfdSubs returns all PFD subscriptions sorted by subscription ID
+func (n *PfdChangeNotifier) GetPfdSubs() []PfdSub {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	subs := make([]PfdSub, 0, len(n.subs))
+	for _, sub := range n.subs {
+		subs = append(subs, sub.clone())
+	}
+	// Subscription IDs are decimal numbers without leading zeros
+	sort.Slice(subs, func(i, j int) bool {
+		if len(subs[i].SubID) != len(subs[j].SubID) {
+			return len(subs[i].SubID) < len(subs[j].SubID)
+		}
+		return subs[i].SubID < subs[j].SubID
+	})
+	return subs
+}
+
+func newPfdSub(subID string, pfdSub *models.PfdSubscription) *PfdSub {
+	sub := &PfdSub{
+		SubID:             subID,
+		NotifyUri:         pfdSub.NotifyUri,
+		SupportedFeatures: pfdSub.SupportedFeatures,
+	}
+	if len(pfdSub.ApplicationIds) > 0 {
+		sub.ApplicationIds = append([]string(nil), pfdSub.ApplicationIds...)
+	}
+	return sub
+}
+
+func (s *PfdSub) clone() PfdSub {
+	sub := *s
+	if len(s.ApplicationIds) > 0 {
+		sub.ApplicationIds = append([]string(nil), s.ApplicationIds...)
+	}
+	return sub
+}
+
+// indexPfdSub shall be called with n.mu held
+func (n *PfdChangeNotifier) indexPfdSub(sub *PfdSub) {
+	n.subs[sub.SubID] = sub
+	if len(sub.ApplicationIds) == 0 {
+		n.wildcardSubIDs[sub.SubID] = true
+		return
+	}
+	for _, appID := range sub.ApplicationIds {
+		if _, exist := n.appIdToSubIDs[appID]; !exist {
+			n.appIdToSubIDs[appID] = make(map[string]bool)
+		}
+		n.appIdToSubIDs[appID][sub.SubID] = true
+	}
+}
+
+// unindexPfdSub shall be called with n.mu held
+func (n *PfdChangeNotifier) unindexPfdSub(subID string) {
+	delete(n.subs, subID)
+	delete(n.wildcardSubIDs, subID)
+	for appID, subIDs := range n.appIdToSubIDs {
+		delete(subIDs, subID)
+		if len(subIDs) == 0 {
+			delete(n.appIdToSubIDs, appID)
+		}
+	}
+}
+
+// savePfdSub shall be called with n.mu held
+func (n *PfdChangeNotifier) savePfdSub(sub *PfdSub) {
+	value, err := json.Marshal(sub)
+	if err != nil {
+		logger.PFDManageLog.Errorf("Marshal PFD subscription[%s] err: %+v", sub.SubID, err)
+		return
+	}
+	if err = n.store.Put(nef_context.StoreBucketPfdSub, sub.SubID, value); err != nil {
+		logger.PFDManageLog.Errorf("Save PFD subscription[%s] err: %+v", sub.SubID, err)
+	}
+}
+
 func (n *PfdChangeNotifier) getSubIDs(appID string) []string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
-	subIDs := make([]string, 0, len(n.appIdToSubIDs[appID]))
+	subIDs := make([]string, 0, len(n.appIdToSubIDs[appID])+len(n.wildcardSubIDs))
 	for subID := range n.appIdToSubIDs[appID] {
 		subIDs = append(subIDs, subID)
 	}
+	for subID := range n.wildcardSubIDs {
+		subIDs = append(subIDs, subID)
+	}
 	return subIDs
 }
 
 func (n *PfdChangeNotifier) getSubURI(subID string) string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
-	return n.subIdToURI[subID]
+
+	if sub, exist := n.subs[subID]; exist {
+		return sub.NotifyUri
+	}
+	return ""
 }
 
 func (n *PfdChangeNotifier) NewPfdNotifyContext() *PfdNotifyContext {
 	return &PfdNotifyContext{
 		notifier:             n,
 		appIdToNotification:  make(map[string]models.PfdChangeNotification),
+		appIdToAllowedDelay:  make(map[string]time.Duration),
 		subIdToChangedAppIDs: make(map[string][]string),
 	}
 }
 
 func (nc *PfdNotifyContext) AddNotification(appID string, notif *models.PfdChangeNotification) {
-	nc.appIdToNotification[appID] = *notif
-	for _, subID := range nc.notifier.getSubIDs(appID) {
-		nc.subIdToChangedAppIDs[subID] = append(nc.subIdToChangedAppIDs[subID], appID)
+	if _, exist := nc.appIdToNotification[appID]; !exist {
+		for _, subID := range nc.notifier.getSubIDs(appID) {
+			nc.subIdToChangedAppIDs[subID] = append(nc.subIdToChangedAppIDs[subID], appID)
+		}
 	}
+	nc.appIdToNotification[appID] = *notif
+	delete(nc.appIdToAllowedDelay, appID)
+}
+
+// AddDelayedNotification adds the notification which is allowed to be delivered after the delay,
+// it is coalesced with the other changes of the same subscription within the delay
+func (nc *PfdNotifyContext) AddDelayedNotification(
+	appID string, notif *models.PfdChangeNotification, allowedDelay time.Duration,
+) {
+	nc.AddNotification(appID, notif)
+	nc.appIdToAllowedDelay[appID] = allowedDelay
+}
+
+// Discard drops the notifications added so far, e.g. when the transaction is rolled back
+func (nc *PfdNotifyContext) Discard() {
+	nc.appIdToNotification = make(map[string]models.PfdChangeNotification)
+	nc.appIdToAllowedDelay = make(map[string]time.Duration)
+	nc.subIdToChangedAppIDs = make(map[string][]string)
 }
 
 func (nc *PfdNotifyContext) FlushNotifications() {
+	now := time.Now()
 	for subID, appIDs := range nc.subIdToChangedAppIDs {
-		pfdChangeNotifications := make([]models.PfdChangeNotification, 0, len(appIDs))
+		notifications := make(map[string]models.PfdChangeNotification, len(appIDs))
+		var deadline time.Time
+		immediate := false
 		for _, appID := range appIDs {
-			pfdChangeNotifications = append(pfdChangeNotifications, nc.appIdToNotification[appID])
+			notifications[appID] = nc.appIdToNotification[appID]
+			allowedDelay, delayed := nc.appIdToAllowedDelay[appID]
+			if !delayed {
+				immediate = true
+			} else if d := now.Add(allowedDelay); deadline.IsZero() || d.Before(deadline) {
+				deadline = d
+			}
 		}
+		if immediate {
+			deadline = now
+		}
+		nc.notifier.schedule(subID, notifications, deadline)
+	}
+}
 
-		go func(id string) {
-			defer func() {
-				if p := recover(); p != nil {
-					// Print stack for panic to log. Fatalf() will let program exit.
-					logger.PFDManageLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
-				}
-			}()
-
-			_, _, err := nc.notifier.clientPfdManagement.NotificationApi.NotificationPost(
-				context.TODO(), nc.notifier.getSubURI(id), pfdChangeNotifications)
-			if err != nil {
-				logger.PFDManageLog.Fatal(err)
-			}
-		}(subID)
-		// TODO: Handle the response of notification properly
+// schedule delivers the notifications of the subscription no later than the deadline.
+// The notifications are merged into the pending ones, where the newer notification of
+// an application replaces the older one, and all of them are delivered at the earliest deadline.
+func (n *PfdChangeNotifier) schedule(
+	subID string, notifications map[string]models.PfdChangeNotification, deadline time.Time,
+) {
+	n.pendingMu.Lock()
+	p, exist := n.pending[subID]
+	if !exist {
+		p = &pfdPendingNotify{
+			notifications: make(map[string]models.PfdChangeNotification),
+		}
+		n.pending[subID] = p
+	}
+	for appID, notif := range notifications {
+		p.notifications[appID] = notif
+	}
+
+	delay := time.Until(deadline)
+	if delay <= 0 {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		delete(n.pending, subID)
+		n.pendingMu.Unlock()
+		n.notify(subID, p.notifications)
+		return
+	}
+	if p.timer == nil || deadline.Before(p.deadline) {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		p.deadline = deadline
+		p.timer = time.AfterFunc(delay, func() {
+			n.flushPending(subID)
+		})
+	}
+	n.pendingMu.Unlock()
+}
+
+func (n *PfdChangeNotifier) flushPending(subID string) {
+	n.pendingMu.Lock()
+	p, exist := n.pending[subID]
+	delete(n.pending, subID)
+	n.pendingMu.Unlock()
+
+	if exist {
+		n.notify(subID, p.notifications)
+	}
+}
+
+func (n *PfdChangeNotifier) notify(subID string, notifications map[string]models.PfdChangeNotification) {
+	notifyUri := n.getSubURI(subID)
+	if notifyUri == "" {
+		// Unsubscribed after the notifications were added
+		return
+	}
+
+	appIDs := make([]string, 0, len(notifications))
+	for appID := range notifications {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+	pfdChangeNotifications := make([]models.PfdChangeNotification, 0, len(appIDs))
+	for _, appID := range appIDs {
+		pfdChangeNotifications = append(pfdChangeNotifications, notifications[appID])
+	}
+	n.pool.enqueue(&pfdNotifyJob{
+		client:        n.clientPfdManagement,
+		subID:         subID,
+		notifyUri:     notifyUri,
+		notifications: pfdChangeNotifications,
+	})
+}
+
+func (job *pfdNotifyJob) target() string {
+	return job.notifyUri
+}
+
+func (job *pfdNotifyJob) post(ctx context.Context) (int, error) {
+	pfdChangeReports, rsp, err := job.client.NotificationApi.NotificationPost(
+		ctx, job.notifyUri, job.notifications)
+	if rsp != nil && rsp.Body != nil {
+		if rspCloseErr := rsp.Body.Close(); rspCloseErr != nil {
+			logger.PFDManageLog.Errorf("Response body cannot close: %+v", rspCloseErr)
+		}
+	}
+	if err != nil {
+		return 0, err
 	}
+	for _, report := range pfdChangeReports {
+		logger.PFDManageLog.Warnf("Subscriber [%s] failed to apply PFDs of %v: %+v",
+			job.notifyUri, report.ApplicationId, report.PfdError)
+	}
+	return rsp.StatusCode, nil
+}
+
+func (n *PfdChangeNotifier) addDeadLetter(job *pfdNotifyJob, attempts int, reason string) {
+	logger.PFDManageLog.Errorf("Notify PFD change to [%s] failed after %d attempts: %s",
+		job.notifyUri, attempts, reason)
+	metrics.IncPfdNotification(metrics.PfdNotifyFailure)
+
+	n.deadLetterMu.Lock()
+	defer n.deadLetterMu.Unlock()
+	if len(n.deadLetters) >= PfdNotifyMaxDeadLetter {
+		n.deadLetters = n.deadLetters[1:]
+	}
+	n.deadLetters = append(n.deadLetters, PfdDeadLetter{
+		SubID:         job.subID,
+		NotifyUri:     job.notifyUri,
+		Notifications: job.notifications,
+		Attempts:      attempts,
+		Reason:        reason,
+		Time:          time.Now(),
+	})
+}
+
+// GetDeadLetters returns the undelivered notifications, the oldest first
+func (n *PfdChangeNotifier) GetDeadLetters() []PfdDeadLetter {
+	n.deadLetterMu.RLock()
+	defer n.deadLetterMu.RUnlock()
+
+	deadLetters := make([]PfdDeadLetter, len(n.deadLetters))
+	copy(deadLetters, n.deadLetters)
+	return deadLetters
 }
@@ -0,0 +1,259 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func newTestPfdSubscriber(t *testing.T, statuses ...int) (string, *int32) {
+	var numReqs int32
+	srv := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := atomic.AddInt32(&numReqs, 1) - 1
+		status := statuses[len(statuses)-1]
+		if int(i) < len(statuses) {
+			status = statuses[i]
+		}
+		w.WriteHeader(status)
+	}), &http2.Server{}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &numReqs
+}
+
+func TestPfdChangeNotifierDelivery(t *testing.T) {
+	n, err := NewPfdChangeNotifier(nef_context.NewMemStore())
+	require.NoError(t, err)
+	defer n.Close()
+	n.pool.retryInterval = 10 * time.Millisecond
+
+	testCases := []struct {
+		description         string
+		statuses            []int
+		expectedReqs        int32
+		expectedDeadLetters int
+	}{
+		{
+			description:  "TC1: Delivered after server errors, should retry",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusNoContent},
+			expectedReqs: 3,
+		},
+		{
+			description:         "TC2: Subscriber keeps failing, should be dead letter after retries",
+			statuses:            []int{http.StatusServiceUnavailable},
+			expectedReqs:        PfdNotifyMaxRetry + 1,
+			expectedDeadLetters: 1,
+		},
+		{
+			description:         "TC3: Rejected by subscriber, should be dead letter without retry",
+			statuses:            []int{http.StatusNotFound},
+			expectedReqs:        1,
+			expectedDeadLetters: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			uri, numReqs := newTestPfdSubscriber(t, tc.statuses...)
+			subID := n.AddPfdSub(&models.PfdSubscription{
+				ApplicationIds: []string{"app1"},
+				NotifyUri:      uri,
+			})
+			defer func() {
+				require.NoError(t, n.DeletePfdSub(subID))
+			}()
+			numDeadLetters := len(n.GetDeadLetters())
+
+			nc := n.NewPfdNotifyContext()
+			nc.AddNotification("app1", &models.PfdChangeNotification{
+				ApplicationId: "app1",
+				RemovalFlag:   true,
+			})
+			nc.FlushNotifications()
+
+			require.Eventually(t, func() bool {
+				return atomic.LoadInt32(numReqs) == tc.expectedReqs &&
+					len(n.GetDeadLetters()) == numDeadLetters+tc.expectedDeadLetters
+			}, 2*time.Second, 10*time.Millisecond)
+
+			if tc.expectedDeadLetters > 0 {
+				deadLetter := n.GetDeadLetters()[numDeadLetters]
+				require.Equal(t, subID, deadLetter.SubID)
+				require.Equal(t, int(tc.expectedReqs), deadLetter.Attempts)
+			}
+		})
+	}
+}
+
+func TestPfdChangeNotifierClose(t *testing.T) {
+	n, err := NewPfdChangeNotifier(nef_context.NewMemStore())
+	require.NoError(t, err)
+	n.pool.timeout = 200 * time.Millisecond
+
+	// The subscriber never responds, so the workers are busy and the rest of jobs stay in queue
+	var numReqs int32
+	srv := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numReqs, 1)
+		<-r.Context().Done()
+	}), &http2.Server{}))
+	defer srv.Close()
+	n.AddPfdSub(&models.PfdSubscription{NotifyUri: srv.URL})
+
+	numJobs := PfdNotifyWorkers + 2
+	for i := 0; i < numJobs; i++ {
+		nc := n.NewPfdNotifyContext()
+		nc.AddNotification("app1", &models.PfdChangeNotification{ApplicationId: "app1", RemovalFlag: true})
+		nc.FlushNotifications()
+	}
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&numReqs) == PfdNotifyWorkers
+	}, 2*time.Second, 10*time.Millisecond)
+
+	n.Close()
+	require.Len(t, n.GetDeadLetters(), numJobs)
+	for _, deadLetter := range n.GetDeadLetters() {
+		require.Equal(t, "1", deadLetter.SubID)
+	}
+}
+
+func TestPfdChangeNotifierWildcardSub(t *testing.T) {
+	n, err := NewPfdChangeNotifier(nef_context.NewMemStore())
+	require.NoError(t, err)
+	defer n.Close()
+
+	app1SubID := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://127.0.0.1:8000/app1",
+	})
+	wildcardSubID := n.AddPfdSub(&models.PfdSubscription{
+		NotifyUri: "http://127.0.0.1:8000/all",
+	})
+
+	nc := n.NewPfdNotifyContext()
+	nc.AddNotification("app1", &models.PfdChangeNotification{ApplicationId: "app1", RemovalFlag: true})
+	nc.AddNotification("app2", &models.PfdChangeNotification{ApplicationId: "app2", RemovalFlag: true})
+	require.Equal(t, map[string][]string{
+		app1SubID:     {"app1"},
+		wildcardSubID: {"app1", "app2"},
+	}, nc.subIdToChangedAppIDs)
+	require.Equal(t, []PfdSub{
+		{SubID: app1SubID, NotifyUri: "http://127.0.0.1:8000/app1", ApplicationIds: []string{"app1"}},
+		{SubID: wildcardSubID, NotifyUri: "http://127.0.0.1:8000/all"},
+	}, n.GetPfdSubs())
+
+	require.NoError(t, n.DeletePfdSub(wildcardSubID))
+	nc = n.NewPfdNotifyContext()
+	nc.AddNotification("app2", &models.PfdChangeNotification{ApplicationId: "app2", RemovalFlag: true})
+	require.Empty(t, nc.subIdToChangedAppIDs)
+}
+
+func TestPfdChangeNotifierRestore(t *testing.T) {
+	store := nef_context.NewMemStore()
+	n, err := NewPfdChangeNotifier(store)
+	require.NoError(t, err)
+
+	subID1 := n.AddPfdSub(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://127.0.0.1:8000/sub1",
+	})
+	subID2 := n.AddPfdSub(&models.PfdSubscription{
+		NotifyUri: "http://127.0.0.1:8000/sub2",
+	})
+	require.NoError(t, n.UpdatePfdSub(subID1, &models.PfdSubscription{
+		ApplicationIds:    []string{"app2"},
+		NotifyUri:         "http://127.0.0.1:8000/sub1-new",
+		SupportedFeatures: "01",
+	}))
+	require.NoError(t, n.DeletePfdSub(subID2))
+	n.Close()
+
+	n, err = NewPfdChangeNotifier(store)
+	require.NoError(t, err)
+	defer n.Close()
+
+	require.Equal(t, []PfdSub{
+		{
+			SubID:             subID1,
+			NotifyUri:         "http://127.0.0.1:8000/sub1-new",
+			ApplicationIds:    []string{"app2"},
+			SupportedFeatures: "01",
+		},
+	}, n.GetPfdSubs())
+	require.Empty(t, n.getSubIDs("app1"))
+	require.Equal(t, []string{subID1}, n.getSubIDs("app2"))
+	require.Equal(t, "3", n.AddPfdSub(&models.PfdSubscription{NotifyUri: "http://127.0.0.1:8000/sub3"}))
+}
+
+func TestPfdChangeNotifierAllowedDelay(t *testing.T) {
+	n, err := NewPfdChangeNotifier(nef_context.NewMemStore())
+	require.NoError(t, err)
+	defer n.Close()
+
+	reqs := make(chan []models.PfdChangeNotification, 10)
+	srv := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notifs []models.PfdChangeNotification
+		if err := json.NewDecoder(r.Body).Decode(&notifs); err == nil {
+			reqs <- notifs
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}), &http2.Server{}))
+	defer srv.Close()
+	n.AddPfdSub(&models.PfdSubscription{NotifyUri: srv.URL})
+
+	notify := func(appID string, allowedDelay time.Duration) {
+		nc := n.NewPfdNotifyContext()
+		notif := &models.PfdChangeNotification{ApplicationId: appID, RemovalFlag: true}
+		if allowedDelay > 0 {
+			nc.AddDelayedNotification(appID, notif, allowedDelay)
+		} else {
+			nc.AddNotification(appID, notif)
+		}
+		nc.FlushNotifications()
+	}
+	appIDs := func(notifs []models.PfdChangeNotification) []string {
+		ids := make([]string, 0, len(notifs))
+		for _, notif := range notifs {
+			ids = append(ids, notif.ApplicationId)
+		}
+		return ids
+	}
+
+	// Changes within the allowed delay are delivered together at the earliest deadline
+	start := time.Now()
+	notify("app1", 200*time.Millisecond)
+	notify("app2", time.Hour)
+	select {
+	case notifs := <-reqs:
+		require.Equal(t, []string{"app1", "app2"}, appIDs(notifs))
+		require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "Delayed notifications are not delivered")
+	}
+
+	// A change without allowed delay delivers the pending ones at once
+	notify("app3", time.Hour)
+	notify("app4", 0)
+	select {
+	case notifs := <-reqs:
+		require.Equal(t, []string{"app3", "app4"}, appIDs(notifs))
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "Notifications are not delivered")
+	}
+	require.Empty(t, reqs)
+
+	// The changes still waiting for the allowed delay are recorded as dead letters on close
+	notify("app5", time.Hour)
+	n.Close()
+	require.Empty(t, reqs)
+	deadLetters := n.GetDeadLetters()
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, []string{"app5"}, appIDs(deadLetters[0].Notifications))
+}
@@ -0,0 +1,167 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+// TriggerMessage is a device trigger message handed over to the delivery backend
+type TriggerMessage struct {
+	// Unique reference of the message, used for recall and replacement
+	Ref               string              `json:"ref"`
+	ExternalId        string              `json:"externalId,omitempty"`
+	Msisdn            string              `json:"msisdn,omitempty"`
+	ValidityPeriod    int32               `json:"validityPeriod"`
+	Priority          nef_models.Priority `json:"priority"`
+	ApplicationPortId int32               `json:"applicationPortId"`
+	AppSrcPortId      int32               `json:"appSrcPortId,omitempty"`
+	Payload           []byte              `json:"payload"`
+}
+
+// TriggerReportFunc is invoked by TriggerDeliverer when the delivery result of a message is known
+type TriggerReportFunc func(ref string, result nef_models.DeliveryResult)
+
+// TriggerDeliverer delivers device trigger messages towards UE, e.g. via SMS-SC over T4.
+// Deliver shall not block on the delivery itself; the result is reported through the TriggerReportFunc.
+type TriggerDeliverer interface {
+	Deliver(msg *TriggerMessage, report TriggerReportFunc) error
+	Recall(ref string) error
+	Close() error
+}
+
+func NewTriggerDeliverer(delivererType, path string) (TriggerDeliverer, error) {
+	switch delivererType {
+	case factory.NefTriggerLoopback:
+		return NewLoopbackDeliverer(), nil
+	case factory.NefTriggerFile:
+		return NewFileDeliverer(path)
+	default:
+		return nil, fmt.Errorf("Unsupported trigger deliverer[%s]", delivererType)
+	}
+}
+
+// LoopbackDeliverer accepts every message and reports SUCCESS immediately.
+// It is intended for testing without SMS-SC.
+type LoopbackDeliverer struct {
+	msgs map[string]*TriggerMessage
+	mu   sync.Mutex
+}
+
+func NewLoopbackDeliverer() *LoopbackDeliverer {
+	return &LoopbackDeliverer{
+		msgs: make(map[string]*TriggerMessage),
+	}
+}
+
+func (d *LoopbackDeliverer) Deliver(msg *TriggerMessage, report TriggerReportFunc) error {
+	d.mu.Lock()
+	d.msgs[msg.Ref] = msg
+	d.mu.Unlock()
+
+	reportInBackground(msg.Ref, nef_models.DeliveryResult_SUCCESS,
+		func(ref string, result nef_models.DeliveryResult) {
+			// The message is not kept once delivered, unless it has been recalled
+			d.mu.Lock()
+			_, ok := d.msgs[ref]
+			delete(d.msgs, ref)
+			d.mu.Unlock()
+			if ok && report != nil {
+				report(ref, result)
+			}
+		})
+	return nil
+}
+
+func (d *LoopbackDeliverer) Recall(ref string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.msgs[ref]; !ok {
+		return fmt.Errorf("Trigger message[%s] not found", ref)
+	}
+	delete(d.msgs, ref)
+	return nil
+}
+
+func (d *LoopbackDeliverer) Close() error {
+	return nil
+}
+
+// FileDeliverer appends every message to a local file as a JSON line
+// and reports SUCCESS once the record is written.
+type FileDeliverer struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+type triggerRecord struct {
+	Time   time.Time       `json:"time"`
+	Action string          `json:"action"`
+	Ref    string          `json:"ref"`
+	Msg    *TriggerMessage `json:"msg,omitempty"`
+}
+
+func NewFileDeliverer(path string) (*FileDeliverer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("Open trigger file[%s] err: %+v", path, err)
+	}
+	return &FileDeliverer{file: file}, nil
+}
+
+func (d *FileDeliverer) Deliver(msg *TriggerMessage, report TriggerReportFunc) error {
+	if err := d.write(&triggerRecord{Action: "deliver", Ref: msg.Ref, Msg: msg}); err != nil {
+		return err
+	}
+	reportInBackground(msg.Ref, nef_models.DeliveryResult_SUCCESS, report)
+	return nil
+}
+
+func (d *FileDeliverer) Recall(ref string) error {
+	return d.write(&triggerRecord{Action: "recall", Ref: ref})
+}
+
+func (d *FileDeliverer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.file.Close()
+}
+
+func (d *FileDeliverer) write(record *triggerRecord) error {
+	record.Time = time.Now()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err = d.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("Write trigger file err: %+v", err)
+	}
+	return nil
+}
+
+func reportInBackground(ref string, result nef_models.DeliveryResult, report TriggerReportFunc) {
+	if report == nil {
+		return
+	}
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.NotifierLog.Errorf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		report(ref, result)
+	}()
+}
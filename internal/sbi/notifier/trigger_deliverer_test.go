@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"testing"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopbackDeliverer(t *testing.T) {
+	d := NewLoopbackDeliverer()
+	defer func() {
+		require.NoError(t, d.Close())
+	}()
+
+	reports := make(chan nef_models.DeliveryResult, 1)
+	require.NoError(t, d.Deliver(&TriggerMessage{Ref: "af1/1/1"},
+		func(ref string, result nef_models.DeliveryResult) {
+			require.Equal(t, "af1/1/1", ref)
+			reports <- result
+		}))
+	require.Equal(t, nef_models.DeliveryResult_SUCCESS, <-reports)
+
+	// The delivered message is not kept anymore
+	d.mu.Lock()
+	require.Empty(t, d.msgs)
+	d.mu.Unlock()
+	require.Error(t, d.Recall("af1/1/1"))
+}
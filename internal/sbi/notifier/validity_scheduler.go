@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+)
+
+// ValidityScheduler runs the jobs at the end of the validity of the subscriptions.
+// A job is identified by a key, and scheduling the key again replaces the pending job.
+// The schedule is kept in memory only, the owner rebuilds it from the restored subscriptions.
+type ValidityScheduler struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+	closed bool
+}
+
+func NewValidityScheduler() (*ValidityScheduler, error) {
+	return &ValidityScheduler{
+		timers: make(map[string]*time.Timer),
+	}, nil
+}
+
+// Schedule runs the job at the given time, immediately if the time has passed.
+// The job runs in its own goroutine and should check whether it's still due,
+// since it may race with a later Schedule or Cancel of the same key.
+func (s *ValidityScheduler) Schedule(key string, at time.Time, job func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if timer, ok := s.timers[key]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		s.mu.Lock()
+		if s.timers[key] != timer {
+			// Replaced or canceled after the timer fired
+			s.mu.Unlock()
+			return
+		}
+		delete(s.timers, key)
+		s.mu.Unlock()
+
+		defer func() {
+			if p := recover(); p != nil {
+				logger.NotifierLog.Errorf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+		job()
+	})
+	s.timers[key] = timer
+	logger.NotifierLog.Debugf("Schedule validity job[%s] at %s", key, at)
+}
+
+// Cancel removes the pending job of the key if any
+func (s *ValidityScheduler) Cancel(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[key]; ok {
+		timer.Stop()
+		delete(s.timers, key)
+	}
+}
+
+// Pending returns whether a job of the key is waiting to run
+func (s *ValidityScheduler) Pending(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.timers[key]
+	return ok
+}
+
+// Close stops all pending jobs, no job is scheduled afterwards
+func (s *ValidityScheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for key, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, key)
+	}
+}
@@ -0,0 +1,497 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// All flows of a subscription are carried in a single media component
+const qosMedCompN = 1
+
+func (p *Processor) GetAsSessionWithQoSSubscriptions(
+	scsAsID string,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("GetAsSessionWithQoSSubscriptions - scsAsID[%s]", scsAsID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var qosSubs []nef_models.AsSessionWithQoSSubscription
+	for _, sub := range af.QosSubs {
+		qosSubs = append(qosSubs, *sub.QosSub)
+	}
+	return &HandlerResponse{http.StatusOK, nil, &qosSubs}
+}
+
+func (p *Processor) PostAsSessionWithQoSSubscription(
+	scsAsID string,
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("PostAsSessionWithQoSSubscription - scsAsID[%s]", scsAsID)
+
+	rsp := p.validateAsSessionWithQoSSubscription(qosSub)
+	if rsp != nil {
+		return rsp
+	}
+	if rsp = p.authorizeAfDnnSnssai(scsAsID, qosSub.Dnn, qosSub.Snssai); rsp != nil {
+		return rsp
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(scsAsID)
+	if af == nil {
+		af = nefCtx.NewAf(scsAsID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	correID := nefCtx.NewCorreID()
+	afSub := af.NewQosSub(correID, qosSub)
+	if afSub == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	asc := p.convertAsSessionWithQoSSubToAppSessionContext(qosSub, afSub.NotifCorreID)
+	rspStatus, rspBody, appSessID, pcfUri := p.Consumer().PostAppSessions("", asc)
+	if rspStatus != http.StatusCreated {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+	afSub.AppSessID = appSessID
+	afSub.PcfUri = pcfUri
+
+	af.QosSubs[afSub.SubID] = afSub
+	af.Log.Infoln("AS session with QoS subscription is added")
+
+	nefCtx.AddAf(af)
+
+	qosSub.Self = p.genAsSessionWithQoSSubURI(scsAsID, afSub.SubID)
+	headers := map[string][]string{
+		"Location": {qosSub.Self},
+	}
+	return &HandlerResponse{http.StatusCreated, headers, qosSub}
+}
+
+func (p *Processor) GetIndividualAsSessionWithQoSSubscription(
+	scsAsID, subID string,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("GetIndividualAsSessionWithQoSSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	afSub, ok := af.QosSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, afSub.QosSub}
+}
+
+func (p *Processor) PutIndividualAsSessionWithQoSSubscription(
+	scsAsID, subID string,
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("PutIndividualAsSessionWithQoSSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	rsp := p.validateAsSessionWithQoSSubscription(qosSub)
+	if rsp != nil {
+		return rsp
+	}
+	if rsp = p.authorizeAfDnnSnssai(scsAsID, qosSub.Dnn, qosSub.Snssai); rsp != nil {
+		return rsp
+	}
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afSub, ok := af.QosSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	// UE address can not be changed within the same AF application session
+	if qosSub.UeIpv4Addr != afSub.QosSub.UeIpv4Addr ||
+		qosSub.UeIpv6Addr != afSub.QosSub.UeIpv6Addr ||
+		qosSub.MacAddr != afSub.QosSub.MacAddr {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("UE address can not be modified")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	ascUpdateData := p.convertAsSessionWithQoSSubToAppSessionContextUpdateData(qosSub, afSub.NotifCorreID)
+	rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+
+	qosSub.Self = afSub.QosSub.Self
+	afSub.QosSub = qosSub
+	return &HandlerResponse{http.StatusOK, nil, afSub.QosSub}
+}
+
+func (p *Processor) PatchIndividualAsSessionWithQoSSubscription(
+	scsAsID, subID string,
+	qosSubPatch *nef_models.AsSessionWithQoSSubscriptionPatch,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("PatchIndividualAsSessionWithQoSSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afSub, ok := af.QosSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	qosSub := patchAsSessionWithQoSSubData(afSub.QosSub, qosSubPatch)
+	if rsp := p.validateAsSessionWithQoSSubscription(qosSub); rsp != nil {
+		return rsp
+	}
+
+	ascUpdateData := p.convertAsSessionWithQoSSubToAppSessionContextUpdateData(qosSub, afSub.NotifCorreID)
+	rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+
+	afSub.QosSub = qosSub
+	return &HandlerResponse{http.StatusOK, nil, afSub.QosSub}
+}
+
+func (p *Processor) DeleteIndividualAsSessionWithQoSSubscription(
+	scsAsID, subID string,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("DeleteIndividualAsSessionWithQoSSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afSub, ok := af.QosSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	rspStatus, rspBody := p.Consumer().DeleteAppSession(afSub.PcfUri, afSub.AppSessID)
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+	delete(af.QosSubs, subID)
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+func (p *Processor) validateAsSessionWithQoSSubscription(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+) *HandlerResponse {
+	if qosSub.NotificationDestination == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	// TS29.122: One of "ueIpv4Addr", "ueIpv6Addr" or "macAddr" shall be included.
+	// "flowInfo" is used with the UE IP address and "ethFlowInfo" with the MAC address.
+	switch {
+	case qosSub.UeIpv4Addr != "" || qosSub.UeIpv6Addr != "":
+		if len(qosSub.FlowInfo) == 0 {
+			pd := openapi.ProblemDetailsMalformedReqSyntax("Missing flowInfo for UE IP address")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	case qosSub.MacAddr != "":
+		if len(qosSub.EthFlowInfo) == 0 {
+			pd := openapi.ProblemDetailsMalformedReqSyntax("Missing ethFlowInfo for UE MAC address")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	default:
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing one of ueIpv4Addr, ueIpv6Addr or macAddr")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	if qosSub.QosReference == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing qosReference")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if p.Config().QosReference(qosSub.QosReference) == nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(
+			fmt.Sprintf("Unknown qosReference[%s]", qosSub.QosReference))
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+func patchAsSessionWithQoSSubData(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+	qosSubPatch *nef_models.AsSessionWithQoSSubscriptionPatch,
+) *nef_models.AsSessionWithQoSSubscription {
+	patched := *qosSub
+	if qosSubPatch.FlowInfo != nil {
+		patched.FlowInfo = qosSubPatch.FlowInfo
+	}
+	if qosSubPatch.EthFlowInfo != nil {
+		patched.EthFlowInfo = qosSubPatch.EthFlowInfo
+	}
+	if qosSubPatch.QosReference != "" {
+		patched.QosReference = qosSubPatch.QosReference
+	}
+	if qosSubPatch.UsageThreshold != nil {
+		patched.UsageThreshold = qosSubPatch.UsageThreshold
+	}
+	if qosSubPatch.NotificationDestination != "" {
+		patched.NotificationDestination = qosSubPatch.NotificationDestination
+	}
+	return &patched
+}
+
+func (p *Processor) genAsSessionWithQoSSubURI(
+	scsAsID, subscriptionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/{subscriptionId}
+	return p.Config().ServiceUri(factory.ServiceAsQos) + "/" + scsAsID + "/subscriptions/" + subscriptionId
+}
+
+func (p *Processor) genPcfNotificationUri(notifCorreID string) string {
+	// PCF appends "/notify" or "/terminate" to this URI
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/pcf/" + notifCorreID
+}
+
+func genQosEventsSubscReqData(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+	notifUri string,
+) *models.EventsSubscReqData {
+	evSubsc := &models.EventsSubscReqData{
+		Events: []models.AfEventSubscription{
+			{Event: models.AfEvent_QOS_NOTIF},
+			{Event: models.AfEvent_SUCCESSFUL_RESOURCES_ALLOCATION},
+			{Event: models.AfEvent_FAILED_RESOURCES_ALLOCATION},
+		},
+		NotifUri: notifUri,
+		UsgThres: qosSub.UsageThreshold,
+	}
+	if qosSub.UsageThreshold != nil {
+		evSubsc.Events = append(evSubsc.Events,
+			models.AfEventSubscription{Event: models.AfEvent_USAGE_REPORT})
+	}
+	return evSubsc
+}
+
+func (p *Processor) genQosMediaComponent(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+) models.MediaComponent {
+	medComp := models.MediaComponent{
+		MedCompN:    qosMedCompN,
+		MedSubComps: make(map[string]models.MediaSubComponent),
+	}
+	if qosRef := p.Config().QosReference(qosSub.QosReference); qosRef != nil {
+		medComp.MedType = models.MediaType(qosRef.MedType)
+		medComp.MarBwDl = qosRef.MarBwDl
+		medComp.MarBwUl = qosRef.MarBwUl
+		medComp.MirBwDl = qosRef.MirBwDl
+		medComp.MirBwUl = qosRef.MirBwUl
+	}
+
+	for _, flowInfo := range qosSub.FlowInfo {
+		medComp.MedSubComps[strconv.Itoa(int(flowInfo.FlowId))] = models.MediaSubComponent{
+			FNum:   flowInfo.FlowId,
+			FDescs: flowInfo.FlowDescriptions,
+		}
+	}
+	for i, ethFlowDesc := range qosSub.EthFlowInfo {
+		fNum := int32(i + 1)
+		medComp.MedSubComps[strconv.Itoa(int(fNum))] = models.MediaSubComponent{
+			FNum:      fNum,
+			EthfDescs: []models.EthFlowDescription{ethFlowDesc},
+		}
+	}
+	return medComp
+}
+
+func (p *Processor) convertAsSessionWithQoSSubToAppSessionContext(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+	notifCorreID string,
+) *models.AppSessionContext {
+	notifUri := p.genPcfNotificationUri(notifCorreID)
+	asc := &models.AppSessionContext{
+		AscReqData: &models.AppSessionContextReqData{
+			EvSubsc: genQosEventsSubscReqData(qosSub, notifUri),
+			MedComponents: map[string]models.MediaComponent{
+				strconv.Itoa(qosMedCompN): p.genQosMediaComponent(qosSub),
+			},
+			UeIpv4:    qosSub.UeIpv4Addr,
+			UeIpv6:    qosSub.UeIpv6Addr,
+			UeMac:     qosSub.MacAddr,
+			NotifUri:  notifUri,
+			SuppFeat:  qosSub.SupportedFeatures,
+			Dnn:       qosSub.Dnn,
+			SliceInfo: qosSub.Snssai,
+		},
+	}
+	return asc
+}
+
+func (p *Processor) convertAsSessionWithQoSSubToAppSessionContextUpdateData(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+	notifCorreID string,
+) *models.AppSessionContextUpdateData {
+	evSubsc := genQosEventsSubscReqData(qosSub, p.genPcfNotificationUri(notifCorreID))
+	evSubscRm := &models.EventsSubscReqDataRm{
+		Events:   evSubsc.Events,
+		NotifUri: evSubsc.NotifUri,
+	}
+	if thres := evSubsc.UsgThres; thres != nil {
+		evSubscRm.UsgThres = &models.UsageThresholdRm{
+			Duration:       thres.Duration,
+			TotalVolume:    thres.TotalVolume,
+			DownlinkVolume: thres.DownlinkVolume,
+			UplinkVolume:   thres.UplinkVolume,
+		}
+	}
+
+	medComp := p.genQosMediaComponent(qosSub)
+	medCompRm := models.MediaComponentRm{
+		MedCompN:    medComp.MedCompN,
+		MedType:     medComp.MedType,
+		MarBwDl:     medComp.MarBwDl,
+		MarBwUl:     medComp.MarBwUl,
+		MirBwDl:     medComp.MirBwDl,
+		MirBwUl:     medComp.MirBwUl,
+		MedSubComps: make(map[string]models.MediaSubComponentRm),
+	}
+	for k, medSubComp := range medComp.MedSubComps {
+		medCompRm.MedSubComps[k] = models.MediaSubComponentRm{
+			FNum:      medSubComp.FNum,
+			FDescs:    medSubComp.FDescs,
+			EthfDescs: medSubComp.EthfDescs,
+		}
+	}
+
+	return &models.AppSessionContextUpdateData{
+		EvSubsc: evSubscRm,
+		MedComponents: map[string]models.MediaComponentRm{
+			strconv.Itoa(qosMedCompN): medCompRm,
+		},
+	}
+}
+
+func convertEventsNotificationToUserPlaneEventReports(
+	qosSub *nef_models.AsSessionWithQoSSubscription,
+	evNotif *models.EventsNotification,
+) []nef_models.UserPlaneEventReport {
+	var upReports []nef_models.UserPlaneEventReport
+	for _, afEvNotif := range evNotif.EvNotifs {
+		var flowIDs []int32
+		for _, flows := range afEvNotif.Flows {
+			flowIDs = append(flowIDs, flows.FNums...)
+		}
+
+		switch afEvNotif.Event {
+		case models.AfEvent_QOS_NOTIF:
+			for _, qncReport := range evNotif.QncReports {
+				upReport := nef_models.UserPlaneEventReport{
+					Event:   nef_models.UserPlaneEvent_QOS_NOT_GUARANTEED,
+					FlowIds: flowIDs,
+				}
+				if qncReport.NotifType == models.QosNotifType_GUARANTEED {
+					upReport.Event = nef_models.UserPlaneEvent_QOS_GUARANTEED
+					upReport.AppliedQosRef = qosSub.QosReference
+				}
+				upReports = append(upReports, upReport)
+			}
+		case models.AfEvent_USAGE_REPORT:
+			upReports = append(upReports, nef_models.UserPlaneEventReport{
+				Event:            nef_models.UserPlaneEvent_USAGE_REPORT,
+				AccumulatedUsage: evNotif.UsgRep,
+				FlowIds:          flowIDs,
+			})
+		case models.AfEvent_SUCCESSFUL_RESOURCES_ALLOCATION:
+			upReports = append(upReports, nef_models.UserPlaneEventReport{
+				Event:         nef_models.UserPlaneEvent_SUCCESSFUL_RESOURCES_ALLOCATION,
+				FlowIds:       flowIDs,
+				AppliedQosRef: qosSub.QosReference,
+			})
+		case models.AfEvent_FAILED_RESOURCES_ALLOCATION:
+			upReports = append(upReports, nef_models.UserPlaneEventReport{
+				Event:   nef_models.UserPlaneEvent_FAILED_RESOURCES_ALLOCATION,
+				FlowIds: flowIDs,
+			})
+		case models.AfEvent_ACCESS_TYPE_CHANGE:
+			upReports = append(upReports, nef_models.UserPlaneEventReport{
+				Event:   nef_models.UserPlaneEvent_ACCESS_TYPE_CHANGE,
+				RatType: evNotif.RatType,
+			})
+		case models.AfEvent_PLMN_CHG:
+			upReports = append(upReports, nef_models.UserPlaneEventReport{
+				Event:  nef_models.UserPlaneEvent_PLMN_CHG,
+				PlmnId: evNotif.PlmnId,
+			})
+		}
+	}
+	return upReports
+}
+
+// notifyUserPlaneEventReports forwards the reports to AF. The caller should hold af.Mu.
+func (p *Processor) notifyUserPlaneEventReports(
+	afSub *nef_context.AfQosSubscription,
+	upReports []nef_models.UserPlaneEventReport,
+) {
+	if len(upReports) == 0 {
+		return
+	}
+
+	upNotif := &nef_models.UserPlaneNotificationData{
+		Transaction:  afSub.QosSub.Self,
+		EventReports: upReports,
+	}
+	afSub.Log.Infof("Notify AF %d user plane event reports", len(upReports))
+	p.Notifier().AfNotifier.Notify(afSub.QosSub.NotificationDestination, upNotif, afSub.Log)
+}
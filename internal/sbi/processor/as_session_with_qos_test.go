@@ -0,0 +1,218 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var (
+	qosRefVideoHd = factory.QosReference{
+		QosReference: "qos-video-hd",
+		MedType:      "VIDEO",
+		MarBwDl:      "10 Mbps",
+		MarBwUl:      "2 Mbps",
+		MirBwDl:      "5 Mbps",
+		MirBwUl:      "1 Mbps",
+	}
+	qosSub1ForAf1 = nef_models.AsSessionWithQoSSubscription{
+		Dnn:                     "internet",
+		NotificationDestination: "http://af1.example.com/qos-notif",
+		FlowInfo: []nef_models.FlowInfo{
+			{
+				FlowId: 1,
+				FlowDescriptions: []string{
+					"permit out ip from 10.60.0.1 to 10.60.0.2",
+				},
+			},
+		},
+		QosReference: "qos-video-hd",
+		UeIpv4Addr:   "10.60.0.1",
+	}
+)
+
+func TestPostAsSessionWithQoSSubscription(t *testing.T) {
+	initNRFDiscPCFStub()
+	initPCFPaPostQosAppSessionsStub()
+
+	cfg := nefApp.Config()
+	cfg.Configuration.QosReferences = []factory.QosReference{qosRefVideoHd}
+	nefCtx := nefApp.Context()
+	defer func() {
+		cfg.Configuration.QosReferences = nil
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	qosSub := qosSub1ForAf1
+	rspQosSub := qosSub1ForAf1
+	rspQosSub.Self = nefApp.Processor().genAsSessionWithQoSSubURI("af1", "1")
+
+	testCases := []struct {
+		description      string
+		scsAsID          string
+		qosSub           *nef_models.AsSessionWithQoSSubscription
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: Missing UE address, should return ProblemDetails",
+			scsAsID:     "af1",
+			qosSub: &nef_models.AsSessionWithQoSSubscription{
+				NotificationDestination: "http://af1.example.com/qos-notif",
+				QosReference:            "qos-video-hd",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Missing one of ueIpv4Addr, ueIpv6Addr or macAddr"),
+			},
+		},
+		{
+			description: "TC2: Unknown QoS reference, should return ProblemDetails",
+			scsAsID:     "af1",
+			qosSub: &nef_models.AsSessionWithQoSSubscription{
+				NotificationDestination: "http://af1.example.com/qos-notif",
+				FlowInfo:                qosSub1ForAf1.FlowInfo,
+				QosReference:            "qos-unknown",
+				UeIpv4Addr:              "10.60.0.1",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Unknown qosReference[qos-unknown]"),
+			},
+		},
+		{
+			description: "TC3: Successful subscription, should post AppSession to PCF",
+			scsAsID:     "af1",
+			qosSub:      &qosSub,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspQosSub.Self},
+				},
+				Body: &rspQosSub,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostAsSessionWithQoSSubscription(tc.scsAsID, tc.qosSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	require.Equal(t, "67890", af.QosSubs["1"].AppSessID)
+	require.Equal(t, "http://127.0.0.7:8000", af.QosSubs["1"].PcfUri)
+}
+
+func TestPcfEventNotification(t *testing.T) {
+	afNotifChan := make(chan *http.Request, 1)
+	gock.New("http://af1.example.com").
+		Post("/qos-notif").
+		Persist().
+		Reply(http.StatusNoContent)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "qos-notif") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	qosSub := qosSub1ForAf1
+	afSub := af1.NewQosSub(nefCtx.NewCorreID(), &qosSub)
+	afSub.AppSessID = "67890"
+	qosSub.Self = nefApp.Processor().genAsSessionWithQoSSubURI("af1", afSub.SubID)
+	af1.QosSubs[afSub.SubID] = afSub
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	evNotif := &models.EventsNotification{
+		EvSubsUri: "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/67890/events-subscription",
+		EvNotifs: []models.AfEventNotification{
+			{
+				Event: models.AfEvent_QOS_NOTIF,
+				Flows: []models.Flows{
+					{MedCompN: qosMedCompN, FNums: []int32{1}},
+				},
+			},
+		},
+		QncReports: []models.QosNotificationControlInfo{
+			{NotifType: models.QosNotifType_GUARANTEED},
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		notifCorreID     string
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description:  "TC1: Subscription not found, should return ProblemDetails",
+			notifCorreID: "100",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body:   openapi.ProblemDetailsDataNotFound("Subscrption is not found"),
+			},
+		},
+		{
+			description:      "TC2: QoS guaranteed, should notify AF",
+			notifCorreID:     afSub.NotifCorreID,
+			expectedResponse: &HandlerResponse{Status: http.StatusNoContent},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PcfEventNotification(tc.notifCorreID, evNotif)
+			require.Equal(t, tc.expectedResponse, rsp)
+			if rsp.Status != http.StatusNoContent {
+				return
+			}
+
+			r := <-afNotifChan
+			var upNotif nef_models.UserPlaneNotificationData
+			if err := json.NewDecoder(r.Body).Decode(&upNotif); err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, nef_models.UserPlaneNotificationData{
+				Transaction: qosSub.Self,
+				EventReports: []nef_models.UserPlaneEventReport{
+					{
+						Event:         nef_models.UserPlaneEvent_QOS_GUARANTEED,
+						FlowIds:       []int32{1},
+						AppliedQosRef: "qos-video-hd",
+					},
+				},
+			}, upNotif)
+		})
+	}
+}
+
+func initPCFPaPostQosAppSessionsStub() {
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		MatchType("json").
+		BodyString(`.*"medType":"VIDEO".*`).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/67890").
+		JSON(models.AppSessionContext{})
+}
@@ -2,14 +2,18 @@ package processor
 
 import (
 	"net/http"
+	"strings"
 
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/models_nef"
 )
 
 func (p *Processor) SmfNotification(
-	eeNotif *models.NsmfEventExposureNotification,
+	eeNotif *nef_models.NsmfEventExposureNotification,
 ) *HandlerResponse {
 	logger.TrafInfluLog.Infof("SmfNotification - NotifId[%s]", eeNotif.NotifId)
 
@@ -19,10 +23,252 @@ func (p *Processor) SmfNotification(
 		return &HandlerResponse{http.StatusNotFound, nil, pd}
 	}
 
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if sub.TiSub == nil || sub.TiSub.NotificationDestination == "" {
+		sub.Log.Warnln("No notificationDestination is provided by AF, skip notifying")
+		return &HandlerResponse{http.StatusOK, nil, nil}
+	}
+
+	notified := false
+	for i := range eeNotif.EventNotifs {
+		smfNotif := &eeNotif.EventNotifs[i]
+		if smfNotif.Event != models.SmfEvent_UP_PATH_CH {
+			sub.Log.Debugf("Ignore SMF event[%s]", smfNotif.Event)
+			continue
+		}
+		afNotif := p.convertSmfEventNotifToEventNotif(sub, smfNotif)
+		sub.Log.Infof("Notify AF UP path change: DNAI[%s] -> DNAI[%s]",
+			afNotif.SourceDnai, afNotif.TargetDnai)
+		p.Notifier().AfNotifier.Notify(sub.TiSub.NotificationDestination, afNotif, sub.Log)
+		notified = true
+	}
+
+	// The acknowledgement of AF is relayed to SMF by PostAfAck
+	if notified && sub.TiSub.AfAckInd && eeNotif.AckUri != "" {
+		sub.SmfAckUri = eeNotif.AckUri
+		p.Context().SaveAf(af)
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, nil}
+}
+
+func (p *Processor) PostAfAck(
+	notifCorreID string,
+	afAck *models_nef.AfAckInfo,
+) *HandlerResponse {
+	logger.TrafInfluLog.Infof("PostAfAck - NotifCorreID[%s]", notifCorreID)
+
+	if afAck.AckResult == nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Absent of AfAckInfo.AckResult")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	af, sub := p.Context().FindAfSub(notifCorreID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	if sub.TiSub == nil || !sub.TiSub.AfAckInd {
+		af.Mu.Unlock()
+		pd := openapi.ProblemDetailsMalformedReqSyntax("AF acknowledgement is not expected")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	smfAckUri := sub.SmfAckUri
+	if smfAckUri == "" {
+		af.Mu.Unlock()
+		pd := openapi.ProblemDetailsDataNotFound("No UP path change is waiting for AF acknowledgement")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	ack := &nef_models.AckOfNotify{
+		NotifId:   sub.NotifCorreID,
+		AckResult: afAck.AckResult,
+		Gpsi:      afAck.Gpsi,
+	}
+	if ack.Gpsi == "" {
+		ack.Gpsi = sub.TiSub.Gpsi
+	}
+	af.Mu.Unlock()
+
+	// af.Mu is not held while waiting for SMF
+	rspStatus, rspBody := p.Consumer().PostAckOfNotify(smfAckUri, ack)
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub.AfAck = afAck
+	// Keep the URI of a later UP path change received while relaying
+	if sub.SmfAckUri == smfAckUri {
+		sub.SmfAckUri = ""
+	}
+	p.Context().SaveAf(af)
+	sub.Log.Infof("AF acknowledged UP path change: status[%s]", afAck.AckResult.AfStatus)
+
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+func (p *Processor) convertSmfEventNotifToEventNotif(
+	sub *nef_context.AfSubscription,
+	smfNotif *models.EventNotification,
+) *models_nef.EventNotification {
+	afNotif := &models_nef.EventNotification{
+		AfTransId:          sub.TiSub.AfTransId,
+		DnaiChgType:        smfNotif.DnaiChgType,
+		SourceTrafficRoute: smfNotif.SourceTraRouting,
+		SubscribedEvent:    models.SubscribedEvent_UP_PATH_CHANGE,
+		TargetTrafficRoute: smfNotif.TargetTraRouting,
+		SourceDnai:         smfNotif.SourceDnai,
+		TargetDnai:         smfNotif.TargetDnai,
+		Gpsi:               smfNotif.Gpsi,
+		SrcUeIpv4Addr:      smfNotif.SourceUeIpv4Addr,
+		SrcUeIpv6Prefix:    smfNotif.SourceUeIpv6Prefix,
+		TgtUeIpv4Addr:      smfNotif.TargetUeIpv4Addr,
+		TgtUeIpv6Prefix:    smfNotif.TargetUeIpv6Prefix,
+		UeMac:              smfNotif.UeMac,
+	}
+
+	if afNotif.Gpsi == "" {
+		afNotif.Gpsi = sub.TiSub.Gpsi
+	}
+	if afNotif.DnaiChgType == "" {
+		afNotif.DnaiChgType = sub.TiSub.DnaiChgType
+	}
+	if sub.TiSub.AfAckInd {
+		afNotif.AfAckUri = p.genAfAckUri(sub.NotifCorreID)
+	}
+	return afNotif
+}
+
+func (p *Processor) AmfNotification(
+	amfNotif *models.AmfEventNotification,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("AmfNotification - NotifyCorrelationId[%s]", amfNotif.NotifyCorrelationId)
+
+	af, sub := p.Context().FindAfMeSub(amfNotif.NotifyCorrelationId)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	var meReports []nef_models.MonitoringEventReport
+	for i := range amfNotif.ReportList {
+		meReports = append(meReports,
+			convertAmfEventReportToMonitoringEventReport(sub.MeSub, &amfNotif.ReportList[i]))
+	}
+	p.notifyMonitoringEventReports(af, sub, meReports)
+
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+func (p *Processor) UdmNotification(
+	notifCorreID string,
+	monReports []models.MonitoringReport,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("UdmNotification - NotifCorreID[%s]", notifCorreID)
+
+	af, sub := p.Context().FindAfMeSub(notifCorreID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	var meReports []nef_models.MonitoringEventReport
+	for i := range monReports {
+		meReports = append(meReports,
+			convertMonitoringReportToMonitoringEventReport(sub.MeSub, &monReports[i]))
+	}
+	p.notifyMonitoringEventReports(af, sub, meReports)
+
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+func (p *Processor) PcfEventNotification(
+	notifCorreID string,
+	evNotif *models.EventsNotification,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("PcfEventNotification - NotifCorreID[%s]", notifCorreID)
+
+	af, sub := p.Context().FindAfQosSub(notifCorreID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
 	af.Mu.RLock()
 	defer af.Mu.RUnlock()
 
-	// TODO: Notify AF
+	upReports := convertEventsNotificationToUserPlaneEventReports(sub.QosSub, evNotif)
+	p.notifyUserPlaneEventReports(sub, upReports)
 
-	return &HandlerResponse{http.StatusOK, nil, nil}
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+func (p *Processor) PcfTerminationNotification(
+	notifCorreID string,
+	termInfo *models.TerminationInfo,
+) *HandlerResponse {
+	logger.AsQosLog.Infof("PcfTerminationNotification - NotifCorreID[%s]", notifCorreID)
+
+	af, sub := p.Context().FindAfQosSub(notifCorreID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub.Log.Infof("AF application session is terminated by PCF: cause[%s]", termInfo.TermCause)
+	p.notifyUserPlaneEventReports(sub, []nef_models.UserPlaneEventReport{
+		{Event: nef_models.UserPlaneEvent_SESSION_TERMINATION},
+	})
+
+	// TS 29.514: AF shall delete the application session after the termination request,
+	// which is done after the response to PCF is sent
+	pcfUri, appSessID := sub.PcfUri, sub.AppSessID
+	go func() {
+		rspStatus, _ := p.Consumer().DeleteAppSession(pcfUri, appSessID)
+		if rspStatus != http.StatusOK && rspStatus != http.StatusNoContent {
+			sub.Log.Warnf("Delete terminated AppSession[%s] failed: status[%d]", appSessID, rspStatus)
+		}
+	}()
+
+	delete(af.QosSubs, sub.SubID)
+	p.Context().SaveAf(af)
+
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+// NfStatusNotification handles the NF status notification of NRF, TS 29.510 clause 5.2.2.6
+func (p *Processor) NfStatusNotification(notifData *models.NotificationData) *HandlerResponse {
+	logger.ConsumerLog.Infof("NfStatusNotification - event[%s], nfInstanceUri[%s]",
+		notifData.Event, notifData.NfInstanceUri)
+
+	if notifData.NfInstanceUri == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Absent of NotificationData.NfInstanceUri")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	if notifData.Event == models.NotificationEventType_DEREGISTERED {
+		nfInstanceUri := strings.TrimSuffix(notifData.NfInstanceUri, "/")
+		nfInstID := nfInstanceUri[strings.LastIndex(nfInstanceUri, "/")+1:]
+		p.Context().InvalidateNfInstance(nfInstID)
+	}
+
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
 }
@@ -0,0 +1,305 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/models_nef"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var tiSubWithNotifForAf1 = models_nef.TrafficInfluSub{
+	AfServiceId:             "Service6",
+	AfAppId:                 "App6",
+	AfTransId:               "Trans6",
+	Dnn:                     "internet",
+	Ipv4Addr:                "10.60.0.11",
+	DnaiChgType:             models.DnaiChangeType_EARLY,
+	NotificationDestination: "http://af1.example.com/ti-notif",
+	AfAckInd:                true,
+}
+
+func TestSmfNotification(t *testing.T) {
+	afNotifChan := make(chan *http.Request, 1)
+	gock.New("http://af1.example.com").
+		Post("/ti-notif").
+		Persist().
+		Reply(http.StatusNoContent)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "ti-notif") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub := af1.NewSub(nefCtx.NewCorreID(), &tiSubWithNotifForAf1)
+	af1.Subs[afSub.SubID] = afSub
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	testCases := []struct {
+		description      string
+		eeNotif          *nef_models.NsmfEventExposureNotification
+		expectedResponse *HandlerResponse
+		expectedAfNotif  *models_nef.EventNotification
+	}{
+		{
+			description: "TC1: Subscription not found, should return ProblemDetails",
+			eeNotif: &nef_models.NsmfEventExposureNotification{
+				NotifId: "100",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "Subscrption is not found",
+				},
+			},
+		},
+		{
+			description: "TC2: UP path change, should notify AF with EventNotification",
+			eeNotif: &nef_models.NsmfEventExposureNotification{
+				NotifId: afSub.NotifCorreID,
+				AckUri:  "http://127.0.0.20:8000/ack",
+				EventNotifs: []models.EventNotification{
+					{
+						Event:            models.SmfEvent_UP_PATH_CH,
+						DnaiChgType:      models.DnaiChangeType_EARLY,
+						SourceDnai:       "mec1",
+						TargetDnai:       "mec2",
+						SourceUeIpv4Addr: "10.60.0.11",
+						TargetUeIpv4Addr: "10.60.0.11",
+					},
+				},
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+			},
+			expectedAfNotif: &models_nef.EventNotification{
+				AfTransId:       "Trans6",
+				DnaiChgType:     models.DnaiChangeType_EARLY,
+				SubscribedEvent: models.SubscribedEvent_UP_PATH_CHANGE,
+				SourceDnai:      "mec1",
+				TargetDnai:      "mec2",
+				SrcUeIpv4Addr:   "10.60.0.11",
+				TgtUeIpv4Addr:   "10.60.0.11",
+				AfAckUri:        "http://127.0.0.5:8000/nnef-callback/v1/notification/af-ack/" + afSub.NotifCorreID,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().SmfNotification(tc.eeNotif)
+			require.Equal(t, tc.expectedResponse, rsp)
+			if tc.expectedAfNotif == nil {
+				return
+			}
+			r := <-afNotifChan
+			var afNotif models_nef.EventNotification
+			if err := json.NewDecoder(r.Body).Decode(&afNotif); err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, *tc.expectedAfNotif, afNotif)
+		})
+	}
+	require.Equal(t, "http://127.0.0.20:8000/ack", afSub.SmfAckUri)
+}
+
+func TestPostAfAck(t *testing.T) {
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub := af1.NewSub(nefCtx.NewCorreID(), &tiSubWithNotifForAf1)
+	af1.Subs[afSub.SubID] = afSub
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	afAck := &models_nef.AfAckInfo{
+		AfTransId: "Trans6",
+		AckResult: &models_nef.AfResultInfo{
+			AfStatus: models_nef.AfResultStatus_SUCCESS,
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		notifCorreID     string
+		afAck            *models_nef.AfAckInfo
+		smfAckUri        string
+		expectedResponse *HandlerResponse
+		expectedAck      *nef_models.AckOfNotify
+	}{
+		{
+			description:  "TC1: Missing AckResult, should return ProblemDetails",
+			notifCorreID: afSub.NotifCorreID,
+			afAck:        &models_nef.AfAckInfo{},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Title:  "Malformed request syntax",
+					Status: http.StatusBadRequest,
+					Detail: "Absent of AfAckInfo.AckResult",
+				},
+			},
+		},
+		{
+			description:  "TC2: Subscription not found, should return ProblemDetails",
+			notifCorreID: "100",
+			afAck:        afAck,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "Subscrption is not found",
+				},
+			},
+		},
+		{
+			description:  "TC3: No UP path change waiting for acknowledgement, should return ProblemDetails",
+			notifCorreID: afSub.NotifCorreID,
+			afAck:        afAck,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "No UP path change is waiting for AF acknowledgement",
+				},
+			},
+		},
+		{
+			description:  "TC4: Valid acknowledgement, should be relayed to SMF and stored in subscription",
+			notifCorreID: afSub.NotifCorreID,
+			afAck:        afAck,
+			smfAckUri:    "http://127.0.0.20:8000/ack",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+			expectedAck: &nef_models.AckOfNotify{
+				NotifId:   afSub.NotifCorreID,
+				AckResult: afAck.AckResult,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var ack nef_models.AckOfNotify
+			var smfMock *gock.Response
+			if tc.expectedAck != nil {
+				smfMock = gock.New("http://127.0.0.20:8000").
+					Post("/ack$").
+					AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+						return true, json.NewDecoder(req.Body).Decode(&ack)
+					}).
+					Reply(http.StatusNoContent)
+			}
+			af1.Mu.Lock()
+			afSub.SmfAckUri = tc.smfAckUri
+			af1.Mu.Unlock()
+
+			rsp := nefApp.Processor().PostAfAck(tc.notifCorreID, tc.afAck)
+			require.Equal(t, tc.expectedResponse, rsp)
+			if tc.expectedAck == nil {
+				return
+			}
+			require.True(t, smfMock.Mock.Done())
+			require.Equal(t, *tc.expectedAck, ack)
+			require.Equal(t, afAck, afSub.AfAck)
+			require.Empty(t, afSub.SmfAckUri)
+		})
+	}
+}
+
+func TestNfStatusNotification(t *testing.T) {
+	nefCtx := nefApp.Context()
+	srvName := models.ServiceName_NPCF_POLICYAUTHORIZATION
+	pcf1 := nef_context.NfCandidate{NfInstID: "pcf1", Uri: "http://127.0.0.7:8000"}
+	pcf2 := nef_context.NfCandidate{NfInstID: "pcf2", Uri: "http://127.0.0.8:8000"}
+	nefCtx.SetNfCandidates(srvName, []nef_context.NfCandidate{pcf1, pcf2}, 0)
+	defer nefCtx.SetNfCandidates(srvName, nil, 0)
+
+	testCases := []struct {
+		description        string
+		notifData          *models.NotificationData
+		expectedResponse   *HandlerResponse
+		expectedCandidates []nef_context.NfCandidate
+	}{
+		{
+			description: "TC1: Missing NfInstanceUri, should return ProblemDetails",
+			notifData: &models.NotificationData{
+				Event: models.NotificationEventType_DEREGISTERED,
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Title:  "Malformed request syntax",
+					Status: http.StatusBadRequest,
+					Detail: "Absent of NotificationData.NfInstanceUri",
+				},
+			},
+			expectedCandidates: []nef_context.NfCandidate{pcf1, pcf2},
+		},
+		{
+			description: "TC2: Unknown NF instance deregistered, should keep the candidates",
+			notifData: &models.NotificationData{
+				Event:         models.NotificationEventType_DEREGISTERED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/pcf3",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+			expectedCandidates: []nef_context.NfCandidate{pcf1, pcf2},
+		},
+		{
+			description: "TC3: Cached NF instance deregistered, should remove it from the candidates",
+			notifData: &models.NotificationData{
+				Event:         models.NotificationEventType_DEREGISTERED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/pcf1",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+			expectedCandidates: []nef_context.NfCandidate{pcf2},
+		},
+		{
+			description: "TC4: Last cached NF instance deregistered, should clear the candidates",
+			notifData: &models.NotificationData{
+				Event:         models.NotificationEventType_DEREGISTERED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/pcf2",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().NfStatusNotification(tc.notifData)
+			require.Equal(t, tc.expectedResponse, rsp)
+			cands, _ := nefCtx.NfCandidates(srvName)
+			require.Equal(t, tc.expectedCandidates, cands)
+		})
+	}
+}
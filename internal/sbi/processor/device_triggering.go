@@ -0,0 +1,367 @@
+package processor
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+)
+
+const maxPortId = 65535
+
+func (p *Processor) GetDeviceTriggeringTransactions(
+	scsAsID string,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("GetDeviceTriggeringTransactions - scsAsID[%s]", scsAsID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var triggers []nef_models.DeviceTriggering
+	for _, trigTr := range af.TrigTrans {
+		triggers = append(triggers, *trigTr.Trigger)
+	}
+	return &HandlerResponse{http.StatusOK, nil, &triggers}
+}
+
+func (p *Processor) PostDeviceTriggeringTransaction(
+	scsAsID string,
+	trigger *nef_models.DeviceTriggering,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("PostDeviceTriggeringTransaction - scsAsID[%s]", scsAsID)
+
+	rsp := validateDeviceTriggering(trigger)
+	if rsp != nil {
+		return rsp
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(scsAsID)
+	if af == nil {
+		af = nefCtx.NewAf(scsAsID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	trigTr := af.NewTrigTrans(trigger)
+	if trigTr == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	trigger.Self = p.genDeviceTriggeringURI(scsAsID, trigTr.TransID)
+	trigger.DeliveryResult = ""
+
+	// The transaction is saved before the delivery, so that the delivery report can find it
+	af.TrigTrans[trigTr.TransID] = trigTr
+	nefCtx.AddAf(af)
+
+	if rsp := p.deliverDeviceTrigger(af, trigTr); rsp != nil {
+		delete(af.TrigTrans, trigTr.TransID)
+		nefCtx.SaveAf(af)
+		return rsp
+	}
+	af.Log.Infoln("Device triggering transaction is added")
+
+	headers := map[string][]string{
+		"Location": {trigger.Self},
+	}
+	return &HandlerResponse{http.StatusCreated, headers, copyDeviceTriggering(trigTr.Trigger)}
+}
+
+func (p *Processor) GetIndividualDeviceTriggeringTransaction(
+	scsAsID, transID string,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("GetIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]", scsAsID, transID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	trigTr, ok := af.TrigTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, copyDeviceTriggering(trigTr.Trigger)}
+}
+
+func (p *Processor) PutIndividualDeviceTriggeringTransaction(
+	scsAsID, transID string,
+	trigger *nef_models.DeviceTriggering,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("PutIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]", scsAsID, transID)
+
+	rsp := validateDeviceTriggering(trigger)
+	if rsp != nil {
+		return rsp
+	}
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	trigTr, ok := af.TrigTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	// The UE to be triggered can not be changed by replacement
+	if trigger.ExternalId != trigTr.Trigger.ExternalId ||
+		trigger.Msisdn != trigTr.Trigger.Msisdn {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("UE identifier can not be modified")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	return p.replaceDeviceTrigger(af, trigTr, trigger)
+}
+
+func (p *Processor) PatchIndividualDeviceTriggeringTransaction(
+	scsAsID, transID string,
+	triggerPatch *nef_models.DeviceTriggeringPatch,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("PatchIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]", scsAsID, transID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	trigTr, ok := af.TrigTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	trigger := patchDeviceTriggeringData(trigTr.Trigger, triggerPatch)
+	if rsp := validateDeviceTriggering(trigger); rsp != nil {
+		return rsp
+	}
+
+	return p.replaceDeviceTrigger(af, trigTr, trigger)
+}
+
+func (p *Processor) DeleteIndividualDeviceTriggeringTransaction(
+	scsAsID, transID string,
+) *HandlerResponse {
+	logger.DevTrigLog.Infof("DeleteIndividualDeviceTriggeringTransaction - scsAsID[%s], transID[%s]", scsAsID, transID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	trigTr, ok := af.TrigTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Transaction is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	// Only the trigger not delivered yet needs to be recalled
+	if trigTr.IsPending() {
+		if err := p.Notifier().TriggerDeliverer.Recall(trigTr.MsgRef); err != nil {
+			trigTr.Log.Warnf("Recall device trigger err: %+v", err)
+		}
+	}
+	delete(af.TrigTrans, transID)
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+// replaceDeviceTrigger recalls the pending trigger and delivers the new one. The caller should hold af.Mu.
+func (p *Processor) replaceDeviceTrigger(
+	af *nef_context.AfData,
+	trigTr *nef_context.AfTriggerTransaction,
+	trigger *nef_models.DeviceTriggering,
+) *HandlerResponse {
+	if trigTr.IsPending() {
+		if err := p.Notifier().TriggerDeliverer.Recall(trigTr.MsgRef); err != nil {
+			trigTr.Log.Warnf("Recall device trigger err: %+v", err)
+		}
+	}
+
+	oldTrigger, oldMsgRef := trigTr.Trigger, trigTr.MsgRef
+	trigger.Self = oldTrigger.Self
+	trigger.DeliveryResult = ""
+	trigTr.Trigger = trigger
+	if rsp := p.deliverDeviceTrigger(af, trigTr); rsp != nil {
+		trigTr.Trigger, trigTr.MsgRef = oldTrigger, oldMsgRef
+		return rsp
+	}
+	return &HandlerResponse{http.StatusOK, nil, copyDeviceTriggering(trigTr.Trigger)}
+}
+
+// deliverDeviceTrigger hands over the trigger to TriggerDeliverer with a new message reference.
+// The caller should hold af.Mu.
+func (p *Processor) deliverDeviceTrigger(
+	af *nef_context.AfData,
+	trigTr *nef_context.AfTriggerTransaction,
+) *HandlerResponse {
+	msg := convertDeviceTriggeringToTriggerMessage(af.NewTrigMsgRef(trigTr), trigTr.Trigger)
+	if err := p.Notifier().TriggerDeliverer.Deliver(msg, p.handleDeviceTriggerDeliveryReport); err != nil {
+		trigTr.Log.Errorf("Deliver device trigger err: %+v", err)
+		pd := openapi.ProblemDetailsSystemFailure(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	trigTr.Log.Infof("Device trigger[%s] is delivered", msg.Ref)
+	return nil
+}
+
+func (p *Processor) handleDeviceTriggerDeliveryReport(
+	msgRef string,
+	result nef_models.DeliveryResult,
+) {
+	logger.DevTrigLog.Infof("DeviceTriggerDeliveryReport - msgRef[%s], result[%s]", msgRef, result)
+
+	af, trigTr := p.Context().FindAfTrigTrans(msgRef)
+	if trigTr == nil {
+		logger.DevTrigLog.Warnf("Transaction of device trigger[%s] is not found", msgRef)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if af.TrigTrans[trigTr.TransID] != trigTr {
+		logger.DevTrigLog.Warnf("Transaction of device trigger[%s] is deleted", msgRef)
+		return
+	}
+	trigTr.Trigger.DeliveryResult = result
+	p.Context().SaveAf(af)
+
+	deliveryReport := &nef_models.DeviceTriggeringDeliveryReportNotification{
+		Transaction: trigTr.Trigger.Self,
+		Result:      result,
+	}
+	p.Notifier().AfNotifier.Notify(trigTr.Trigger.NotificationDestination, deliveryReport, trigTr.Log)
+}
+
+// copyDeviceTriggering returns a copy of the trigger for the response body, since the trigger
+// of the transaction is updated by the delivery report under af.Mu
+func copyDeviceTriggering(trigger *nef_models.DeviceTriggering) *nef_models.DeviceTriggering {
+	triggerCopy := *trigger
+	return &triggerCopy
+}
+
+func validateDeviceTriggering(trigger *nef_models.DeviceTriggering) *HandlerResponse {
+	if trigger.NotificationDestination == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if trigger.ExternalId == "" && trigger.Msisdn == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing one of externalId or msisdn")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if trigger.ValidityPeriod <= 0 {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing or invalid validityPeriod")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	switch trigger.Priority {
+	case nef_models.Priority_NO_PRIORITY, nef_models.Priority_PRIORITY:
+	default:
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing or invalid priority")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if trigger.ApplicationPortId < 0 || trigger.ApplicationPortId > maxPortId ||
+		trigger.AppSrcPortId < 0 || trigger.AppSrcPortId > maxPortId {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Invalid applicationPortId or appSrcPortId")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if trigger.TriggerPayload == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing triggerPayload")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if _, err := base64.StdEncoding.DecodeString(trigger.TriggerPayload); err != nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("triggerPayload is not base64 encoded")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+func patchDeviceTriggeringData(
+	trigger *nef_models.DeviceTriggering,
+	triggerPatch *nef_models.DeviceTriggeringPatch,
+) *nef_models.DeviceTriggering {
+	patched := *trigger
+	if triggerPatch.ValidityPeriod != 0 {
+		patched.ValidityPeriod = triggerPatch.ValidityPeriod
+	}
+	if triggerPatch.Priority != "" {
+		patched.Priority = triggerPatch.Priority
+	}
+	if triggerPatch.ApplicationPortId != 0 {
+		patched.ApplicationPortId = triggerPatch.ApplicationPortId
+	}
+	if triggerPatch.TriggerPayload != "" {
+		patched.TriggerPayload = triggerPatch.TriggerPayload
+	}
+	if triggerPatch.NotificationDestination != "" {
+		patched.NotificationDestination = triggerPatch.NotificationDestination
+	}
+	return &patched
+}
+
+func convertDeviceTriggeringToTriggerMessage(
+	msgRef string,
+	trigger *nef_models.DeviceTriggering,
+) *notifier.TriggerMessage {
+	// Payload has been validated
+	payload, err := base64.StdEncoding.DecodeString(trigger.TriggerPayload)
+	if err != nil {
+		logger.DevTrigLog.Warnf("Decode triggerPayload err: %+v", err)
+	}
+	return &notifier.TriggerMessage{
+		Ref:               msgRef,
+		ExternalId:        trigger.ExternalId,
+		Msisdn:            trigger.Msisdn,
+		ValidityPeriod:    trigger.ValidityPeriod,
+		Priority:          trigger.Priority,
+		ApplicationPortId: trigger.ApplicationPortId,
+		AppSrcPortId:      trigger.AppSrcPortId,
+		Payload:           payload,
+	}
+}
+
+func (p *Processor) genDeviceTriggeringURI(
+	scsAsID, transactionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-device-triggering/v1/{scsAsId}/transactions/{transactionId}
+	return p.Config().ServiceUri(factory.ServiceDevTrig) + "/" + scsAsID + "/transactions/" + transactionId
+}
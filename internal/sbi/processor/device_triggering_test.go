@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var trigger1ForAf1 = nef_models.DeviceTriggering{
+	Msisdn:                  "0900000001",
+	NotificationDestination: "http://af1.example.com/trigger-notif",
+	ValidityPeriod:          3600,
+	Priority:                nef_models.Priority_NO_PRIORITY,
+	ApplicationPortId:       9200,
+	TriggerPayload:          "d2FrZSB1cA==",
+}
+
+func TestPostDeviceTriggeringTransaction(t *testing.T) {
+	afNotifChan := make(chan *http.Request, 1)
+	gock.New("http://af1.example.com").
+		Post("/trigger-notif").
+		Persist().
+		Reply(http.StatusNoContent)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "trigger-notif") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	trigger := trigger1ForAf1
+	rspTrigger := trigger1ForAf1
+	rspTrigger.Self = nefApp.Processor().genDeviceTriggeringURI("af1", "1")
+
+	invalidPayload := trigger1ForAf1
+	invalidPayload.TriggerPayload = "not base64!"
+
+	testCases := []struct {
+		description      string
+		scsAsID          string
+		trigger          *nef_models.DeviceTriggering
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: Missing UE identifier, should return ProblemDetails",
+			scsAsID:     "af1",
+			trigger: &nef_models.DeviceTriggering{
+				NotificationDestination: "http://af1.example.com/trigger-notif",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Missing one of externalId or msisdn"),
+			},
+		},
+		{
+			description: "TC2: Invalid triggerPayload, should return ProblemDetails",
+			scsAsID:     "af1",
+			trigger:     &invalidPayload,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"triggerPayload is not base64 encoded"),
+			},
+		},
+		{
+			description: "TC3: Successful transaction, should deliver the trigger",
+			scsAsID:     "af1",
+			trigger:     &trigger,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTrigger.Self},
+				},
+				Body: &rspTrigger,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostDeviceTriggeringTransaction(tc.scsAsID, tc.trigger)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+
+	// Loopback deliverer reports SUCCESS, which should be forwarded to AF
+	r := <-afNotifChan
+	var report nef_models.DeviceTriggeringDeliveryReportNotification
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, nef_models.DeviceTriggeringDeliveryReportNotification{
+		Transaction: rspTrigger.Self,
+		Result:      nef_models.DeliveryResult_SUCCESS,
+	}, report)
+
+	rsp := nefApp.Processor().GetIndividualDeviceTriggeringTransaction("af1", "1")
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.Equal(t, nef_models.DeliveryResult_SUCCESS,
+		rsp.Body.(*nef_models.DeviceTriggering).DeliveryResult)
+}
+
+func TestReplaceDeviceTriggeringTransaction(t *testing.T) {
+	gock.New("http://af1.example.com").
+		Post("/trigger-notif").
+		Persist().
+		Reply(http.StatusNoContent)
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	deliveryResult := func() nef_models.DeliveryResult {
+		rsp := nefApp.Processor().GetIndividualDeviceTriggeringTransaction("af1", "1")
+		require.Equal(t, http.StatusOK, rsp.Status)
+		return rsp.Body.(*nef_models.DeviceTriggering).DeliveryResult
+	}
+	msgRef := func() string {
+		af := nefCtx.GetAf("af1")
+		af.Mu.RLock()
+		defer af.Mu.RUnlock()
+		return af.TrigTrans["1"].MsgRef
+	}
+
+	trigger := trigger1ForAf1
+	rsp := nefApp.Processor().PostDeviceTriggeringTransaction("af1", &trigger)
+	require.Equal(t, http.StatusCreated, rsp.Status)
+	require.Eventually(t, func() bool {
+		return deliveryResult() == nef_models.DeliveryResult_SUCCESS
+	}, time.Second, 10*time.Millisecond)
+	oldMsgRef := msgRef()
+
+	// The replacement is delivered with a new message reference
+	trigger = trigger1ForAf1
+	trigger.TriggerPayload = "d2FrZSB1cCBhZ2Fpbg=="
+	rsp = nefApp.Processor().PutIndividualDeviceTriggeringTransaction("af1", "1", &trigger)
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.NotEqual(t, oldMsgRef, msgRef())
+	require.Eventually(t, func() bool {
+		return deliveryResult() == nef_models.DeliveryResult_SUCCESS
+	}, time.Second, 10*time.Millisecond)
+
+	// The late report of the replaced message should not match the replacement
+	nefApp.Processor().handleDeviceTriggerDeliveryReport(oldMsgRef, nef_models.DeliveryResult_FAILURE)
+	require.Equal(t, nef_models.DeliveryResult_SUCCESS, deliveryResult())
+}
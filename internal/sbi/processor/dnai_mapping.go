@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/internal/util"
+	"github.com/free5gc/nef/internal/validator"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// GetDnaiMappings returns the DNAI mappings, optionally filtered by the IP address or FQDN of
+// the application server, or by the geographic zone. The IP address selects the mapping of
+// the longest matched range, which is the one used for traffic routing.
+func (p *Processor) GetDnaiMappings(
+	afID, easIpAddr, easFqdn, geoZoneID string,
+) *HandlerResponse {
+	logger.DnaiMapLog.Infof("GetDnaiMappings - afID[%s], easIpAddr[%s], easFqdn[%s], geoZoneId[%s]",
+		afID, easIpAddr, easFqdn, geoZoneID)
+
+	ipDnai := ""
+	if easIpAddr != "" {
+		if net.ParseIP(easIpAddr) == nil {
+			pd := openapi.ProblemDetailsMalformedReqSyntax("Invalid eas-ip-addr: " + easIpAddr)
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+		ipDnai = p.Config().DnaiOfEasIpAddr(easIpAddr)
+		if ipDnai == "" {
+			return &HandlerResponse{http.StatusOK, nil, &[]nef_models.DnaiMapping{}}
+		}
+	}
+
+	dnaiMappings := []nef_models.DnaiMapping{}
+	for _, mapping := range p.Config().DnaiMappings() {
+		if ipDnai != "" && mapping.Dnai != ipDnai {
+			continue
+		}
+		if easFqdn != "" && !mapping.IsEasFqdnMatched(easFqdn) {
+			continue
+		}
+		if geoZoneID != "" && !mapping.IsGeoZoneServed(geoZoneID) {
+			continue
+		}
+		dnaiMappings = append(dnaiMappings, convertDnaiMapping(&mapping))
+	}
+	return &HandlerResponse{http.StatusOK, nil, &dnaiMappings}
+}
+
+func (p *Processor) GetIndividualDnaiMapping(
+	afID, dnai string,
+) *HandlerResponse {
+	logger.DnaiMapLog.Infof("GetIndividualDnaiMapping - afID[%s], dnai[%s]", afID, dnai)
+
+	for _, mapping := range p.Config().DnaiMappings() {
+		if mapping.Dnai == dnai {
+			dnaiMapping := convertDnaiMapping(&mapping)
+			return &HandlerResponse{http.StatusOK, nil, &dnaiMapping}
+		}
+	}
+	pd := openapi.ProblemDetailsDataNotFound("DNAI mapping is not found")
+	return &HandlerResponse{http.StatusNotFound, nil, pd}
+}
+
+// fillTrafficRouteDnais fills in the DNAI of the traffic routes without one from the DNAI mappings,
+// by the IP address of the application server in routeInfo. The routes of the request are not modified.
+func (p *Processor) fillTrafficRouteDnais(
+	routes []models.RouteToLocation,
+) ([]models.RouteToLocation, *HandlerResponse) {
+	var filled []models.RouteToLocation
+	var invalidParams []models.InvalidParam
+	for i, route := range routes {
+		if route.Dnai != "" {
+			continue
+		}
+		if filled == nil {
+			filled = append([]models.RouteToLocation(nil), routes...)
+		}
+
+		dnai := ""
+		if route.RouteInfo != nil {
+			if dnai = p.Config().DnaiOfEasIpAddr(route.RouteInfo.Ipv4Addr); dnai == "" {
+				dnai = p.Config().DnaiOfEasIpAddr(route.RouteInfo.Ipv6Addr)
+			}
+		}
+		if dnai == "" {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  validator.JoinPointer("/trafficRoutes", strconv.Itoa(i), "dnai"),
+				Reason: "No DNAI is mapped to the routeInfo",
+			})
+			continue
+		}
+		logger.TrafInfluLog.Infof("Map trafficRoutes[%d] to DNAI[%s]", i, dnai)
+		filled[i].Dnai = dnai
+	}
+	if len(invalidParams) > 0 {
+		pd := util.ProblemDetailsInvalidParams("Invalid trafficRoutes", invalidParams)
+		return nil, &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if filled == nil {
+		return routes, nil
+	}
+	return filled, nil
+}
+
+func convertDnaiMapping(mapping *factory.DnaiMapping) nef_models.DnaiMapping {
+	return nef_models.DnaiMapping{
+		Dnai:            mapping.Dnai,
+		EasIpAddrRanges: mapping.EasIpAddrRanges,
+		EasFqdns:        mapping.EasFqdns,
+		GeoZoneIds:      mapping.GeoZoneIds,
+	}
+}
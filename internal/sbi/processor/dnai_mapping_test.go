@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/stretchr/testify/require"
+)
+
+var testDnaiMappings = []factory.DnaiMapping{
+	{
+		Dnai:            "mec1",
+		EasIpAddrRanges: []string{"10.60.0.0/16", "2001:db8::/32"},
+		EasFqdns:        []string{"eas1.mec1.example.com"},
+		GeoZoneIds:      []string{"zone1"},
+	},
+	{
+		Dnai:            "mec2",
+		EasIpAddrRanges: []string{"10.60.1.0/24"},
+		EasFqdns:        []string{"eas2.mec2.example.com"},
+		GeoZoneIds:      []string{"zone1", "zone2"},
+	},
+}
+
+func TestGetDnaiMappings(t *testing.T) {
+	cfg := nefApp.Config()
+	cfg.Configuration.DnaiMappings = testDnaiMappings
+	defer func() {
+		cfg.Configuration.DnaiMappings = nil
+	}()
+
+	mec1 := convertDnaiMapping(&testDnaiMappings[0])
+	mec2 := convertDnaiMapping(&testDnaiMappings[1])
+
+	testCases := []struct {
+		description      string
+		easIpAddr        string
+		easFqdn          string
+		geoZoneID        string
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: No filter, should return all mappings",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{mec1, mec2},
+			},
+		},
+		{
+			description: "TC2: IPv4 address, should return the mapping of the longest matched range",
+			easIpAddr:   "10.60.1.10",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{mec2},
+			},
+		},
+		{
+			description: "TC3: IPv6 address",
+			easIpAddr:   "2001:db8::10",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{mec1},
+			},
+		},
+		{
+			description: "TC4: FQDN is case-insensitive",
+			easFqdn:     "EAS2.mec2.example.com.",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{mec2},
+			},
+		},
+		{
+			description: "TC5: Geographic zone",
+			geoZoneID:   "zone2",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{mec2},
+			},
+		},
+		{
+			description: "TC6: Unmapped IP address, should return empty list",
+			easIpAddr:   "192.168.0.1",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]nef_models.DnaiMapping{},
+			},
+		},
+		{
+			description: "TC7: Invalid IP address, should return ProblemDetails",
+			easIpAddr:   "10.60.1",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body:   openapi.ProblemDetailsMalformedReqSyntax("Invalid eas-ip-addr: 10.60.1"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().GetDnaiMappings("af1", tc.easIpAddr, tc.easFqdn, tc.geoZoneID)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+}
+
+func TestGetIndividualDnaiMapping(t *testing.T) {
+	cfg := nefApp.Config()
+	cfg.Configuration.DnaiMappings = testDnaiMappings
+	defer func() {
+		cfg.Configuration.DnaiMappings = nil
+	}()
+
+	mec2 := convertDnaiMapping(&testDnaiMappings[1])
+
+	testCases := []struct {
+		description      string
+		dnai             string
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: Configured DNAI, should return the mapping",
+			dnai:        "mec2",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &mec2,
+			},
+		},
+		{
+			description: "TC2: Unknown DNAI, should return ProblemDetails",
+			dnai:        "mec3",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body:   openapi.ProblemDetailsDataNotFound("DNAI mapping is not found"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().GetIndividualDnaiMapping("af1", tc.dnai)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+const udmEeReferenceID = "1"
+
+// Monitoring types which are served by Namf_EventExposure
+var monTypeToAmfEventType = map[nef_models.MonitoringType]models.AmfEventType{
+	nef_models.MonitoringType_LOCATION_REPORTING:    models.AmfEventType_LOCATION_REPORT,
+	nef_models.MonitoringType_COMMUNICATION_FAILURE: models.AmfEventType_COMMUNICATION_FAILURE_REPORT,
+}
+
+// Monitoring types which are served by Nudm_EventExposure
+var monTypeToUdmEventType = map[nef_models.MonitoringType]models.EventType{
+	nef_models.MonitoringType_LOSS_OF_CONNECTIVITY:            models.EventType_LOSS_OF_CONNECTIVITY,
+	nef_models.MonitoringType_CHANGE_OF_IMSI_IMEI_ASSOCIATION: models.EventType_CHANGE_OF_SUPI_PEI_ASSOCIATION,
+	nef_models.MonitoringType_ROAMING_STATUS:                  models.EventType_ROAMING_STATUS,
+	nef_models.MonitoringType_AVAILABILITY_AFTER_DDN_FAILURE:  models.EventType_AVAILABILITY_AFTER_DNN_FAILURE,
+}
+
+func (p *Processor) GetMonitoringEventSubscriptions(
+	scsAsID string,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("GetMonitoringEventSubscriptions - scsAsID[%s]", scsAsID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var meSubs []nef_models.MonitoringEventSubscription
+	for _, sub := range af.MeSubs {
+		meSubs = append(meSubs, *sub.MeSub)
+	}
+	return &HandlerResponse{http.StatusOK, nil, &meSubs}
+}
+
+func (p *Processor) PostMonitoringEventSubscription(
+	scsAsID string,
+	meSub *nef_models.MonitoringEventSubscription,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("PostMonitoringEventSubscription - scsAsID[%s]", scsAsID)
+
+	rsp := validateMonitoringEventSubscription(meSub)
+	if rsp != nil {
+		return rsp
+	}
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(scsAsID)
+	if af == nil {
+		af = nefCtx.NewAf(scsAsID)
+		if af == nil {
+			pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	correID := nefCtx.NewCorreID()
+	afSub := af.NewMeSub(correID, meSub)
+	if afSub == nil {
+		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	meSub.Self = p.genMonitoringEventSubURI(scsAsID, afSub.SubID)
+
+	var meReports []nef_models.MonitoringEventReport
+	if amfEvtType, ok := monTypeToAmfEventType[meSub.MonitoringType]; ok {
+		if meSub.ExternalGroupId != "" {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				fmt.Sprintf("Group is not supported for monitoringType %s", meSub.MonitoringType))
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+		amfSub := p.convertMonitoringEventSubToAmfCreateEventSub(meSub, amfEvtType, afSub.NotifCorreID)
+		rspStatus, rspBody := p.Consumer().AmfEventSubscriptionCreate(amfSub)
+		if rspStatus != http.StatusCreated {
+			return &HandlerResponse{rspStatus, nil, rspBody}
+		}
+		created := rspBody.(*models.AmfCreatedEventSubscription)
+		afSub.AmfSubID = created.SubscriptionId
+		for i := range created.ReportList {
+			meReports = append(meReports,
+				convertAmfEventReportToMonitoringEventReport(meSub, &created.ReportList[i]))
+		}
+	} else {
+		afSub.UeIdentity = getUdmUeIdentity(meSub)
+		eeSub := p.convertMonitoringEventSubToEeSubscription(meSub, afSub.NotifCorreID)
+		rspStatus, rspBody, udmSubID := p.Consumer().EeSubscriptionCreate(afSub.UeIdentity, eeSub)
+		if rspStatus != http.StatusCreated {
+			return &HandlerResponse{rspStatus, nil, rspBody}
+		}
+		afSub.UdmSubID = udmSubID
+		created := rspBody.(*models.CreatedEeSubscription)
+		for i := range created.EventReports {
+			meReports = append(meReports,
+				convertMonitoringReportToMonitoringEventReport(meSub, &created.EventReports[i]))
+		}
+	}
+
+	if len(meReports) > 0 {
+		// TS 29.522: the immediate reports are carried within the response,
+		// and each of them is counted against maximumNumberOfReports
+		if maxReports := meSub.MaximumNumberOfReports; maxReports > 0 && int32(len(meReports)) > maxReports {
+			meReports = meReports[:maxReports]
+		}
+		meSub.MonitoringEventReport = &meReports[0]
+		if len(meReports) > 1 {
+			meSub.AddnMonEventReports = meReports[1:]
+		}
+		afSub.NumReports += int32(len(meReports))
+		if afSub.IsReportsExhausted() {
+			// The request is fulfilled by the immediate reports, no subscription resource is created
+			meSub.Self = ""
+			afSub.Log.Infoln("Monitoring request is fulfilled by immediate reports")
+			return &HandlerResponse{http.StatusOK, nil, meSub}
+		}
+	}
+
+	af.MeSubs[afSub.SubID] = afSub
+	af.Log.Infoln("Monitoring event subscription is added")
+
+	nefCtx.AddAf(af)
+
+	headers := map[string][]string{
+		"Location": {meSub.Self},
+	}
+	return &HandlerResponse{http.StatusCreated, headers, meSub}
+}
+
+func (p *Processor) GetIndividualMonitoringEventSubscription(
+	scsAsID, subID string,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("GetIndividualMonitoringEventSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	afSub, ok := af.MeSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, afSub.MeSub}
+}
+
+func (p *Processor) DeleteIndividualMonitoringEventSubscription(
+	scsAsID, subID string,
+) *HandlerResponse {
+	logger.MonEvtLog.Infof("DeleteIndividualMonitoringEventSubscription - scsAsID[%s], subID[%s]", scsAsID, subID)
+
+	af := p.Context().GetAf(scsAsID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afSub, ok := af.MeSubs[subID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	if rsp := p.deleteMonitoringEventSubFromCoreNetwork(afSub); rsp != nil {
+		return rsp
+	}
+	delete(af.MeSubs, subID)
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+// deleteMonitoringEventSubFromCoreNetwork removes the AMF or UDM subscription of afSub.
+// The subscription which has been already removed by AMF or UDM is treated as deleted.
+func (p *Processor) deleteMonitoringEventSubFromCoreNetwork(
+	afSub *nef_context.AfMeSubscription,
+) *HandlerResponse {
+	var rspStatus int
+	var rspBody interface{}
+	switch {
+	case afSub.AmfSubID != "":
+		rspStatus, rspBody = p.Consumer().AmfEventSubscriptionDelete(afSub.AmfSubID)
+	case afSub.UdmSubID != "":
+		rspStatus, rspBody = p.Consumer().EeSubscriptionDelete(afSub.UeIdentity, afSub.UdmSubID)
+	default:
+		return nil
+	}
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent &&
+		rspStatus != http.StatusNotFound {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+	return nil
+}
+
+// notifyMonitoringEventReports forwards the reports to AF and removes the subscription
+// once maximumNumberOfReports is reached. The caller should hold af.Mu.
+func (p *Processor) notifyMonitoringEventReports(
+	af *nef_context.AfData,
+	afSub *nef_context.AfMeSubscription,
+	meReports []nef_models.MonitoringEventReport,
+) {
+	if len(meReports) == 0 {
+		return
+	}
+
+	afSub.NumReports += int32(len(meReports))
+	meNotif := &nef_models.MonitoringNotification{
+		Subscription:           afSub.MeSub.Self,
+		MonitoringEventReports: meReports,
+	}
+	afSub.Log.Infof("Notify AF %d monitoring event reports", len(meReports))
+	p.Notifier().AfNotifier.Notify(afSub.MeSub.NotificationDestination, meNotif, afSub.Log)
+
+	if afSub.IsReportsExhausted() {
+		afSub.Log.Infof("Maximum number of reports[%d] is reached, remove subscription",
+			afSub.MeSub.MaximumNumberOfReports)
+		delete(af.MeSubs, afSub.SubID)
+	}
+	p.Context().SaveAf(af)
+}
+
+func validateMonitoringEventSubscription(
+	meSub *nef_models.MonitoringEventSubscription,
+) *HandlerResponse {
+	if meSub.NotificationDestination == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Missing notificationDestination")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	// TS29.522: One of "externalId", "msisdn" or "externalGroupId" shall be included.
+	if meSub.ExternalId == "" &&
+		meSub.Msisdn == "" &&
+		meSub.ExternalGroupId == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(
+			"Missing one of externalId, msisdn or externalGroupId")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	switch meSub.MonitoringType {
+	case nef_models.MonitoringType_UE_REACHABILITY:
+		if meSub.ReachabilityType != nef_models.ReachabilityType_DATA &&
+			meSub.ReachabilityType != nef_models.ReachabilityType_SMS {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				"Missing or invalid reachabilityType for UE_REACHABILITY")
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	case nef_models.MonitoringType_LOCATION_REPORTING,
+		nef_models.MonitoringType_COMMUNICATION_FAILURE,
+		nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+		nef_models.MonitoringType_CHANGE_OF_IMSI_IMEI_ASSOCIATION,
+		nef_models.MonitoringType_ROAMING_STATUS,
+		nef_models.MonitoringType_AVAILABILITY_AFTER_DDN_FAILURE:
+	default:
+		pd := openapi.ProblemDetailsMalformedReqSyntax(
+			fmt.Sprintf("Unsupported monitoringType[%s]", meSub.MonitoringType))
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+func (p *Processor) genMonitoringEventSubURI(
+	scsAsID, subscriptionId string,
+) string {
+	// E.g. https://localhost:29505/3gpp-monitoring-event/v1/{scsAsId}/subscriptions/{subscriptionId}
+	return p.Config().ServiceUri(factory.ServiceMonEvt) + "/" + scsAsID + "/subscriptions/" + subscriptionId
+}
+
+func (p *Processor) genAmfNotificationUri() string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/amf"
+}
+
+func (p *Processor) genUdmNotificationUri(notifCorreID string) string {
+	// Nudm_EE notification carries no correlation ID, so it is kept in the callback URI
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/udm/" + notifCorreID
+}
+
+func getGpsi(meSub *nef_models.MonitoringEventSubscription) string {
+	if meSub.Msisdn != "" {
+		return "msisdn-" + meSub.Msisdn
+	}
+	if meSub.ExternalId != "" {
+		return "extid-" + meSub.ExternalId
+	}
+	return ""
+}
+
+func getUdmUeIdentity(meSub *nef_models.MonitoringEventSubscription) string {
+	if gpsi := getGpsi(meSub); gpsi != "" {
+		return gpsi
+	}
+	return "extgroupid-" + meSub.ExternalGroupId
+}
+
+func (p *Processor) convertMonitoringEventSubToAmfCreateEventSub(
+	meSub *nef_models.MonitoringEventSubscription,
+	amfEvtType models.AmfEventType,
+	notifCorreID string,
+) *models.AmfCreateEventSubscription {
+	amfSub := &models.AmfCreateEventSubscription{
+		Subscription: &models.AmfEventSubscription{
+			EventList: &[]models.AmfEvent{
+				{
+					Type:          amfEvtType,
+					ImmediateFlag: amfEvtType == models.AmfEventType_LOCATION_REPORT,
+				},
+			},
+			EventNotifyUri:      p.genAmfNotificationUri(),
+			NotifyCorrelationId: notifCorreID,
+			NfId:                p.Context().NfInstID(),
+			Gpsi:                getGpsi(meSub),
+			Options: &models.AmfEventMode{
+				Trigger:    models.AmfEventTrigger_CONTINUOUS,
+				MaxReports: meSub.MaximumNumberOfReports,
+				Expiry:     meSub.MonitorExpireTime,
+			},
+		},
+	}
+	if meSub.MaximumNumberOfReports == 1 {
+		amfSub.Subscription.Options.Trigger = models.AmfEventTrigger_ONE_TIME
+	}
+	return amfSub
+}
+
+func (p *Processor) convertMonitoringEventSubToEeSubscription(
+	meSub *nef_models.MonitoringEventSubscription,
+	notifCorreID string,
+) *models.EeSubscription {
+	monCfg := models.MonitoringConfiguration{
+		EventType: monTypeToUdmEventType[meSub.MonitoringType],
+	}
+	if meSub.MonitoringType == nef_models.MonitoringType_UE_REACHABILITY {
+		monCfg.EventType = models.EventType_UE_REACHABILITY_FOR_DATA
+		if meSub.ReachabilityType == nef_models.ReachabilityType_SMS {
+			monCfg.EventType = models.EventType_UE_REACHABILITY_FOR_SMS
+		}
+	}
+
+	eeSub := &models.EeSubscription{
+		CallbackReference: p.genUdmNotificationUri(notifCorreID),
+		MonitoringConfigurations: map[string]models.MonitoringConfiguration{
+			udmEeReferenceID: monCfg,
+		},
+		SupportedFeatures: meSub.SupportedFeatures,
+	}
+	if meSub.MaximumNumberOfReports > 0 || meSub.MonitorExpireTime != nil {
+		eeSub.ReportingOptions = &models.ReportingOptions{
+			MaxNumOfReports: meSub.MaximumNumberOfReports,
+			Expiry:          meSub.MonitorExpireTime,
+		}
+	}
+	return eeSub
+}
+
+func convertAmfEventReportToMonitoringEventReport(
+	meSub *nef_models.MonitoringEventSubscription,
+	report *models.AmfEventReport,
+) nef_models.MonitoringEventReport {
+	meReport := nef_models.MonitoringEventReport{
+		MonitoringType: meSub.MonitoringType,
+		ExternalId:     meSub.ExternalId,
+		Msisdn:         meSub.Msisdn,
+		EventTime:      report.TimeStamp,
+	}
+
+	switch report.Type {
+	case models.AmfEventType_LOCATION_REPORT:
+		meReport.LocationInfo = convertUserLocationToLocationInfo(report.Location)
+	case models.AmfEventType_COMMUNICATION_FAILURE_REPORT:
+		// TODO: NGAP release cause has no counterpart in FailureCause yet
+		if cf := report.CommFailure; cf != nil {
+			meReport.FailureCause = &nef_models.FailureCause{
+				RanNasCause: cf.NasReleaseCode,
+			}
+		}
+	}
+	return meReport
+}
+
+func convertUserLocationToLocationInfo(
+	userLoc *models.UserLocation,
+) *nef_models.LocationInfo {
+	if userLoc == nil {
+		return nil
+	}
+
+	locInfo := &nef_models.LocationInfo{}
+	if nrLoc := userLoc.NrLocation; nrLoc != nil {
+		locInfo.AgeOfLocationInfo = nrLoc.AgeOfLocationInformation
+		if nrLoc.Ncgi != nil {
+			locInfo.CellId = nrLoc.Ncgi.NrCellId
+			locInfo.PlmnId = nrLoc.Ncgi.PlmnId
+		}
+		if nrLoc.Tai != nil {
+			locInfo.TrackingAreaId = nrLoc.Tai.Tac
+			locInfo.PlmnId = nrLoc.Tai.PlmnId
+		}
+	} else if eutraLoc := userLoc.EutraLocation; eutraLoc != nil {
+		locInfo.AgeOfLocationInfo = eutraLoc.AgeOfLocationInformation
+		if eutraLoc.Ecgi != nil {
+			locInfo.CellId = eutraLoc.Ecgi.EutraCellId
+			locInfo.PlmnId = eutraLoc.Ecgi.PlmnId
+		}
+		if eutraLoc.Tai != nil {
+			locInfo.TrackingAreaId = eutraLoc.Tai.Tac
+			locInfo.PlmnId = eutraLoc.Tai.PlmnId
+		}
+	}
+	return locInfo
+}
+
+func convertMonitoringReportToMonitoringEventReport(
+	meSub *nef_models.MonitoringEventSubscription,
+	report *models.MonitoringReport,
+) nef_models.MonitoringEventReport {
+	meReport := nef_models.MonitoringEventReport{
+		MonitoringType: meSub.MonitoringType,
+		ExternalId:     meSub.ExternalId,
+		Msisdn:         meSub.Msisdn,
+		EventTime:      report.TimeStamp,
+	}
+
+	// The UE of a group subscription is identified by the GPSI in report
+	if strings.HasPrefix(report.Gpsi, "msisdn-") {
+		meReport.Msisdn = strings.TrimPrefix(report.Gpsi, "msisdn-")
+	} else if strings.HasPrefix(report.Gpsi, "extid-") {
+		meReport.ExternalId = strings.TrimPrefix(report.Gpsi, "extid-")
+	}
+
+	switch report.EventType {
+	case models.EventType_UE_REACHABILITY_FOR_DATA:
+		meReport.ReachabilityType = nef_models.ReachabilityType_DATA
+	case models.EventType_UE_REACHABILITY_FOR_SMS:
+		meReport.ReachabilityType = nef_models.ReachabilityType_SMS
+	case models.EventType_CHANGE_OF_SUPI_PEI_ASSOCIATION:
+		if report.Report != nil {
+			meReport.ImeiChange = nef_models.AssociationType_IMEI
+			if strings.HasPrefix(report.Report.NewPei, "imeisv-") {
+				meReport.ImeiChange = nef_models.AssociationType_IMEISV
+			}
+		}
+	case models.EventType_ROAMING_STATUS:
+		if report.Report != nil {
+			meReport.RoamingStatus = report.Report.Roaming
+			meReport.PlmnId = report.Report.NewServingPlmn
+		}
+	}
+	return meReport
+}
@@ -0,0 +1,434 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var (
+	meSubLocForAf1 = nef_models.MonitoringEventSubscription{
+		Msisdn:                  "0900000001",
+		NotificationDestination: "http://af1.example.com/me-notif",
+		MonitoringType:          nef_models.MonitoringType_LOCATION_REPORTING,
+		LocationType:            nef_models.LocationType_LAST_KNOWN_LOCATION,
+		MaximumNumberOfReports:  5,
+	}
+	meSubLossForAf1 = nef_models.MonitoringEventSubscription{
+		ExternalId:              "ue1@example.com",
+		NotificationDestination: "http://af1.example.com/me-notif",
+		MonitoringType:          nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+		MaximumNumberOfReports:  2,
+	}
+	meReportTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	meNrLocation = &models.UserLocation{
+		NrLocation: &models.NrLocation{
+			Tai: &models.Tai{
+				PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"},
+				Tac:    "000001",
+			},
+			Ncgi: &models.Ncgi{
+				PlmnId:   &models.PlmnId{Mcc: "208", Mnc: "93"},
+				NrCellId: "000000010",
+			},
+		},
+	}
+)
+
+func TestPostMonitoringEventSubscription(t *testing.T) {
+	initNRFDiscAMFStub()
+	initNRFDiscUDMStub("nudm-ee")
+	initAMFEvtsCreateSubscriptionStub()
+	initUDMEeCreateSubscriptionStub()
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	meSubLoc := meSubLocForAf1
+	rspMeSubLoc := meSubLocForAf1
+	rspMeSubLoc.Self = nefApp.Processor().genMonitoringEventSubURI("af1", "1")
+	rspMeSubLoc.MonitoringEventReport = &nef_models.MonitoringEventReport{
+		MonitoringType: nef_models.MonitoringType_LOCATION_REPORTING,
+		Msisdn:         "0900000001",
+		EventTime:      &meReportTime,
+		LocationInfo: &nef_models.LocationInfo{
+			CellId:         "000000010",
+			TrackingAreaId: "000001",
+			PlmnId:         &models.PlmnId{Mcc: "208", Mnc: "93"},
+		},
+	}
+
+	meSubLoss := meSubLossForAf1
+	rspMeSubLoss := meSubLossForAf1
+	rspMeSubLoss.Self = nefApp.Processor().genMonitoringEventSubURI("af1", "2")
+
+	testCases := []struct {
+		description      string
+		scsAsID          string
+		meSub            *nef_models.MonitoringEventSubscription
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: Missing UE identifier, should return ProblemDetails",
+			scsAsID:     "af1",
+			meSub: &nef_models.MonitoringEventSubscription{
+				NotificationDestination: "http://af1.example.com/me-notif",
+				MonitoringType:          nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Missing one of externalId, msisdn or externalGroupId"),
+			},
+		},
+		{
+			description: "TC2: UE_REACHABILITY without reachabilityType, should return ProblemDetails",
+			scsAsID:     "af1",
+			meSub: &nef_models.MonitoringEventSubscription{
+				Msisdn:                  "0900000001",
+				NotificationDestination: "http://af1.example.com/me-notif",
+				MonitoringType:          nef_models.MonitoringType_UE_REACHABILITY,
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Missing or invalid reachabilityType for UE_REACHABILITY"),
+			},
+		},
+		{
+			description: "TC3: Location reporting, should subscribe to AMF and carry immediate report",
+			scsAsID:     "af1",
+			meSub:       &meSubLoc,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspMeSubLoc.Self},
+				},
+				Body: &rspMeSubLoc,
+			},
+		},
+		{
+			description: "TC4: Loss of connectivity, should subscribe to UDM",
+			scsAsID:     "af1",
+			meSub:       &meSubLoss,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspMeSubLoss.Self},
+				},
+				Body: &rspMeSubLoss,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostMonitoringEventSubscription(tc.scsAsID, tc.meSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	require.Equal(t, "amf-sub-1", af.MeSubs["1"].AmfSubID)
+	require.Equal(t, "udm-sub-1", af.MeSubs["2"].UdmSubID)
+	require.Equal(t, "extid-ue1@example.com", af.MeSubs["2"].UeIdentity)
+}
+
+func TestPostMonitoringEventSubscriptionImmediateReports(t *testing.T) {
+	initNRFDiscUDMStub("nudm-ee")
+	created := models.CreatedEeSubscription{
+		EventReports: []models.MonitoringReport{
+			{ReferenceId: 1, EventType: models.EventType_ROAMING_STATUS, TimeStamp: &meReportTime},
+			{ReferenceId: 1, EventType: models.EventType_ROAMING_STATUS, TimeStamp: &meReportTime},
+			{ReferenceId: 1, EventType: models.EventType_ROAMING_STATUS, TimeStamp: &meReportTime},
+		},
+	}
+	gock.New("http://127.0.0.3:8000/nudm-ee/v1").
+		Post("/extid-ue2@example.com/ee-subscriptions$").
+		Persist().
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.3:8000/nudm-ee/v1/extid-ue2@example.com/ee-subscriptions/udm-sub-2").
+		JSON(created)
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	meReport := nef_models.MonitoringEventReport{
+		MonitoringType: nef_models.MonitoringType_ROAMING_STATUS,
+		ExternalId:     "ue2@example.com",
+		EventTime:      &meReportTime,
+	}
+
+	testCases := []struct {
+		description        string
+		maxReports         int32
+		expectedStatus     int
+		expectedAddnCount  int
+		expectedNumReports int32
+	}{
+		{
+			description:        "TC1: Unlimited reports, should carry all immediate reports and create subscription",
+			expectedStatus:     http.StatusCreated,
+			expectedAddnCount:  2,
+			expectedNumReports: 3,
+		},
+		{
+			description:       "TC2: Maximum reports reached by immediate reports, should not create subscription",
+			maxReports:        2,
+			expectedStatus:    http.StatusOK,
+			expectedAddnCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			meSub := &nef_models.MonitoringEventSubscription{
+				ExternalId:              "ue2@example.com",
+				NotificationDestination: "http://af1.example.com/me-notif",
+				MonitoringType:          nef_models.MonitoringType_ROAMING_STATUS,
+				MaximumNumberOfReports:  tc.maxReports,
+			}
+			rsp := nefApp.Processor().PostMonitoringEventSubscription("af1", meSub)
+			require.Equal(t, tc.expectedStatus, rsp.Status)
+
+			rspMeSub := rsp.Body.(*nef_models.MonitoringEventSubscription)
+			require.Equal(t, &meReport, rspMeSub.MonitoringEventReport)
+			require.Len(t, rspMeSub.AddnMonEventReports, tc.expectedAddnCount)
+			for _, addnReport := range rspMeSub.AddnMonEventReports {
+				require.Equal(t, meReport, addnReport)
+			}
+
+			if tc.expectedStatus != http.StatusCreated {
+				require.Empty(t, rspMeSub.Self)
+				return
+			}
+			af := nefCtx.GetAf("af1")
+			require.NotNil(t, af)
+			subID := rspMeSub.Self[strings.LastIndex(rspMeSub.Self, "/")+1:]
+			require.Equal(t, tc.expectedNumReports, af.MeSubs[subID].NumReports)
+		})
+	}
+}
+
+func TestUdmNotification(t *testing.T) {
+	afNotifChan := make(chan *http.Request, 2)
+	gock.New("http://af1.example.com").
+		Post("/me-notif").
+		Persist().
+		Reply(http.StatusNoContent)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "me-notif") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	meSub := meSubLossForAf1
+	afSub := af1.NewMeSub(nefCtx.NewCorreID(), &meSub)
+	afSub.UdmSubID = "udm-sub-1"
+	afSub.UeIdentity = "extid-ue1@example.com"
+	meSub.Self = nefApp.Processor().genMonitoringEventSubURI("af1", afSub.SubID)
+	af1.MeSubs[afSub.SubID] = afSub
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	monReports := []models.MonitoringReport{
+		{
+			ReferenceId: 1,
+			EventType:   models.EventType_LOSS_OF_CONNECTIVITY,
+			TimeStamp:   &meReportTime,
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		notifCorreID     string
+		expectedResponse *HandlerResponse
+		expectedMeSubs   int
+	}{
+		{
+			description:  "TC1: Subscription not found, should return ProblemDetails",
+			notifCorreID: "100",
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body:   openapi.ProblemDetailsDataNotFound("Subscrption is not found"),
+			},
+			expectedMeSubs: 1,
+		},
+		{
+			description:      "TC2: First report, should notify AF",
+			notifCorreID:     afSub.NotifCorreID,
+			expectedResponse: &HandlerResponse{Status: http.StatusNoContent},
+			expectedMeSubs:   1,
+		},
+		{
+			description:      "TC3: Maximum number of reports is reached, should remove subscription",
+			notifCorreID:     afSub.NotifCorreID,
+			expectedResponse: &HandlerResponse{Status: http.StatusNoContent},
+			expectedMeSubs:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().UdmNotification(tc.notifCorreID, monReports)
+			require.Equal(t, tc.expectedResponse, rsp)
+			require.Len(t, af1.MeSubs, tc.expectedMeSubs)
+			if rsp.Status != http.StatusNoContent {
+				return
+			}
+
+			r := <-afNotifChan
+			var meNotif nef_models.MonitoringNotification
+			if err := json.NewDecoder(r.Body).Decode(&meNotif); err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, nef_models.MonitoringNotification{
+				Subscription: meSub.Self,
+				MonitoringEventReports: []nef_models.MonitoringEventReport{
+					{
+						MonitoringType: nef_models.MonitoringType_LOSS_OF_CONNECTIVITY,
+						ExternalId:     "ue1@example.com",
+						EventTime:      &meReportTime,
+					},
+				},
+			}, meNotif)
+		})
+	}
+}
+
+func initAMFEvtsCreateSubscriptionStub() {
+	created := models.AmfCreatedEventSubscription{
+		SubscriptionId: "amf-sub-1",
+		ReportList: []models.AmfEventReport{
+			{
+				Type:      models.AmfEventType_LOCATION_REPORT,
+				State:     &models.AmfEventState{Active: true},
+				TimeStamp: &meReportTime,
+				Location:  meNrLocation,
+			},
+		},
+	}
+	gock.New("http://127.0.0.18:8000/namf-evts/v1").
+		Post("/subscriptions").
+		Persist().
+		Reply(http.StatusCreated).
+		JSON(created)
+}
+
+func initUDMEeCreateSubscriptionStub() {
+	gock.New("http://127.0.0.3:8000/nudm-ee/v1").
+		Post("/extid-ue1@example.com/ee-subscriptions").
+		Persist().
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.3:8000/nudm-ee/v1/extid-ue1@example.com/ee-subscriptions/udm-sub-1").
+		JSON(models.CreatedEeSubscription{})
+}
+
+func initNRFDiscAMFStub() {
+	searchResult := &models.SearchResult{
+		ValidityPeriod: 100,
+		NfInstances: []models.NfProfile{
+			{
+				NfInstanceId: "nef-unit-testing",
+				NfType:       "AMF",
+				NfStatus:     "REGISTERED",
+				NfServices: &[]models.NfService{
+					{
+						ServiceInstanceId: "1",
+						ServiceName:       "namf-evts",
+						Versions: &[]models.NfServiceVersion{
+							{
+								ApiVersionInUri: "v1",
+								ApiFullVersion:  "1.0.0",
+							},
+						},
+						Scheme:          "http",
+						NfServiceStatus: "REGISTERED",
+						IpEndPoints: &[]models.IpEndPoint{
+							{
+								Ipv4Address: "127.0.0.18",
+								Transport:   "TCP",
+								Port:        8000,
+							},
+						},
+						ApiPrefix: "http://127.0.0.18:8000",
+					},
+				},
+			},
+		},
+	}
+
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", "AMF").
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", "namf-evts").
+		Reply(http.StatusOK).
+		JSON(searchResult)
+}
+
+func initNRFDiscUDMStub(serviceName string) {
+	searchResult := &models.SearchResult{
+		ValidityPeriod: 100,
+		NfInstances: []models.NfProfile{
+			{
+				NfInstanceId: "nef-unit-testing",
+				NfType:       "UDM",
+				NfStatus:     "REGISTERED",
+				NfServices: &[]models.NfService{
+					{
+						ServiceInstanceId: "1",
+						ServiceName:       models.ServiceName(serviceName),
+						Versions: &[]models.NfServiceVersion{
+							{
+								ApiVersionInUri: "v1",
+								ApiFullVersion:  "1.0.0",
+							},
+						},
+						Scheme:          "http",
+						NfServiceStatus: "REGISTERED",
+						IpEndPoints: &[]models.IpEndPoint{
+							{
+								Ipv4Address: "127.0.0.3",
+								Transport:   "TCP",
+								Port:        8000,
+							},
+						},
+						ApiPrefix: "http://127.0.0.3:8000",
+					},
+				},
+			},
+		},
+	}
+
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", "UDM").
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", serviceName).
+		Reply(http.StatusOK).
+		JSON(searchResult)
+}
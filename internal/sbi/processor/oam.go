@@ -2,8 +2,317 @@ package processor
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	OamSubTypeTrafficInfluence = "TRAFFIC_INFLUENCE"
+	OamSubTypeMonitoringEvent  = "MONITORING_EVENT"
+	OamSubTypeAsSessionWithQoS = "AS_SESSION_WITH_QOS"
 )
 
+// OamAf is the OAM view of an AF with its subscriptions and transactions
+type OamAf struct {
+	AfId                string                  `json:"afId"`
+	NumSubscId          uint64                  `json:"numSubscId"`
+	NumTransId          uint64                  `json:"numTransId"`
+	Subscriptions       []OamSubscription       `json:"subscriptions,omitempty"`
+	PfdTransactions     []OamPfdTransaction     `json:"pfdTransactions,omitempty"`
+	TriggerTransactions []OamTriggerTransaction `json:"triggerTransactions,omitempty"`
+}
+
+// OamSubscription shows the resources in core network behind an AF subscription
+type OamSubscription struct {
+	SubId         string `json:"subId"`
+	Type          string `json:"type"`
+	NotifCorreId  string `json:"notifCorreId,omitempty"`
+	PcfAppSessId  string `json:"pcfAppSessId,omitempty"`
+	UdrInfluId    string `json:"udrInfluId,omitempty"`
+	AmfSubId      string `json:"amfSubId,omitempty"`
+	UdmSubId      string `json:"udmSubId,omitempty"`
+	UdmUeIdentity string `json:"udmUeIdentity,omitempty"`
+}
+
+type OamPfdTransaction struct {
+	TransId        string   `json:"transId"`
+	ExternalAppIds []string `json:"externalAppIds,omitempty"`
+}
+
+type OamTriggerTransaction struct {
+	TransId        string                    `json:"transId"`
+	MsgRef         string                    `json:"msgRef"`
+	DeliveryResult nef_models.DeliveryResult `json:"deliveryResult,omitempty"`
+}
+
+type OamCounters struct {
+	NumCorreId uint64 `json:"numCorreId"`
+	NumAfs     int    `json:"numAfs"`
+}
+
 func (p *Processor) GetOamIndex() *HandlerResponse {
 	return &HandlerResponse{http.StatusOK, nil, nil}
 }
+
+func (p *Processor) GetOamAfs() *HandlerResponse {
+	logger.OamLog.Infof("GetOamAfs")
+
+	afs := p.Context().GetAfs()
+	oamAfs := make([]OamAf, 0, len(afs))
+	for _, af := range afs {
+		af.Mu.RLock()
+		oamAfs = append(oamAfs, *convertAfDataToOamAf(af))
+		af.Mu.RUnlock()
+	}
+	return &HandlerResponse{http.StatusOK, nil, &oamAfs}
+}
+
+func (p *Processor) GetOamAf(afID string) *HandlerResponse {
+	logger.OamLog.Infof("GetOamAf - afID[%s]", afID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+	return &HandlerResponse{http.StatusOK, nil, convertAfDataToOamAf(af)}
+}
+
+func (p *Processor) GetOamPfdSubscriptions() *HandlerResponse {
+	logger.OamLog.Infof("GetOamPfdSubscriptions")
+
+	pfdSubs := p.Notifier().PfdChangeNotifier.GetPfdSubs()
+	return &HandlerResponse{http.StatusOK, nil, &pfdSubs}
+}
+
+func (p *Processor) GetOamPfdDeadLetters() *HandlerResponse {
+	logger.OamLog.Infof("GetOamPfdDeadLetters")
+
+	deadLetters := p.Notifier().PfdChangeNotifier.GetDeadLetters()
+	return &HandlerResponse{http.StatusOK, nil, &deadLetters}
+}
+
+func (p *Processor) GetOamAfDeadLetters() *HandlerResponse {
+	logger.OamLog.Infof("GetOamAfDeadLetters")
+
+	deadLetters := p.Notifier().AfNotifier.GetDeadLetters()
+	return &HandlerResponse{http.StatusOK, nil, &deadLetters}
+}
+
+func (p *Processor) GetOamCounters() *HandlerResponse {
+	logger.OamLog.Infof("GetOamCounters")
+
+	nefCtx := p.Context()
+	return &HandlerResponse{http.StatusOK, nil, &OamCounters{
+		NumCorreId: nefCtx.CorreID(),
+		NumAfs:     len(nefCtx.GetAfs()),
+	}}
+}
+
+// DeleteOamAf removes the AF regardless of the failures of core network,
+// after trying to remove all its resources in PCF, UDR, AMF and UDM
+func (p *Processor) DeleteOamAf(afID string) *HandlerResponse {
+	logger.OamLog.Infof("DeleteOamAf - afID[%s]", afID)
+
+	nefCtx := p.Context()
+	af := nefCtx.GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
+
+	af.Mu.Lock()
+	for subID := range af.Subs {
+		p.forceDeleteAfSub(af, subID)
+	}
+	for subID := range af.MeSubs {
+		p.forceDeleteAfSub(af, subID)
+	}
+	for subID := range af.QosSubs {
+		p.forceDeleteAfSub(af, subID)
+	}
+
+	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
+	for transID, afPfdTr := range af.PfdTrans {
+		for extAppID := range afPfdTr.ExtAppIDs {
+			if rsp := p.deletePfdDataFromUDR(extAppID); rsp != nil {
+				afPfdTr.Log.Warnf("Delete PFD data of appID[%s] from UDR failed: %d", extAppID, rsp.Status)
+			}
+			pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
+				ApplicationId: extAppID,
+				RemovalFlag:   true,
+			})
+		}
+		delete(af.PfdTrans, transID)
+	}
+	pfdNotifyContext.FlushNotifications()
+
+	for transID, trigTr := range af.TrigTrans {
+		if trigTr.IsPending() {
+			if err := p.Notifier().TriggerDeliverer.Recall(trigTr.MsgRef); err != nil {
+				trigTr.Log.Warnf("Recall device trigger err: %+v", err)
+			}
+		}
+		delete(af.TrigTrans, transID)
+	}
+	af.Mu.Unlock()
+
+	nefCtx.DeleteAf(afID)
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+// DeleteOamAfSubscription removes the subscription of any API regardless of the failures of core network
+func (p *Processor) DeleteOamAfSubscription(afID, subID string) *HandlerResponse {
+	logger.OamLog.Infof("DeleteOamAfSubscription - afID[%s], subID[%s]", afID, subID)
+
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	if !p.forceDeleteAfSub(af, subID) {
+		pd := openapi.ProblemDetailsDataNotFound("Subscription is not found")
+		return &HandlerResponse{http.StatusNotFound, nil, pd}
+	}
+	return &HandlerResponse{http.StatusNoContent, nil, nil}
+}
+
+// forceDeleteAfSub tries to remove the resources of the subscription in core network,
+// and removes the subscription even if it fails. The caller should hold af.Mu.
+func (p *Processor) forceDeleteAfSub(af *nef_context.AfData, subID string) bool {
+	if sub, ok := af.Subs[subID]; ok {
+		var rspStatus int
+		if sub.AppSessID != "" {
+			rspStatus, _ = p.Consumer().DeleteAppSession(sub.PcfUri, sub.AppSessID)
+		} else {
+			rspStatus, _ = p.Consumer().AppDataInfluenceDataDelete(sub.InfluID)
+		}
+		if rspStatus != http.StatusOK && rspStatus != http.StatusNoContent {
+			sub.Log.Warnf("Delete subscription from core network failed: %d", rspStatus)
+		}
+		delete(af.Subs, subID)
+		p.cancelTiSubExpiry(af.AfID, subID)
+		sub.Log.Infoln("Subscription is force deleted")
+		return true
+	}
+
+	if sub, ok := af.MeSubs[subID]; ok {
+		if rsp := p.deleteMonitoringEventSubFromCoreNetwork(sub); rsp != nil {
+			sub.Log.Warnf("Delete subscription from core network failed: %d", rsp.Status)
+		}
+		delete(af.MeSubs, subID)
+		sub.Log.Infoln("Subscription is force deleted")
+		return true
+	}
+
+	if sub, ok := af.QosSubs[subID]; ok {
+		rspStatus, _ := p.Consumer().DeleteAppSession(sub.PcfUri, sub.AppSessID)
+		if rspStatus != http.StatusOK && rspStatus != http.StatusNoContent {
+			sub.Log.Warnf("Delete subscription from core network failed: %d", rspStatus)
+		}
+		delete(af.QosSubs, subID)
+		sub.Log.Infoln("Subscription is force deleted")
+		return true
+	}
+	return false
+}
+
+func (p *Processor) PostOamConfigReload() *HandlerResponse {
+	logger.OamLog.Infof("PostOamConfigReload")
+
+	result, err := p.ReloadConfig()
+	if err != nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return &HandlerResponse{http.StatusOK, nil, result}
+}
+
+// convertAfDataToOamAf builds the OAM view of the AF. The caller should hold af.Mu.
+func convertAfDataToOamAf(af *nef_context.AfData) *OamAf {
+	oamAf := &OamAf{
+		AfId:       af.AfID,
+		NumSubscId: af.NumSubscID,
+		NumTransId: af.NumTransID,
+	}
+	for _, sub := range af.Subs {
+		oamAf.Subscriptions = append(oamAf.Subscriptions, OamSubscription{
+			SubId:        sub.SubID,
+			Type:         OamSubTypeTrafficInfluence,
+			NotifCorreId: sub.NotifCorreID,
+			PcfAppSessId: sub.AppSessID,
+			UdrInfluId:   sub.InfluID,
+		})
+	}
+	for _, sub := range af.MeSubs {
+		oamAf.Subscriptions = append(oamAf.Subscriptions, OamSubscription{
+			SubId:         sub.SubID,
+			Type:          OamSubTypeMonitoringEvent,
+			NotifCorreId:  sub.NotifCorreID,
+			AmfSubId:      sub.AmfSubID,
+			UdmSubId:      sub.UdmSubID,
+			UdmUeIdentity: sub.UeIdentity,
+		})
+	}
+	for _, sub := range af.QosSubs {
+		oamAf.Subscriptions = append(oamAf.Subscriptions, OamSubscription{
+			SubId:        sub.SubID,
+			Type:         OamSubTypeAsSessionWithQoS,
+			NotifCorreId: sub.NotifCorreID,
+			PcfAppSessId: sub.AppSessID,
+		})
+	}
+	sort.Slice(oamAf.Subscriptions, func(i, j int) bool {
+		return lessNumericID(oamAf.Subscriptions[i].SubId, oamAf.Subscriptions[j].SubId)
+	})
+
+	for _, afPfdTr := range af.PfdTrans {
+		appIDs := afPfdTr.GetExtAppIDs()
+		sort.Strings(appIDs)
+		oamAf.PfdTransactions = append(oamAf.PfdTransactions, OamPfdTransaction{
+			TransId:        afPfdTr.TransID,
+			ExternalAppIds: appIDs,
+		})
+	}
+	sort.Slice(oamAf.PfdTransactions, func(i, j int) bool {
+		return lessNumericID(oamAf.PfdTransactions[i].TransId, oamAf.PfdTransactions[j].TransId)
+	})
+
+	for _, trigTr := range af.TrigTrans {
+		oamAf.TriggerTransactions = append(oamAf.TriggerTransactions, OamTriggerTransaction{
+			TransId:        trigTr.TransID,
+			MsgRef:         trigTr.MsgRef,
+			DeliveryResult: trigTr.Trigger.DeliveryResult,
+		})
+	}
+	sort.Slice(oamAf.TriggerTransactions, func(i, j int) bool {
+		return lessNumericID(oamAf.TriggerTransactions[i].TransId, oamAf.TriggerTransactions[j].TransId)
+	})
+	return oamAf
+}
+
+func lessNumericID(a, b string) bool {
+	numA, errA := strconv.ParseUint(a, 10, 64)
+	numB, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return numA < numB
+}
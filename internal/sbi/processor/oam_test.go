@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestOamAfAndForceDelete(t *testing.T) {
+	initNRFDiscPCFStub()
+	// PCF fails to delete the stale app session, which shall not block the force deletion
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/12345/delete").
+		Reply(http.StatusInternalServerError)
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Delete("/application-data/influenceData/influ-1").
+		Reply(http.StatusNoContent)
+
+	nefCtx := nefApp.Context()
+	defer nefCtx.ResetCorreID()
+
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &tiSub1ForAf1)
+	afSub1.InfluID = "influ-1"
+	af1.Subs[afSub1.SubID] = afSub1
+	afSub2 := af1.NewSub(nefCtx.NewCorreID(), &tiSub3ForAf1)
+	afSub2.AppSessID = "12345"
+	af1.Subs[afSub2.SubID] = afSub2
+	afPfdTr := af1.NewPfdTrans()
+	af1.PfdTrans[afPfdTr.TransID] = afPfdTr
+	nefCtx.AddAf(af1)
+	af1.Mu.Unlock()
+
+	rsp := nefApp.Processor().GetOamAf("af1")
+	require.Equal(t, &HandlerResponse{
+		Status: http.StatusOK,
+		Body: &OamAf{
+			AfId:       "af1",
+			NumSubscId: 2,
+			NumTransId: 1,
+			Subscriptions: []OamSubscription{
+				{SubId: "1", Type: OamSubTypeTrafficInfluence, NotifCorreId: "1", UdrInfluId: "influ-1"},
+				{SubId: "2", Type: OamSubTypeTrafficInfluence, NotifCorreId: "2", PcfAppSessId: "12345"},
+			},
+			PfdTransactions: []OamPfdTransaction{
+				{TransId: "1", ExternalAppIds: []string{}},
+			},
+		},
+	}, rsp)
+
+	rsp = nefApp.Processor().GetOamCounters()
+	require.Equal(t, &HandlerResponse{
+		Status: http.StatusOK,
+		Body:   &OamCounters{NumCorreId: 2, NumAfs: 1},
+	}, rsp)
+
+	rsp = nefApp.Processor().DeleteOamAfSubscription("af1", "2")
+	require.Equal(t, &HandlerResponse{Status: http.StatusNoContent}, rsp)
+	rsp = nefApp.Processor().DeleteOamAfSubscription("af1", "2")
+	require.Equal(t, http.StatusNotFound, rsp.Status)
+
+	rsp = nefApp.Processor().DeleteOamAf("af1")
+	require.Equal(t, &HandlerResponse{Status: http.StatusNoContent}, rsp)
+	require.Nil(t, nefCtx.GetAf("af1"))
+}
@@ -3,9 +3,14 @@ package processor
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/internal/util"
+	"github.com/free5gc/nef/internal/validator"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -18,6 +23,14 @@ const (
 	DetailNoExtAppID = "Absent of PfdData.ExternalAppID"
 	DetailNoPfdID    = "Absent of Pfd.PfdID"
 	DetailNoPfdInfo  = "One of FlowDescriptions, Urls or DomainNames should be provided"
+	DetailInvalidPfd = "Invalid PFD content"
+)
+
+const (
+	// Maximum number of concurrent UDR requests of a PFD management request
+	PfdUdrConcurrency = 8
+	// Maximum number of application IDs in a UDR query of PFDs
+	PfdUdrQueryBatchSize = 32
 )
 
 func (p *Processor) GetPFDManagementTransactions(scsAsID string) *HandlerResponse {
@@ -30,11 +43,15 @@ func (p *Processor) GetPFDManagementTransactions(scsAsID string) *HandlerRespons
 	}
 
 	af.Mu.RLock()
-	defer af.Mu.RUnlock()
+	transIDToAppIDs := make(map[string][]string, len(af.PfdTrans))
+	for transID, afPfdTr := range af.PfdTrans {
+		transIDToAppIDs[transID] = afPfdTr.GetExtAppIDs()
+	}
+	af.Mu.RUnlock()
 
 	var pfdMngs []models.PfdManagement
-	for _, afPfdTr := range af.PfdTrans {
-		pfdMng, rsp := p.buildPfdManagement(scsAsID, afPfdTr)
+	for transID, appIDs := range transIDToAppIDs {
+		pfdMng, rsp := p.buildPfdManagement(scsAsID, transID, appIDs)
 		if rsp != nil {
 			return rsp
 		}
@@ -50,7 +67,9 @@ func (p *Processor) PostPFDManagementTransactions(
 ) *HandlerResponse {
 	logger.PFDManageLog.Infof("PostPFDManagementTransactions - scsAsID[%s]", scsAsID)
 
-	// TODO: Authorize the AF
+	if rsp := p.authorizeAfExtAppIDs(scsAsID, getExtAppIDs(pfdMng)...); rsp != nil {
+		return rsp
+	}
 
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, "-1", pfdMng, nefCtx); pd != nil {
@@ -66,10 +85,12 @@ func (p *Processor) PostPFDManagementTransactions(
 		return &HandlerResponse{http.StatusNotFound, nil, openapi.ProblemDetailsDataNotFound(DetailNoAF)}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
+	af.Mu.Lock()
 	afPfdTr := af.NewPfdTrans()
+	af.Mu.Unlock()
 	if afPfdTr == nil {
 		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
 		return &HandlerResponse{int(pd.Status), nil, pd}
@@ -78,31 +99,23 @@ func (p *Processor) PostPFDManagementTransactions(
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
-	for appID, pfdData := range pfdMng.PfdDatas {
-		afPfdTr.AddExtAppID(appID)
-		pfdDataForApp := convertPfdDataToPfdDataForApp(&pfdData)
-		if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
-			delete(pfdMng.PfdDatas, appID)
-			addPfdReport(pfdMng, pfdReport)
-		} else {
-			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
-			pfdMng.PfdDatas[appID] = pfdData
-			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-				ApplicationId: appID,
-				Pfds:          pfdDataForApp.Pfds,
-			})
-		}
+	if rsp := p.provisionPfdDatas(scsAsID, afPfdTr.TransID, pfdMng, nil, pfdNotifyContext); rsp != nil {
+		return rsp
 	}
 	if len(pfdMng.PfdDatas) == 0 {
 		// The PFDs for all applications were not created successfully.
 		// PfdReport is included with detailed information.
 		return &HandlerResponse{http.StatusInternalServerError, nil, &pfdMng.PfdReports}
 	}
+	for appID := range pfdMng.PfdDatas {
+		afPfdTr.AddExtAppID(appID)
+	}
 
+	af.Mu.Lock()
 	af.PfdTrans[afPfdTr.TransID] = afPfdTr
 	afPfdTr.Log.Infoln("PFD Management Transaction is added")
-
 	nefCtx.AddAf(af)
+	af.Mu.Unlock()
 
 	pfdMng.Self = p.genPfdManagementURI(scsAsID, afPfdTr.TransID)
 
@@ -118,24 +131,39 @@ func (p *Processor) DeletePFDManagementTransactions(scsAsID string) *HandlerResp
 		return &HandlerResponse{http.StatusNotFound, nil, openapi.ProblemDetailsDataNotFound(DetailNoAF)}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
+
+	af.Mu.RLock()
+	var appIDs []string
+	for _, afPfdTr := range af.PfdTrans {
+		appIDs = append(appIDs, afPfdTr.GetExtAppIDs()...)
+	}
+	af.Mu.RUnlock()
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
+	deletedAppIDs, rsp := p.deletePfdDatasFromUDR(appIDs, pfdNotifyContext)
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	// The transactions with applications failed to be deleted are kept
 	for _, afPfdTr := range af.PfdTrans {
-		for extAppID := range afPfdTr.ExtAppIDs {
-			if rsp := p.deletePfdDataFromUDR(extAppID); rsp != nil {
-				return rsp
+		for _, appID := range deletedAppIDs {
+			if _, ok := afPfdTr.ExtAppIDs[appID]; ok {
+				afPfdTr.DeleteExtAppID(appID)
 			}
-			pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
-				ApplicationId: extAppID,
-				RemovalFlag:   true,
-			})
 		}
-		delete(af.PfdTrans, afPfdTr.TransID)
-		afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
+		if len(afPfdTr.ExtAppIDs) == 0 {
+			delete(af.PfdTrans, afPfdTr.TransID)
+			afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
+		}
+	}
+	if rsp != nil {
+		return rsp
 	}
 
 	// TODO: Remove AfCtx if its subscriptions and transactions are both empty
@@ -155,16 +183,12 @@ func (p *Processor) GetIndividualPFDManagementTransaction(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.RLock()
-	defer af.Mu.RUnlock()
-
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	appIDs, rsp := getPfdTransExtAppIDs(af, transID)
+	if rsp != nil {
+		return rsp
 	}
 
-	pfdMng, rsp := p.buildPfdManagement(scsAsID, afPfdTr)
+	pfdMng, rsp := p.buildPfdManagement(scsAsID, transID, appIDs)
 	if pfdMng == nil {
 		return rsp
 	}
@@ -179,7 +203,9 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 	logger.PFDManageLog.Infof("PutIndividualPFDManagementTransaction - scsAsID[%s], transID[%s]",
 		scsAsID, transID)
 
-	// TODO: Authorize the AF
+	if rsp := p.authorizeAfExtAppIDs(scsAsID, getExtAppIDs(pfdMng)...); rsp != nil {
+		return rsp
+	}
 
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, transID, pfdMng, nefCtx); pd != nil {
@@ -196,13 +222,12 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	oldAppIDs, rsp := getPfdTransExtAppIDs(af, transID)
+	if rsp != nil {
+		return rsp
 	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
@@ -210,36 +235,23 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 
 	// Delete PfdDataForApps in UDR with appID absent in new PfdManagement
 	deprecatedAppIDs := []string{}
-	for extAppID := range afPfdTr.ExtAppIDs {
+	for _, extAppID := range oldAppIDs {
 		if _, exist := pfdMng.PfdDatas[extAppID]; !exist {
 			deprecatedAppIDs = append(deprecatedAppIDs, extAppID)
 		}
 	}
-	for _, appID := range deprecatedAppIDs {
-		if rsp := p.deletePfdDataFromUDR(appID); rsp != nil {
-			return rsp
-		}
-		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-			ApplicationId: appID,
-			RemovalFlag:   true,
-		})
+	if rsp = p.provisionPfdDatas(scsAsID, transID, pfdMng, deprecatedAppIDs, pfdNotifyContext); rsp != nil {
+		return rsp
 	}
 
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afPfdTr := af.PfdTrans[transID]
 	afPfdTr.DeleteAllExtAppIDs()
-	for appID, pfdData := range pfdMng.PfdDatas {
+	for appID := range pfdMng.PfdDatas {
 		afPfdTr.AddExtAppID(appID)
-		pfdDataForApp := convertPfdDataToPfdDataForApp(&pfdData)
-		if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
-			delete(pfdMng.PfdDatas, appID)
-			addPfdReport(pfdMng, pfdReport)
-		} else {
-			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
-			pfdMng.PfdDatas[appID] = pfdData
-			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-				ApplicationId: appID,
-				Pfds:          pfdDataForApp.Pfds,
-			})
-		}
 	}
 	if len(pfdMng.PfdDatas) == 0 {
 		// The PFDs for all applications were not created successfully.
@@ -264,26 +276,30 @@ func (p *Processor) DeleteIndividualPFDManagementTransaction(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	appIDs, rsp := getPfdTransExtAppIDs(af, transID)
+	if rsp != nil {
+		return rsp
 	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
-	for extAppID := range afPfdTr.ExtAppIDs {
-		if rsp := p.deletePfdDataFromUDR(extAppID); rsp != nil {
-			return rsp
+	deletedAppIDs, rsp := p.deletePfdDatasFromUDR(appIDs, pfdNotifyContext)
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	afPfdTr := af.PfdTrans[transID]
+	if rsp != nil {
+		// The transaction is kept with the applications failed to be deleted
+		for _, appID := range deletedAppIDs {
+			afPfdTr.DeleteExtAppID(appID)
 		}
-		pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
-			ApplicationId: extAppID,
-			RemovalFlag:   true,
-		})
+		return rsp
 	}
 	delete(af.PfdTrans, afPfdTr.TransID)
 	afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
@@ -305,19 +321,8 @@ func (p *Processor) GetIndividualApplicationPFDManagement(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.RLock()
-	defer af.Mu.RUnlock()
-
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
-	}
-
-	_, ok = afPfdTr.ExtAppIDs[appID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("Application ID not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	if rsp := checkPfdTransExtAppID(af, transID, appID); rsp != nil {
+		return rsp
 	}
 
 	rspCode, rspBody := p.Consumer().AppDataPfdsAppIdGet(appID)
@@ -342,19 +347,11 @@ func (p *Processor) DeleteIndividualApplicationPFDManagement(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
-	}
-
-	_, ok = afPfdTr.ExtAppIDs[appID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("Application ID not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	if rsp := checkPfdTransExtAppID(af, transID, appID); rsp != nil {
+		return rsp
 	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
@@ -363,12 +360,17 @@ func (p *Processor) DeleteIndividualApplicationPFDManagement(
 	if rsp := p.deletePfdDataFromUDR(appID); rsp != nil {
 		return rsp
 	}
-	afPfdTr.DeleteExtAppID(appID)
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
 		RemovalFlag:   true,
 	})
 
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
+
+	af.PfdTrans[transID].DeleteExtAppID(appID)
+
 	// TODO: Remove afPfdTr if its appID is empty
 
 	// TODO: Remove AfCtx if its subscriptions and transactions are both empty
@@ -383,7 +385,9 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PutIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
+	if rsp := p.authorizeAfExtAppIDs(scsAsID, appID); rsp != nil {
+		return rsp
+	}
 
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
@@ -392,24 +396,19 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
-	}
-
-	_, ok = afPfdTr.ExtAppIDs[appID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("Application ID not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	if rsp := checkPfdTransExtAppID(af, transID, appID); rsp != nil {
+		return rsp
 	}
 
 	if pd := validatePfdData(pfdData, nefCtx, false); pd != nil {
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
+	if pfdReport := p.checkPfdAllowedDelay(appID, pfdData); pfdReport != nil {
+		return &HandlerResponse{http.StatusInternalServerError, nil, pfdReport}
+	}
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
@@ -419,10 +418,7 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		return &HandlerResponse{http.StatusInternalServerError, nil, pfdReport}
 	}
 	pfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
-	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-		ApplicationId: appID,
-		Pfds:          pfdDataForApp.Pfds,
-	})
+	addPfdChangeNotification(pfdNotifyContext, appID, pfdData, pfdDataForApp.Pfds)
 
 	return &HandlerResponse{http.StatusOK, nil, pfdData}
 }
@@ -434,7 +430,9 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PatchIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
+	if rsp := p.authorizeAfExtAppIDs(scsAsID, appID); rsp != nil {
+		return rsp
+	}
 
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
@@ -443,19 +441,11 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
-	af.Mu.Lock()
-	defer af.Mu.Unlock()
+	af.PfdMu.Lock()
+	defer af.PfdMu.Unlock()
 
-	afPfdTr, ok := af.PfdTrans[transID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
-	}
-
-	_, ok = afPfdTr.ExtAppIDs[appID]
-	if !ok {
-		pd := openapi.ProblemDetailsDataNotFound("Application ID not found")
-		return &HandlerResponse{int(pd.Status), nil, pd}
+	if rsp := checkPfdTransExtAppID(af, transID, appID); rsp != nil {
+		return rsp
 	}
 
 	if pd := validatePfdData(pfdData, nefCtx, true); pd != nil {
@@ -474,39 +464,62 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 	if pd := patchModifyPfdData(oldPfdData, pfdData); pd != nil {
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
+	// The allowed delay is not kept in UDR, it only applies to this change
+	oldPfdData.AllowedDelay = pfdData.AllowedDelay
+	if pfdReport := p.checkPfdAllowedDelay(appID, oldPfdData); pfdReport != nil {
+		return &HandlerResponse{http.StatusInternalServerError, nil, pfdReport}
+	}
 
 	pfdDataForApp := convertPfdDataToPfdDataForApp(oldPfdData)
 	if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
 		return &HandlerResponse{http.StatusInternalServerError, nil, pfdReport}
 	}
 	oldPfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
-	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-		ApplicationId: appID,
-		Pfds:          pfdDataForApp.Pfds,
-	})
+	addPfdChangeNotification(pfdNotifyContext, appID, oldPfdData, pfdDataForApp.Pfds)
 
 	return &HandlerResponse{http.StatusOK, nil, oldPfdData}
 }
 
 func (p *Processor) buildPfdManagement(
-	afID string,
-	afPfdTr *nef_context.AfPfdTransaction,
+	afID, transID string,
+	appIDs []string,
 ) (*models.PfdManagement, *HandlerResponse) {
-	transID := afPfdTr.TransID
-	appIDs := afPfdTr.GetExtAppIDs()
 	pfdMng := &models.PfdManagement{
 		Self:     p.genPfdManagementURI(afID, transID),
 		PfdDatas: make(map[string]models.PfdData, len(appIDs)),
 	}
 
-	rspCode, rspBody := p.Consumer().AppDataPfdsGet(appIDs)
-	if rspCode != http.StatusOK {
-		return nil, &HandlerResponse{rspCode, nil, rspBody}
+	// Query UDR in batches to keep the request URIs short
+	sort.Strings(appIDs)
+	var batches [][]string
+	for len(appIDs) > PfdUdrQueryBatchSize {
+		batches = append(batches, appIDs[:PfdUdrQueryBatchSize])
+		appIDs = appIDs[PfdUdrQueryBatchSize:]
 	}
-	for _, pfdDataForApp := range *(rspBody.(*[]models.PfdDataForApp)) {
-		pfdData := convertPfdDataForAppToPfdData(&pfdDataForApp)
-		pfdData.Self = p.genPfdDataURI(afID, transID, pfdData.ExternalAppId)
-		pfdMng.PfdDatas[pfdData.ExternalAppId] = *pfdData
+	if len(appIDs) > 0 {
+		batches = append(batches, appIDs)
+	}
+
+	results := make([][]models.PfdDataForApp, len(batches))
+	rsps := make([]*HandlerResponse, len(batches))
+	forEachConcurrently(len(batches), PfdUdrConcurrency, func(i int) {
+		rspCode, rspBody := p.Consumer().AppDataPfdsGet(batches[i])
+		if rspCode != http.StatusOK {
+			rsps[i] = &HandlerResponse{rspCode, nil, rspBody}
+			return
+		}
+		results[i] = *(rspBody.(*[]models.PfdDataForApp))
+	})
+
+	for i := range batches {
+		if rsps[i] != nil {
+			return nil, rsps[i]
+		}
+		for _, pfdDataForApp := range results[i] {
+			pfdData := convertPfdDataForAppToPfdData(&pfdDataForApp)
+			pfdData.Self = p.genPfdDataURI(afID, transID, pfdData.ExternalAppId)
+			pfdMng.PfdDatas[pfdData.ExternalAppId] = *pfdData
+		}
 	}
 	return pfdMng, nil
 }
@@ -522,6 +535,250 @@ func (p *Processor) storePfdDataToUDR(appID string, pfdDataForApp *models.PfdDat
 	return nil
 }
 
+// provisionPfdDatas deletes the PFDs of the deprecated applications from UDR, then stores the PFDs
+// of pfdMng. The UDR requests are sent concurrently, and the results are merged in the order of
+// application IDs. The applications failed to be stored are moved from PfdDatas to PfdReports.
+// In atomic mode, the transaction fails if any application fails: the UDR changes are rolled back,
+// the notifications are discarded and the error response is returned.
+func (p *Processor) provisionPfdDatas(
+	afID, transID string,
+	pfdMng *models.PfdManagement,
+	deprecatedAppIDs []string,
+	pfdNotifyContext *notifier.PfdNotifyContext,
+) *HandlerResponse {
+	appIDs := make([]string, 0, len(pfdMng.PfdDatas))
+	for appID, pfdData := range pfdMng.PfdDatas {
+		if pfdReport := p.checkPfdAllowedDelay(appID, &pfdData); pfdReport != nil {
+			delete(pfdMng.PfdDatas, appID)
+			addPfdReport(pfdMng, pfdReport)
+		} else {
+			pfdMng.PfdDatas[appID] = pfdData
+			appIDs = append(appIDs, appID)
+		}
+	}
+	sort.Strings(appIDs)
+	defer sortPfdReports(pfdMng)
+
+	var udrTx *pfdUdrTransaction
+	if p.Config().PfdAtomicTransaction() {
+		if len(pfdMng.PfdReports) > 0 {
+			// Some applications have been rejected, nothing is provisioned
+			return &HandlerResponse{http.StatusInternalServerError, nil, &pfdMng.PfdReports}
+		}
+		var rsp *HandlerResponse
+		changedAppIDs := append(append([]string{}, deprecatedAppIDs...), appIDs...)
+		if udrTx, rsp = p.snapshotPfdDatas(changedAppIDs); rsp != nil {
+			return rsp
+		}
+	}
+
+	if deletedAppIDs, rsp := p.deletePfdDatasFromUDR(deprecatedAppIDs, pfdNotifyContext); rsp != nil {
+		if udrTx != nil {
+			return udrTx.abort(rsp, pfdNotifyContext)
+		}
+		// The transaction is kept with the applications failed to be deleted
+		p.deletePfdTransExtAppIDs(afID, transID, deletedAppIDs)
+		return rsp
+	}
+
+	pfdDataForApps := make([]*models.PfdDataForApp, len(appIDs))
+	for i, appID := range appIDs {
+		pfdData := pfdMng.PfdDatas[appID]
+		pfdDataForApps[i] = convertPfdDataToPfdDataForApp(&pfdData)
+	}
+	pfdReports := make([]*models.PfdReport, len(appIDs))
+	forEachConcurrently(len(appIDs), PfdUdrConcurrency, func(i int) {
+		pfdReports[i] = p.storePfdDataToUDR(appIDs[i], pfdDataForApps[i])
+	})
+
+	for i, appID := range appIDs {
+		if pfdReports[i] != nil {
+			delete(pfdMng.PfdDatas, appID)
+			addPfdReport(pfdMng, pfdReports[i])
+			continue
+		}
+		pfdData := pfdMng.PfdDatas[appID]
+		pfdData.Self = p.genPfdDataURI(afID, transID, appID)
+		pfdMng.PfdDatas[appID] = pfdData
+		addPfdChangeNotification(pfdNotifyContext, appID, &pfdData, pfdDataForApps[i].Pfds)
+	}
+	if udrTx != nil && len(pfdMng.PfdReports) > 0 {
+		return udrTx.abort(&HandlerResponse{http.StatusInternalServerError, nil, &pfdMng.PfdReports},
+			pfdNotifyContext)
+	}
+	return nil
+}
+
+// pfdUdrTransaction keeps the PFDs of the applications in UDR before they are changed,
+// so that the changes can be rolled back when the PFD management transaction fails
+type pfdUdrTransaction struct {
+	p      *Processor
+	appIDs []string
+	// PFDs of appIDs before the changes, nil if the application was absent in UDR
+	snapshots []*models.PfdDataForApp
+}
+
+func (p *Processor) snapshotPfdDatas(appIDs []string) (*pfdUdrTransaction, *HandlerResponse) {
+	tx := &pfdUdrTransaction{
+		p:         p,
+		appIDs:    appIDs,
+		snapshots: make([]*models.PfdDataForApp, len(appIDs)),
+	}
+
+	rsps := make([]*HandlerResponse, len(appIDs))
+	forEachConcurrently(len(appIDs), PfdUdrConcurrency, func(i int) {
+		rspCode, rspBody := p.Consumer().AppDataPfdsAppIdGet(appIDs[i])
+		switch rspCode {
+		case http.StatusOK:
+			tx.snapshots[i] = rspBody.(*models.PfdDataForApp)
+		case http.StatusNotFound:
+		default:
+			rsps[i] = &HandlerResponse{rspCode, nil, rspBody}
+		}
+	})
+	for _, rsp := range rsps {
+		if rsp != nil {
+			return nil, rsp
+		}
+	}
+	return tx, nil
+}
+
+// abort restores the PFDs in UDR, and discards the notifications
+func (tx *pfdUdrTransaction) abort(rsp *HandlerResponse, pfdNotifyContext *notifier.PfdNotifyContext) *HandlerResponse {
+	forEachConcurrently(len(tx.appIDs), PfdUdrConcurrency, func(i int) {
+		appID := tx.appIDs[i]
+		if pfdDataForApp := tx.snapshots[i]; pfdDataForApp != nil {
+			if rspCode, _ := tx.p.Consumer().AppDataPfdsAppIdPut(appID, pfdDataForApp); rspCode != http.StatusOK &&
+				rspCode != http.StatusCreated {
+				logger.PFDManageLog.Errorf("Rollback PFDs of appID[%s] failed: status[%d]", appID, rspCode)
+			}
+		} else {
+			if rspCode, _ := tx.p.Consumer().AppDataPfdsAppIdDelete(appID); rspCode != http.StatusNoContent &&
+				rspCode != http.StatusNotFound {
+				logger.PFDManageLog.Errorf("Rollback PFDs of appID[%s] failed: status[%d]", appID, rspCode)
+			}
+		}
+	})
+	logger.PFDManageLog.Warnf("PFD management transaction is rolled back for appIDs: %v", tx.appIDs)
+
+	pfdNotifyContext.Discard()
+	return rsp
+}
+
+// deletePfdDatasFromUDR deletes the PFDs of the applications from UDR concurrently, and adds the
+// removal notifications of the deleted ones. The response of the first failed application is returned.
+func (p *Processor) deletePfdDatasFromUDR(
+	appIDs []string,
+	pfdNotifyContext *notifier.PfdNotifyContext,
+) ([]string, *HandlerResponse) {
+	appIDs = append([]string{}, appIDs...)
+	sort.Strings(appIDs)
+
+	rsps := make([]*HandlerResponse, len(appIDs))
+	forEachConcurrently(len(appIDs), PfdUdrConcurrency, func(i int) {
+		rsps[i] = p.deletePfdDataFromUDR(appIDs[i])
+	})
+
+	var failedRsp *HandlerResponse
+	deletedAppIDs := make([]string, 0, len(appIDs))
+	for i, appID := range appIDs {
+		if rsps[i] != nil {
+			if failedRsp == nil {
+				failedRsp = rsps[i]
+			}
+			continue
+		}
+		deletedAppIDs = append(deletedAppIDs, appID)
+		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
+			ApplicationId: appID,
+			RemovalFlag:   true,
+		})
+	}
+	return deletedAppIDs, failedRsp
+}
+
+// deletePfdTransExtAppIDs removes the applications deleted from UDR from the PFD transaction
+func (p *Processor) deletePfdTransExtAppIDs(afID, transID string, appIDs []string) {
+	af := p.Context().GetAf(afID)
+	if af == nil || len(appIDs) == 0 {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	afPfdTr, ok := af.PfdTrans[transID]
+	if !ok {
+		return
+	}
+	for _, appID := range appIDs {
+		afPfdTr.DeleteExtAppID(appID)
+	}
+	p.Context().SaveAf(af)
+}
+
+// getPfdTransExtAppIDs returns the application IDs of the PFD transaction
+func getPfdTransExtAppIDs(af *nef_context.AfData, transID string) ([]string, *HandlerResponse) {
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	afPfdTr, ok := af.PfdTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
+		return nil, &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return afPfdTr.GetExtAppIDs(), nil
+}
+
+// checkPfdTransExtAppID checks that the application is provisioned by the PFD transaction
+func checkPfdTransExtAppID(af *nef_context.AfData, transID, appID string) *HandlerResponse {
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	afPfdTr, ok := af.PfdTrans[transID]
+	if !ok {
+		pd := openapi.ProblemDetailsDataNotFound("PFD transaction not found")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if _, ok = afPfdTr.ExtAppIDs[appID]; !ok {
+		pd := openapi.ProblemDetailsDataNotFound("Application ID not found")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+// checkPfdAllowedDelay sets the caching time of the PFDs. SHORT_DELAY is reported if the allowed delay
+// is shorter than the caching time, since SMF may keep using the cached PFDs beyond the allowed delay.
+func (p *Processor) checkPfdAllowedDelay(appID string, pfdData *models.PfdData) *models.PfdReport {
+	cachingTime := p.Config().PfdCachingTime()
+	pfdData.CachingTime = cachingTime
+	if pfdData.AllowedDelay > 0 && pfdData.AllowedDelay < cachingTime {
+		return &models.PfdReport{
+			ExternalAppIds: []string{appID},
+			FailureCode:    models.FailureCode_SHORT_DELAY,
+			CachingTime:    cachingTime,
+		}
+	}
+	return nil
+}
+
+// addPfdChangeNotification notifies the changed PFDs, which are coalesced with the other changes
+// within the allowed delay if it is given
+func addPfdChangeNotification(
+	nc *notifier.PfdNotifyContext, appID string, pfdData *models.PfdData, pfds []models.PfdContent,
+) {
+	notif := &models.PfdChangeNotification{
+		ApplicationId: appID,
+		Pfds:          pfds,
+	}
+	if pfdData.AllowedDelay > 0 {
+		nc.AddDelayedNotification(appID, notif, time.Duration(pfdData.AllowedDelay)*time.Second)
+		return
+	}
+	nc.AddNotification(appID, notif)
+}
+
 func (p *Processor) deletePfdDataFromUDR(appID string) *HandlerResponse {
 	rspCode, rspBody := p.Consumer().AppDataPfdsAppIdDelete(appID)
 	if rspCode != http.StatusNoContent {
@@ -555,6 +812,12 @@ func convertPfdDataForAppToPfdData(pfdDataForApp *models.PfdDataForApp) *models.
 		ExternalAppId: pfdDataForApp.ApplicationId,
 		Pfds:          make(map[string]models.Pfd, len(pfdDataForApp.Pfds)),
 	}
+	if pfdDataForApp.CachingTime != nil {
+		// The remaining time that the PFDs may be cached
+		if remaining := time.Until(*pfdDataForApp.CachingTime); remaining > 0 {
+			pfdData.CachingTime = int32((remaining + time.Second - 1) / time.Second)
+		}
+	}
 	for _, pfdContent := range pfdDataForApp.Pfds {
 		var pfd models.Pfd
 		pfd.PfdId = pfdContent.PfdId
@@ -570,6 +833,10 @@ func convertPfdDataToPfdDataForApp(pfdData *models.PfdData) *models.PfdDataForAp
 	pfdDataForApp := &models.PfdDataForApp{
 		ApplicationId: pfdData.ExternalAppId,
 	}
+	if pfdData.CachingTime > 0 {
+		cachingTime := time.Now().Add(time.Duration(pfdData.CachingTime) * time.Second)
+		pfdDataForApp.CachingTime = &cachingTime
+	}
 	for _, pfd := range pfdData.Pfds {
 		var pfdContent models.PfdContent
 		pfdContent.PfdId = pfd.PfdId
@@ -604,6 +871,7 @@ func validatePfdManagement(
 		return openapi.ProblemDetailsDataNotFound(DetailNoPfdData)
 	}
 
+	var invalidParams []models.InvalidParam
 	for appID, pfdData := range pfdMng.PfdDatas {
 		// Check whether the received external Application Identifier(s) are already provisioned
 		appAfID, appTransID, ok := nefCtx.IsAppIDExisted(appID)
@@ -615,13 +883,25 @@ func validatePfdManagement(
 			})
 		}
 		if pd := validatePfdData(&pfdData, nefCtx, false); pd != nil {
-			return pd
+			if len(pd.InvalidParams) == 0 {
+				return pd
+			}
+			// Report the invalid PFDs of all applications at once
+			for _, param := range pd.InvalidParams {
+				param.Param = validator.JoinPointer("/pfdDatas", appID) + param.Param
+				invalidParams = append(invalidParams, param)
+			}
 		}
 	}
+	if len(invalidParams) > 0 {
+		sortInvalidParams(invalidParams)
+		return util.ProblemDetailsInvalidParams(DetailInvalidPfd, invalidParams)
+	}
 
 	if len(pfdMng.PfdDatas) == 0 {
 		// The PFDs for all applications were not created successfully.
 		// PfdReport is included with detailed information.
+		sortPfdReports(pfdMng)
 		return openapi.ProblemDetailsSystemFailure("None of the PFDs were created")
 	}
 	return nil
@@ -635,8 +915,15 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 	if len(pfdData.Pfds) == 0 {
 		return openapi.ProblemDetailsDataNotFound(DetailNoPfd)
 	}
+	if len(pfdData.Pfds) > validator.MaxPfdsPerApp {
+		return util.ProblemDetailsInvalidParams(DetailInvalidPfd, []models.InvalidParam{{
+			Param:  "/pfds",
+			Reason: fmt.Sprintf("more than %d PFDs", validator.MaxPfdsPerApp),
+		}})
+	}
 
-	for _, pfd := range pfdData.Pfds {
+	var invalidParams []models.InvalidParam
+	for pfdID, pfd := range pfdData.Pfds {
 		if pfd.PfdId == "" {
 			return openapi.ProblemDetailsDataNotFound(DetailNoPfdID)
 		}
@@ -644,6 +931,11 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 		if !isPatch && len(pfd.FlowDescriptions) == 0 && len(pfd.Urls) == 0 && len(pfd.DomainNames) == 0 {
 			return openapi.ProblemDetailsDataNotFound(DetailNoPfdInfo)
 		}
+		invalidParams = append(invalidParams, validator.Pfd(validator.JoinPointer("/pfds", pfdID), &pfd)...)
+	}
+	if len(invalidParams) > 0 {
+		sortInvalidParams(invalidParams)
+		return util.ProblemDetailsInvalidParams(DetailInvalidPfd, invalidParams)
 	}
 
 	return nil
@@ -652,7 +944,23 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 func addPfdReport(pfdMng *models.PfdManagement, newReport *models.PfdReport) {
 	if oldReport, ok := pfdMng.PfdReports[string(newReport.FailureCode)]; ok {
 		oldReport.ExternalAppIds = append(oldReport.ExternalAppIds, newReport.ExternalAppIds...)
+		pfdMng.PfdReports[string(newReport.FailureCode)] = oldReport
 	} else {
 		pfdMng.PfdReports[string(newReport.FailureCode)] = *newReport
 	}
 }
+
+// sortPfdReports sorts the application IDs of the PfdReports, so that the response is stable
+func sortPfdReports(pfdMng *models.PfdManagement) {
+	for _, pfdReport := range pfdMng.PfdReports {
+		sort.Strings(pfdReport.ExternalAppIds)
+	}
+}
+
+func getExtAppIDs(pfdMng *models.PfdManagement) []string {
+	appIDs := make([]string, 0, len(pfdMng.PfdDatas))
+	for appID := range pfdMng.PfdDatas {
+		appIDs = append(appIDs, appID)
+	}
+	return appIDs
+}
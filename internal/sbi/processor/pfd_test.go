@@ -1,13 +1,18 @@
 package processor
 
 import (
+	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/internal/util"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -33,7 +38,7 @@ func newTestApp(cfg *factory.Config, tlsKeyLogPath string) (*nefTestApp, error)
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(cfg, nef.nefCtx.Store()); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = NewProcessor(nef); err != nil {
@@ -62,6 +67,10 @@ func (a *nefTestApp) Processor() *Processor {
 	return a.proc
 }
 
+func (a *nefTestApp) ReloadConfig() (*factory.ReloadResult, error) {
+	return factory.ReloadConfig(a.cfg)
+}
+
 var (
 	nefApp *nefTestApp
 
@@ -594,6 +603,48 @@ func TestPutIndividualPFDManagementTransaction(t *testing.T) {
 	}
 }
 
+func TestPutIndividualPFDManagementTransactionDeleteFailure(t *testing.T) {
+	initUDRDrPutPfdDataStub(http.StatusOK)
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Delete("/application-data/pfds/app2$").
+		Persist().
+		Reply(http.StatusNoContent)
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Delete("/application-data/pfds/app3$").
+		Persist().
+		Reply(http.StatusInternalServerError).
+		JSON(models.ProblemDetails{Status: http.StatusInternalServerError})
+	defer gock.Off()
+
+	af := nefApp.Context().NewAf("af1")
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	for _, appID := range []string{"app1", "app2", "app3"} {
+		afPfdTr.AddExtAppID(appID)
+	}
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	af.Mu.Unlock()
+
+	// app2 is deleted while app3 fails, only app3 should be kept with app1 in the transaction
+	rsp := nefApp.Processor().PutIndividualPFDManagementTransaction("af1", afPfdTr.TransID,
+		&models.PfdManagement{
+			PfdDatas: map[string]models.PfdData{
+				"app1": {
+					ExternalAppId: "app1",
+					Pfds:          map[string]models.Pfd{"pfd1": pfd1},
+				},
+			},
+		})
+	require.Equal(t, http.StatusInternalServerError, rsp.Status)
+
+	af.Mu.RLock()
+	require.Equal(t, map[string]struct{}{"app1": {}, "app3": {}}, afPfdTr.ExtAppIDs)
+	af.Mu.RUnlock()
+}
+
 func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 	initUDRDrGetPfdDataStub()
 	defer gock.Off()
@@ -1050,6 +1101,32 @@ func TestValidatePfdData(t *testing.T) {
 			},
 			expectedResult: openapi.ProblemDetailsDataNotFound(DetailNoPfdInfo),
 		},
+		{
+			description: "TC6: Malformed flow description and URL, should return ProblemDetails with InvalidParams",
+			pfdData: &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": {
+						PfdId:            "pfd1",
+						FlowDescriptions: []string{"permit in ip from 10.68.28.39 80 to anywhere"},
+					},
+					"pfd2": {
+						PfdId: "pfd2",
+						Urls:  []string{"ftp://test.example.com"},
+					},
+				},
+			},
+			expectedResult: util.ProblemDetailsInvalidParams(DetailInvalidPfd, []models.InvalidParam{
+				{
+					Param:  "/pfds/pfd1/flowDescriptions/0",
+					Reason: "destination address[anywhere] is invalid",
+				},
+				{
+					Param:  "/pfds/pfd2/urls/0",
+					Reason: "scheme[ftp] should be http or https",
+				},
+			}),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1183,6 +1260,159 @@ func TestPatchModifyPfdData(t *testing.T) {
 	}
 }
 
+func TestCheckPfdAllowedDelay(t *testing.T) {
+	pfdManagement := nefApp.Config().Configuration.PfdManagement
+	nefApp.Config().Configuration.PfdManagement = &factory.PfdManagement{CachingTime: 60}
+	defer func() {
+		nefApp.Config().Configuration.PfdManagement = pfdManagement
+	}()
+
+	testCases := []struct {
+		description    string
+		allowedDelay   int32
+		expectedReport *models.PfdReport
+	}{
+		{
+			description: "TC1: No allowed delay, should be accepted",
+		},
+		{
+			description:  "TC2: Allowed delay is not shorter than caching time, should be accepted",
+			allowedDelay: 60,
+		},
+		{
+			description:  "TC3: Allowed delay is shorter than caching time, should return SHORT_DELAY",
+			allowedDelay: 30,
+			expectedReport: &models.PfdReport{
+				ExternalAppIds: []string{"app1"},
+				FailureCode:    models.FailureCode_SHORT_DELAY,
+				CachingTime:    60,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			pfdData := &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds:          map[string]models.Pfd{"pfd1": pfd1},
+				AllowedDelay:  tc.allowedDelay,
+			}
+			require.Equal(t, tc.expectedReport, nefApp.Processor().checkPfdAllowedDelay("app1", pfdData))
+			require.Equal(t, int32(60), pfdData.CachingTime)
+
+			pfdDataForApp := convertPfdDataToPfdDataForApp(pfdData)
+			require.NotNil(t, pfdDataForApp.CachingTime)
+			require.WithinDuration(t, time.Now().Add(60*time.Second), *pfdDataForApp.CachingTime, time.Second)
+			require.Equal(t, int32(60), convertPfdDataForAppToPfdData(pfdDataForApp).CachingTime)
+		})
+	}
+}
+
+func TestPostPFDManagementTransactionsAtomic(t *testing.T) {
+	pfdManagement := nefApp.Config().Configuration.PfdManagement
+	nefApp.Config().Configuration.PfdManagement = &factory.PfdManagement{AtomicTransaction: true}
+	defer func() {
+		nefApp.Config().Configuration.PfdManagement = pfdManagement
+	}()
+
+	// app1 is stored successfully while app3 fails, app1 should be restored and app3 deleted
+	initUDRDrGetPfdDataStub()
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/app1").
+		Persist().
+		Reply(http.StatusCreated).
+		JSON(pfdDataForApp1)
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/app3").
+		Persist().
+		Reply(http.StatusInternalServerError).
+		JSON(models.ProblemDetails{Status: http.StatusInternalServerError})
+	initUDRDrDeletePfdDataStub()
+	defer gock.Off()
+
+	const prefix = "/nudr-dr/v1/application-data/pfds/"
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	gock.Observe(func(req *http.Request, _ gock.Mock) {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			mu.Lock()
+			requests = append(requests, req.Method+" "+req.URL.Path)
+			mu.Unlock()
+		}
+	})
+	defer gock.Observe(nil)
+
+	af := nefApp.Context().NewAf("af1")
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	rsp := nefApp.Processor().PostPFDManagementTransactions("af1", &models.PfdManagement{
+		PfdDatas: map[string]models.PfdData{
+			"app1": {
+				ExternalAppId: "app1",
+				Pfds:          map[string]models.Pfd{"pfd1": pfd1},
+			},
+			"app3": {
+				ExternalAppId: "app3",
+				Pfds:          map[string]models.Pfd{"pfd3": pfd3},
+			},
+		},
+	})
+	require.Equal(t, http.StatusInternalServerError, rsp.Status)
+	require.Equal(t, &map[string]models.PfdReport{
+		string(models.FailureCode_MALFUNCTION): {
+			ExternalAppIds: []string{"app3"},
+			FailureCode:    models.FailureCode_MALFUNCTION,
+		},
+	}, rsp.Body)
+
+	af.Mu.RLock()
+	require.Empty(t, af.PfdTrans)
+	af.Mu.RUnlock()
+
+	// The requests of each phase are sent concurrently: snapshot, store, then rollback
+	require.Len(t, requests, 6)
+	require.ElementsMatch(t, []string{"GET " + prefix + "app1", "GET " + prefix + "app3"}, requests[0:2])
+	require.ElementsMatch(t, []string{"PUT " + prefix + "app1", "PUT " + prefix + "app3"}, requests[2:4])
+	require.ElementsMatch(t, []string{"PUT " + prefix + "app1", "DELETE " + prefix + "app3"}, requests[4:6])
+}
+
+func TestPostPFDManagementTransactionsReportOrder(t *testing.T) {
+	initUDRDrPutPfdDataStub(http.StatusInternalServerError)
+	defer gock.Off()
+
+	af := nefApp.Context().NewAf("af1")
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	pfdMng := &models.PfdManagement{
+		PfdDatas: make(map[string]models.PfdData),
+	}
+	var appIDs []string
+	for i := 0; i < 2*PfdUdrConcurrency; i++ {
+		appID := fmt.Sprintf("app%02d", i)
+		appIDs = append(appIDs, appID)
+		pfdMng.PfdDatas[appID] = models.PfdData{
+			ExternalAppId: appID,
+			Pfds:          map[string]models.Pfd{"pfd1": pfd1},
+		}
+	}
+
+	// The failures of the concurrent UDR requests are reported in the order of application IDs
+	rsp := nefApp.Processor().PostPFDManagementTransactions("af1", pfdMng)
+	require.Equal(t, &HandlerResponse{
+		Status: http.StatusInternalServerError,
+		Body: &map[string]models.PfdReport{
+			string(models.FailureCode_MALFUNCTION): {
+				ExternalAppIds: appIDs,
+				FailureCode:    models.FailureCode_MALFUNCTION,
+			},
+		},
+	}, rsp)
+}
+
 func initNRFNfmStub() {
 	nrfRegisterInstanceRsp := models.NfProfile{
 		NfInstanceId: "nef-pfd-unit-testing",
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/notifier"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -28,15 +29,34 @@ func (p *Processor) GetIndividualApplicationPFD(appID string) *HandlerResponse {
 	return &HandlerResponse{rspCode, nil, rspBody}
 }
 
+func (p *Processor) GetPFDSubscriptions() *HandlerResponse {
+	logger.PFDFLog.Infof("GetPFDSubscriptions")
+
+	pfdSubs := p.Notifier().PfdChangeNotifier.GetPfdSubs()
+	rspBody := make([]models.PfdSubscription, 0, len(pfdSubs))
+	for i := range pfdSubs {
+		rspBody = append(rspBody, *convertPfdSubToPfdSubscription(&pfdSubs[i]))
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, rspBody}
+}
+
 func (p *Processor) PostPFDSubscriptions(pfdSubsc *models.PfdSubscription) *HandlerResponse {
 	logger.PFDFLog.Infof("PostPFDSubscriptions - appIDs: %v", pfdSubsc.ApplicationIds)
 
-	// TODO: Support SupportedFeatures
 	if len(pfdSubsc.NotifyUri) == 0 {
 		pd := openapi.ProblemDetailsDataNotFound("Absent of Notify URI")
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
+	suppFeat, err := negotiateSuppFeat(p.Config().ServiceSuppFeat(factory.ServiceNefPfd), pfdSubsc.SupportedFeatures)
+	if err != nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	pfdSubsc.SupportedFeatures = suppFeat
+
+	// Empty ApplicationIds subscribes to the PFD changes of all applications
 	subID := p.Notifier().PfdChangeNotifier.AddPfdSub(pfdSubsc)
 	hdrs := make(map[string][]string)
 	addLocationheader(hdrs, p.genPfdSubscriptionURI(subID))
@@ -44,6 +64,43 @@ func (p *Processor) PostPFDSubscriptions(pfdSubsc *models.PfdSubscription) *Hand
 	return &HandlerResponse{http.StatusCreated, hdrs, pfdSubsc}
 }
 
+func (p *Processor) GetIndividualPFDSubscription(subID string) *HandlerResponse {
+	logger.PFDFLog.Infof("GetIndividualPFDSubscription - subID[%s]", subID)
+
+	pfdSub, err := p.Notifier().PfdChangeNotifier.GetPfdSub(subID)
+	if err != nil {
+		pd := openapi.ProblemDetailsDataNotFound(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, convertPfdSubToPfdSubscription(&pfdSub)}
+}
+
+func (p *Processor) PutIndividualPFDSubscription(
+	subID string, pfdSubsc *models.PfdSubscription,
+) *HandlerResponse {
+	logger.PFDFLog.Infof("PutIndividualPFDSubscription - subID[%s], appIDs: %v", subID, pfdSubsc.ApplicationIds)
+
+	if len(pfdSubsc.NotifyUri) == 0 {
+		pd := openapi.ProblemDetailsDataNotFound("Absent of Notify URI")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	suppFeat, err := negotiateSuppFeat(p.Config().ServiceSuppFeat(factory.ServiceNefPfd), pfdSubsc.SupportedFeatures)
+	if err != nil {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	pfdSubsc.SupportedFeatures = suppFeat
+
+	if err = p.Notifier().PfdChangeNotifier.UpdatePfdSub(subID, pfdSubsc); err != nil {
+		pd := openapi.ProblemDetailsDataNotFound(err.Error())
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	return &HandlerResponse{http.StatusOK, nil, pfdSubsc}
+}
+
 func (p *Processor) DeleteIndividualPFDSubscription(subID string) *HandlerResponse {
 	logger.PFDFLog.Infof("DeleteIndividualPFDSubscription - subID[%s]", subID)
 
@@ -59,3 +116,28 @@ func (p *Processor) genPfdSubscriptionURI(subID string) string {
 	// E.g. "https://localhost:29505/nnef-pfdmanagement/v1/subscriptions/{subscriptionId}
 	return fmt.Sprintf("%s/subscriptions/%s", p.Config().ServiceUri(factory.ServiceNefPfd), subID)
 }
+
+// negotiateSuppFeat returns the features supported by both NEF and the consumer,
+// as specified in clause 6.6.2 of TS 29.500
+func negotiateSuppFeat(nefSuppFeat, suppFeat string) (string, error) {
+	if suppFeat == "" {
+		return "", nil
+	}
+	reqFeat, err := openapi.NewSupportedFeature(suppFeat)
+	if err != nil {
+		return "", fmt.Errorf("invalid supportedFeatures[%s]", suppFeat)
+	}
+	nefFeat, err := openapi.NewSupportedFeature(nefSuppFeat)
+	if err != nil {
+		return "", fmt.Errorf("invalid configured suppFeat[%s]", nefSuppFeat)
+	}
+	return nefFeat.NegotiateWith(reqFeat).String(), nil
+}
+
+func convertPfdSubToPfdSubscription(pfdSub *notifier.PfdSub) *models.PfdSubscription {
+	return &models.PfdSubscription{
+		ApplicationIds:    pfdSub.ApplicationIds,
+		NotifyUri:         pfdSub.NotifyUri,
+		SupportedFeatures: pfdSub.SupportedFeatures,
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi/models"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/h2non/gock.v1"
@@ -136,6 +138,149 @@ func TestDeleteIndividualPFDSubscription(t *testing.T) {
 	}
 }
 
+func TestPostPFDSubscriptionsSuppFeat(t *testing.T) {
+	serviceList := nefApp.Config().Configuration.ServiceList
+	nefApp.Config().Configuration.ServiceList = []factory.Service{
+		{
+			ServiceName: factory.ServiceNefPfd,
+			SuppFeat:    "3",
+		},
+	}
+	defer func() {
+		nefApp.Config().Configuration.ServiceList = serviceList
+	}()
+
+	testCases := []struct {
+		description      string
+		pfdSubsc         *models.PfdSubscription
+		expectedStatus   int
+		expectedSuppFeat string
+	}{
+		{
+			description: "TC1: Subscribe to all applications without supported features",
+			pfdSubsc: &models.PfdSubscription{
+				NotifyUri: "http://127.0.0.1:8000/notify",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			description: "TC2: Supported features are negotiated with the configured ones",
+			pfdSubsc: &models.PfdSubscription{
+				ApplicationIds:    []string{"app1"},
+				NotifyUri:         "http://127.0.0.1:8000/notify",
+				SupportedFeatures: "06",
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedSuppFeat: "02",
+		},
+		{
+			description: "TC3: Invalid supported features, should return ProblemDetails",
+			pfdSubsc: &models.PfdSubscription{
+				NotifyUri:         "http://127.0.0.1:8000/notify",
+				SupportedFeatures: "xyz",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostPFDSubscriptions(tc.pfdSubsc)
+			require.Equal(t, tc.expectedStatus, rsp.Status)
+			if tc.expectedStatus != http.StatusCreated {
+				return
+			}
+			require.Equal(t, tc.expectedSuppFeat, rsp.Body.(*models.PfdSubscription).SupportedFeatures)
+
+			subID := rsp.Headers["Location"][0][len(nefApp.Config().ServiceUri(factory.ServiceNefPfd)+"/subscriptions/"):]
+			require.Equal(t, http.StatusNoContent, nefApp.Processor().DeleteIndividualPFDSubscription(subID).Status)
+		})
+	}
+}
+
+func TestGetAndPutPFDSubscription(t *testing.T) {
+	rsp := nefApp.Processor().PostPFDSubscriptions(&models.PfdSubscription{
+		ApplicationIds: []string{"app1"},
+		NotifyUri:      "http://127.0.0.1:8000/notify",
+	})
+	require.Equal(t, http.StatusCreated, rsp.Status)
+	subID := rsp.Headers["Location"][0][len(nefApp.Config().ServiceUri(factory.ServiceNefPfd)+"/subscriptions/"):]
+	defer func() {
+		require.Equal(t, http.StatusNoContent, nefApp.Processor().DeleteIndividualPFDSubscription(subID).Status)
+	}()
+
+	rsp = nefApp.Processor().PutIndividualPFDSubscription(subID, &models.PfdSubscription{
+		ApplicationIds: []string{"app1", "app2"},
+		NotifyUri:      "http://127.0.0.1:8000/notify-new",
+	})
+	require.Equal(t, http.StatusOK, rsp.Status)
+
+	expected := &models.PfdSubscription{
+		ApplicationIds: []string{"app1", "app2"},
+		NotifyUri:      "http://127.0.0.1:8000/notify-new",
+	}
+	rsp = nefApp.Processor().GetIndividualPFDSubscription(subID)
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.Equal(t, expected, rsp.Body)
+
+	rsp = nefApp.Processor().GetPFDSubscriptions()
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.Contains(t, rsp.Body, *expected)
+
+	rsp = nefApp.Processor().PutIndividualPFDSubscription("999", expected)
+	require.Equal(t, http.StatusNotFound, rsp.Status)
+	rsp = nefApp.Processor().GetIndividualPFDSubscription("999")
+	require.Equal(t, http.StatusNotFound, rsp.Status)
+}
+
+func TestGetIndividualApplicationPFDFailover(t *testing.T) {
+	nefCtx := nefApp.Context()
+	srvName := models.ServiceName_NUDR_DR
+	origCands, _ := nefCtx.NfCandidates(srvName)
+	defer nefCtx.SetNfCandidates(srvName, origCands, 0)
+
+	udr1 := nef_context.NfCandidate{NfInstID: "udr1", Uri: "http://127.0.0.41:8000"}
+	udr2 := nef_context.NfCandidate{NfInstID: "udr2", Uri: "http://127.0.0.42:8000"}
+	nefCtx.SetNfCandidates(srvName, []nef_context.NfCandidate{udr1, udr2}, 0)
+
+	udr1Req := gock.New("http://127.0.0.41:8000/nudr-dr/v1").
+		Get("/application-data/pfds/app1")
+	udr1Req.Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable})
+	udr2Req := gock.New("http://127.0.0.42:8000/nudr-dr/v1").
+		Get("/application-data/pfds/app1").
+		Times(2)
+	udr2Req.Reply(http.StatusOK).
+		JSON(pfdDataForApp1)
+	defer gock.Off()
+
+	// udr1 is unavailable, fail over to udr2 and demote udr1
+	rsp := nefApp.Processor().GetIndividualApplicationPFD("app1")
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.Equal(t, &pfdDataForApp1, rsp.Body)
+	cands, valid := nefCtx.NfCandidates(srvName)
+	require.True(t, valid)
+	require.Equal(t, []nef_context.NfCandidate{udr2, udr1}, cands)
+
+	// udr2 is tried first
+	rsp = nefApp.Processor().GetIndividualApplicationPFD("app1")
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.True(t, udr1Req.Mock.Done())
+	require.True(t, udr2Req.Mock.Done())
+
+	// All the candidates are unavailable, they are discovered again by the next request
+	for _, udr := range []string{"http://127.0.0.41:8000", "http://127.0.0.42:8000"} {
+		gock.New(udr + "/nudr-dr/v1").
+			Get("/application-data/pfds/app1").
+			Reply(http.StatusServiceUnavailable).
+			JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable})
+	}
+	rsp = nefApp.Processor().GetIndividualApplicationPFD("app1")
+	require.Equal(t, http.StatusServiceUnavailable, rsp.Status)
+	_, valid = nefCtx.NfCandidates(srvName)
+	require.False(t, valid)
+}
+
 var (
 	// `notifChan` are used in `TestPostPfdChangeReports()` to pass the notification requests intercepted by gock.
 	notifChan   = make(chan *http.Request)
@@ -149,11 +294,11 @@ var (
 )
 
 func TestPostPfdChangeReports(t *testing.T) {
-	// Note: Because TestPostPFDSubscriptions() already used subscription ID 1, the ID will start from 2 here.
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	initUDRDrDeletePfdDataStub()
 	initNEFNotificationStub("http://pfdSub2URI")
 	initNEFNotificationStub("http://pfdSub3URI")
+	initNEFNotificationStub("http://pfdSub4URI")
 	defer gock.Off()
 	gock.Observe(func(request *http.Request, mock gock.Mock) {
 		if strings.Contains(request.URL.String(), "pfdSub") {
@@ -180,6 +325,10 @@ func TestPostPfdChangeReports(t *testing.T) {
 		ApplicationIds: []string{"app1", "app2"},
 		NotifyUri:      "http://pfdSub3URI",
 	})
+	// Subscription without applicationIds receives the changes of all applications
+	subsID3 := nefApp.Notifier().PfdChangeNotifier.AddPfdSub(&models.PfdSubscription{
+		NotifyUri: "http://pfdSub4URI",
+	})
 	defer func() {
 		if err := nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(subsID1); err != nil {
 			t.Fatal(err)
@@ -187,6 +336,9 @@ func TestPostPfdChangeReports(t *testing.T) {
 		if err := nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(subsID2); err != nil {
 			t.Fatal(err)
 		}
+		if err := nefApp.Notifier().PfdChangeNotifier.DeletePfdSub(subsID3); err != nil {
+			t.Fatal(err)
+		}
 	}()
 
 	testCases := []struct {
@@ -195,7 +347,7 @@ func TestPostPfdChangeReports(t *testing.T) {
 		expectedNotifications map[string][]models.PfdChangeNotification
 	}{
 		{
-			description: "Update app1, should send notification for subscription 2 and 3",
+			description: "Update app1, should send notification for subscription 2, 3 and 4",
 			triggerFunc: func() {
 				nefApp.Processor().PutIndividualApplicationPFDManagement("af1", "1", "app1", &models.PfdData{
 					ExternalAppId: "app1",
@@ -221,10 +373,18 @@ func TestPostPfdChangeReports(t *testing.T) {
 						},
 					},
 				},
+				"http://pfdSub4URI/notify": {
+					{
+						ApplicationId: "app1",
+						Pfds: []models.PfdContent{
+							pfdContent1,
+						},
+					},
+				},
 			},
 		},
 		{
-			description: "Delete app2, should send notification for subscription 3",
+			description: "Delete app2, should send notification for subscription 3 and 4",
 			triggerFunc: func() {
 				nefApp.Processor().DeleteIndividualApplicationPFDManagement("af1", "1", "app2")
 			},
@@ -235,6 +395,12 @@ func TestPostPfdChangeReports(t *testing.T) {
 						RemovalFlag:   true,
 					},
 				},
+				"http://pfdSub4URI/notify": {
+					{
+						ApplicationId: "app2",
+						RemovalFlag:   true,
+					},
+				},
 			},
 		},
 	}
@@ -1,10 +1,16 @@
 package processor
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/internal/util"
 	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
 )
 
 type nef interface {
@@ -12,6 +18,7 @@ type nef interface {
 	Config() *factory.Config
 	Consumer() *consumer.Consumer
 	Notifier() *notifier.Notifier
+	ReloadConfig() (*factory.ReloadResult, error)
 }
 
 type Processor struct {
@@ -40,3 +47,71 @@ func addLocationheader(header map[string][]string, location string) {
 		header["Location"] = append(locations, location)
 	}
 }
+
+// authorizeAfDnnSnssai checks whether the AF is allowed to influence the DNN and S-NSSAI
+func (p *Processor) authorizeAfDnnSnssai(afID, dnn string, snssai *models.Snssai) *HandlerResponse {
+	policy := p.Config().AfPolicy(afID)
+	if policy == nil {
+		// The AF has been authorized by SBI server if authorization is enabled
+		return nil
+	}
+
+	if !policy.IsDnnAllowed(dnn) {
+		pd := util.ProblemDetailsForbidden(fmt.Sprintf("AF[%s] is not allowed to influence DNN[%s]", afID, dnn))
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	if !policy.IsSnssaiAllowed(snssai) {
+		pd := util.ProblemDetailsForbidden(fmt.Sprintf("AF[%s] is not allowed to influence S-NSSAI[%d:%s]",
+			afID, snssai.Sst, snssai.Sd))
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+// authorizeAfExtAppIDs checks whether the AF is allowed to provision the external application IDs
+func (p *Processor) authorizeAfExtAppIDs(afID string, appIDs ...string) *HandlerResponse {
+	policy := p.Config().AfPolicy(afID)
+	if policy == nil {
+		return nil
+	}
+
+	for _, appID := range appIDs {
+		if !policy.IsExtAppIdAllowed(appID) {
+			pd := util.ProblemDetailsForbidden(fmt.Sprintf("AF[%s] is not allowed to provision appID[%s]", afID, appID))
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	}
+	return nil
+}
+
+// sortInvalidParams sorts the invalid parameters collected from maps, so that the response is stable
+func sortInvalidParams(invalidParams []models.InvalidParam) {
+	sort.SliceStable(invalidParams, func(i, j int) bool {
+		return invalidParams[i].Param < invalidParams[j].Param
+	})
+}
+
+// forEachConcurrently calls fn for each index in [0, n) with at most concurrency calls in flight,
+// and returns when all of them complete
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
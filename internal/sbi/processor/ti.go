@@ -2,8 +2,14 @@ package processor
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/internal/util"
+	"github.com/free5gc/nef/internal/validator"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -45,6 +51,17 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 	if rsp != nil {
 		return rsp
 	}
+	if rsp = p.validateGeoZoneIds(tiSub.ValidGeoZoneIds); rsp != nil {
+		return rsp
+	}
+	routes, rsp := p.fillTrafficRouteDnais(tiSub.TrafficRoutes)
+	if rsp != nil {
+		return rsp
+	}
+	tiSub.TrafficRoutes = routes
+	if rsp = p.authorizeAfDnnSnssai(afID, tiSub.Dnn, tiSub.Snssai); rsp != nil {
+		return rsp
+	}
 
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(afID)
@@ -68,16 +85,25 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 
 	if len(tiSub.Gpsi) > 0 || len(tiSub.Ipv4Addr) > 0 || len(tiSub.Ipv6Addr) > 0 {
 		// Single UE, sent to PCF
-		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody, appSessID := p.Consumer().PostAppSessions(asc)
+		supi, rsp := p.resolveSupi(tiSub.Gpsi)
+		if rsp != nil {
+			return rsp
+		}
+		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID, supi)
+		rspStatus, rspBody, appSessID, pcfUri := p.Consumer().PostAppSessions(p.getBoundPcfUri(tiSub), asc)
 		if rspStatus != http.StatusCreated {
 			return &HandlerResponse{rspStatus, nil, rspBody}
 		}
 		afSub.AppSessID = appSessID
+		afSub.PcfUri = pcfUri
 	} else if len(tiSub.ExternalGroupId) > 0 || tiSub.AnyUeInd {
 		// Group or any UE, sent to UDR
+		interGroupId, rsp := p.resolveInterGroupId(tiSub)
+		if rsp != nil {
+			return rsp
+		}
 		afSub.InfluID = uuid.New().String()
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
+		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID, interGroupId)
 		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusCreated &&
@@ -92,6 +118,7 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 
 	af.Subs[afSub.SubID] = afSub
 	af.Log.Infoln("Subscription is added")
+	p.scheduleTiSubExpiry(afID, afSub)
 
 	nefCtx.AddAf(af)
 
@@ -136,6 +163,17 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 	if rsp != nil {
 		return rsp
 	}
+	if rsp = p.validateGeoZoneIds(tiSub.ValidGeoZoneIds); rsp != nil {
+		return rsp
+	}
+	routes, rsp := p.fillTrafficRouteDnais(tiSub.TrafficRoutes)
+	if rsp != nil {
+		return rsp
+	}
+	tiSub.TrafficRoutes = routes
+	if rsp = p.authorizeAfDnnSnssai(afID, tiSub.Dnn, tiSub.Snssai); rsp != nil {
+		return rsp
+	}
 
 	af := p.Context().GetAf(afID)
 	if af == nil {
@@ -145,6 +183,7 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
 
 	afSub, ok := af.Subs[subID]
 	if !ok {
@@ -152,16 +191,40 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return &HandlerResponse{http.StatusNotFound, nil, pd}
 	}
 
-	afSub.TiSub = tiSub
 	if afSub.AppSessID != "" {
-		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody, appSessID := p.Consumer().PostAppSessions(asc)
+		supi, rsp := p.resolveSupi(tiSub.Gpsi)
+		if rsp != nil {
+			return rsp
+		}
+		// The app session is replaced, since the UE may be changed. The new one is created first,
+		// so the subscription keeps the old one if it fails. The old one is then deleted from
+		// the PCF holding it, which may not be the PCF of the new one.
+		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID, supi)
+		rspStatus, rspBody, appSessID, pcfUri := p.Consumer().PostAppSessions(p.getBoundPcfUri(tiSub), asc)
 		if rspStatus != http.StatusCreated {
 			return &HandlerResponse{rspStatus, nil, rspBody}
 		}
+		rspStatus, rspBody = p.Consumer().DeleteAppSession(afSub.PcfUri, afSub.AppSessID)
+		if rspStatus != http.StatusOK &&
+			rspStatus != http.StatusNoContent &&
+			rspStatus != http.StatusNotFound {
+			// Roll back, the old app session is still in effect
+			afSub.Log.Warnf("Delete AppSession[%s] failed: status[%d], delete the new AppSession[%s]",
+				afSub.AppSessID, rspStatus, appSessID)
+			if delStatus, _ := p.Consumer().DeleteAppSession(pcfUri, appSessID); delStatus != http.StatusOK &&
+				delStatus != http.StatusNoContent {
+				afSub.Log.Errorf("Delete AppSession[%s] failed: status[%d]", appSessID, delStatus)
+			}
+			return &HandlerResponse{rspStatus, nil, rspBody}
+		}
 		afSub.AppSessID = appSessID
+		afSub.PcfUri = pcfUri
 	} else if afSub.InfluID != "" {
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
+		interGroupId, rsp := p.resolveInterGroupId(tiSub)
+		if rsp != nil {
+			return rsp
+		}
+		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID, interGroupId)
 		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusCreated &&
@@ -173,6 +236,8 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 
+	afSub.TiSub = tiSub
+	p.scheduleTiSubExpiry(afID, afSub)
 	return &HandlerResponse{http.StatusOK, nil, afSub.TiSub}
 }
 
@@ -182,6 +247,21 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 ) *HandlerResponse {
 	logger.TrafInfluLog.Infof("PatchIndividualTrafficInfluenceSubscription - afID[%s], subID[%s]", afID, subID)
 
+	if rsp := validateTrafficFilters(tiSubPatch.TrafficFilters); rsp != nil {
+		return rsp
+	}
+	if rsp := validateTempValidities(tiSubPatch.TempValidities); rsp != nil {
+		return rsp
+	}
+	if rsp := p.validateGeoZoneIds(tiSubPatch.ValidGeoZoneIds); rsp != nil {
+		return rsp
+	}
+	routes, rsp := p.fillTrafficRouteDnais(tiSubPatch.TrafficRoutes)
+	if rsp != nil {
+		return rsp
+	}
+	tiSubPatch.TrafficRoutes = routes
+
 	af := p.Context().GetAf(afID)
 	if af == nil {
 		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
@@ -190,6 +270,7 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
 
 	afSub, ok := af.Subs[subID]
 	if !ok {
@@ -199,7 +280,7 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 
 	if afSub.AppSessID != "" {
 		ascUpdateData := p.convertTrafficInfluSubPatchToAppSessionContextUpdateData(tiSubPatch)
-		rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.AppSessID, ascUpdateData)
+		rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
 			return &HandlerResponse{rspStatus, nil, rspBody}
@@ -217,6 +298,7 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 	}
 
 	afSub.PatchTiSubData(tiSubPatch)
+	p.scheduleTiSubExpiry(afID, afSub)
 	return &HandlerResponse{http.StatusOK, nil, afSub.TiSub}
 }
 
@@ -233,6 +315,7 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
+	defer p.Context().SaveAf(af)
 
 	sub, ok := af.Subs[subID]
 	if !ok {
@@ -241,7 +324,7 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 	}
 
 	if sub.AppSessID != "" {
-		rspStatus, rspBody := p.Consumer().DeleteAppSession(sub.AppSessID)
+		rspStatus, rspBody := p.Consumer().DeleteAppSession(sub.PcfUri, sub.AppSessID)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
 			return &HandlerResponse{rspStatus, nil, rspBody}
@@ -254,9 +337,28 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 		}
 	}
 	delete(af.Subs, subID)
+	p.cancelTiSubExpiry(afID, subID)
 	return &HandlerResponse{http.StatusNoContent, nil, nil}
 }
 
+// getBoundPcfUri returns the PCF holding the PDU session of the UE from the PCF binding in BSF,
+// or empty to create the app session in a discovered PCF if no binding is found
+func (p *Processor) getBoundPcfUri(tiSub *models_nef.TrafficInfluSub) string {
+	rspStatus, rspBody := p.Consumer().GetPcfBinding(
+		tiSub.Ipv4Addr, tiSub.Ipv6Addr, tiSub.Gpsi, tiSub.Dnn, tiSub.Snssai)
+	if rspStatus != http.StatusOK {
+		logger.TrafInfluLog.Infof("No PCF binding in BSF: status[%d], use a discovered PCF", rspStatus)
+		return ""
+	}
+	pcfUri := p.Consumer().PcfBindingUri(rspBody.(*models.PcfBinding))
+	if pcfUri == "" {
+		logger.TrafInfluLog.Warnf("No address of the bound PCF, use a discovered PCF")
+		return ""
+	}
+	logger.TrafInfluLog.Infof("PDU session of the UE is bound to PCF[%s]", pcfUri)
+	return pcfUri
+}
+
 func validateTrafficInfluenceData(
 	tiSub *models_nef.TrafficInfluSub,
 ) *HandlerResponse {
@@ -284,9 +386,210 @@ func validateTrafficInfluenceData(
 				"Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd")
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
+
+	if rsp := validateTrafficFilters(tiSub.TrafficFilters); rsp != nil {
+		return rsp
+	}
+	return validateTempValidities(tiSub.TempValidities)
+}
+
+// validateTrafficFilters checks the flow descriptions of trafficFilters in the same way as PFDs
+func validateTrafficFilters(trafficFilters []models.FlowInfo) *HandlerResponse {
+	var invalidParams []models.InvalidParam
+	for i := range trafficFilters {
+		invalidParams = append(invalidParams,
+			validator.FlowInfo(validator.JoinPointer("/trafficFilters", strconv.Itoa(i)), &trafficFilters[i])...)
+	}
+	if len(invalidParams) > 0 {
+		pd := util.ProblemDetailsInvalidParams("Invalid trafficFilters", invalidParams)
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
 	return nil
 }
 
+// validateTempValidities checks that each temporal validity stops after it starts,
+// and that the subscription doesn't end before it's created
+func validateTempValidities(tempVals []models.TemporalValidity) *HandlerResponse {
+	var invalidParams []models.InvalidParam
+	for i, tempVal := range tempVals {
+		if tempVal.StartTime != nil && tempVal.StopTime != nil && !tempVal.StopTime.After(*tempVal.StartTime) {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  validator.JoinPointer("/tempValidities", strconv.Itoa(i), "stopTime"),
+				Reason: "stopTime is not later than startTime",
+			})
+		}
+	}
+	if len(invalidParams) == 0 {
+		if _, end := tempValidityWindow(tempVals); end != nil && !end.After(time.Now()) {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  "/tempValidities",
+				Reason: "All temporal validities have ended",
+			})
+		}
+	}
+	if len(invalidParams) > 0 {
+		pd := util.ProblemDetailsInvalidParams("Invalid tempValidities", invalidParams)
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+// validateGeoZoneIds checks that the geographic zones are in the configured catalog
+func (p *Processor) validateGeoZoneIds(zoneIDs []string) *HandlerResponse {
+	var invalidParams []models.InvalidParam
+	for i, zoneID := range zoneIDs {
+		if p.Config().GeoZone(zoneID) == nil {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  validator.JoinPointer("/validGeoZoneIds", strconv.Itoa(i)),
+				Reason: "Unknown geographic zone " + zoneID,
+			})
+		}
+	}
+	if len(invalidParams) > 0 {
+		pd := util.ProblemDetailsInvalidParams("Invalid validGeoZoneIds", invalidParams)
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+// geoZonesToSpatialValidity maps the geographic zones to the presence reporting areas for PCF,
+// one area per zone identified by the zone ID. It returns nil if no zone is given.
+func (p *Processor) geoZonesToSpatialValidity(zoneIDs []string) *models.SpatialValidity {
+	if len(zoneIDs) == 0 {
+		return nil
+	}
+
+	spVal := &models.SpatialValidity{
+		PresenceInfoList: make(map[string]models.PresenceInfo),
+	}
+	for _, zoneID := range zoneIDs {
+		zone := p.Config().GeoZone(zoneID)
+		if zone == nil {
+			// Removed from the config after the request was validated
+			continue
+		}
+		spVal.PresenceInfoList[zoneID] = models.PresenceInfo{
+			PraId:            zoneID,
+			TrackingAreaList: zone.Tais,
+			NcgiList:         zone.Ncgis,
+		}
+	}
+	return spVal
+}
+
+// geoZonesToNetworkAreaInfo merges the tracking areas and NR cells of the geographic zones for UDR.
+// It returns nil if no zone is given.
+func (p *Processor) geoZonesToNetworkAreaInfo(zoneIDs []string) *models.NetworkAreaInfo {
+	if len(zoneIDs) == 0 {
+		return nil
+	}
+
+	nwAreaInfo := &models.NetworkAreaInfo{}
+	for _, zoneID := range zoneIDs {
+		zone := p.Config().GeoZone(zoneID)
+		if zone == nil {
+			continue
+		}
+		nwAreaInfo.Tais = append(nwAreaInfo.Tais, zone.Tais...)
+		nwAreaInfo.Ncgis = append(nwAreaInfo.Ncgis, zone.Ncgis...)
+	}
+	return nwAreaInfo
+}
+
+// tempValidityWindow returns the overall window covering the temporal validities.
+// A nil start means the request applies from now on, and a nil end means it never ends.
+func tempValidityWindow(tempVals []models.TemporalValidity) (start, end *time.Time) {
+	for i, tempVal := range tempVals {
+		if i == 0 {
+			start, end = tempVal.StartTime, tempVal.StopTime
+			continue
+		}
+		if start != nil && (tempVal.StartTime == nil || tempVal.StartTime.Before(*start)) {
+			start = tempVal.StartTime
+		}
+		if end != nil && (tempVal.StopTime == nil || tempVal.StopTime.After(*end)) {
+			end = tempVal.StopTime
+		}
+	}
+	return start, end
+}
+
+// ScheduleTrafficInfluenceExpiry schedules the expiry of the restored traffic influence subscriptions.
+// The ones whose temporal validities ended while NEF was down are removed immediately.
+func (p *Processor) ScheduleTrafficInfluenceExpiry() {
+	for _, af := range p.Context().GetAfs() {
+		af.Mu.RLock()
+		for _, sub := range af.Subs {
+			p.scheduleTiSubExpiry(af.AfID, sub)
+		}
+		af.Mu.RUnlock()
+	}
+}
+
+// scheduleTiSubExpiry schedules the removal of the subscription at the end of its temporal validities,
+// or cancels the scheduled one if the subscription never ends. The caller should hold af.Mu.
+func (p *Processor) scheduleTiSubExpiry(afID string, afSub *nef_context.AfSubscription) {
+	var end *time.Time
+	if afSub.TiSub != nil {
+		_, end = tempValidityWindow(afSub.TiSub.TempValidities)
+	}
+	if end == nil {
+		p.cancelTiSubExpiry(afID, afSub.SubID)
+		return
+	}
+
+	subID := afSub.SubID
+	p.Notifier().ValidityScheduler.Schedule(tiSubExpiryKey(afID, subID), *end, func() {
+		p.expireTrafficInfluenceSubscription(afID, subID)
+	})
+	afSub.Log.Infof("Subscription expires at %s", end.Format(time.RFC3339))
+}
+
+func (p *Processor) cancelTiSubExpiry(afID, subID string) {
+	p.Notifier().ValidityScheduler.Cancel(tiSubExpiryKey(afID, subID))
+}
+
+func tiSubExpiryKey(afID, subID string) string {
+	return "ti/" + afID + "/" + subID
+}
+
+// expireTrafficInfluenceSubscription removes the subscription together with its resources
+// in PCF or UDR when its temporal validities end, and notifies AF
+func (p *Processor) expireTrafficInfluenceSubscription(afID, subID string) {
+	af := p.Context().GetAf(afID)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	afSub, ok := af.Subs[subID]
+	if !ok || afSub.TiSub == nil {
+		return
+	}
+	_, end := tempValidityWindow(afSub.TiSub.TempValidities)
+	if end == nil || time.Now().Before(*end) {
+		// The temporal validities were updated after the expiry was scheduled
+		return
+	}
+
+	afSub.Log.Infof("Temporal validities ended at %s", end.Format(time.RFC3339))
+	p.forceDeleteAfSub(af, subID)
+	p.Context().SaveAf(af)
+
+	if afSub.TiSub.NotificationDestination == "" {
+		return
+	}
+	expiryNotif := &nef_models.TrafficInfluExpiryNotification{
+		AfTransId:    afSub.TiSub.AfTransId,
+		Subscription: p.genTrafficInfluSubURI(afID, subID),
+		ExpiryTime:   end,
+	}
+	afSub.Log.Infof("Notify AF the expiry of subscription")
+	p.Notifier().AfNotifier.Notify(afSub.TiSub.NotificationDestination, expiryNotif, afSub.Log)
+}
+
 func (p *Processor) genTrafficInfluSubURI(
 	afID, subscriptionId string,
 ) string {
@@ -298,9 +601,46 @@ func (p *Processor) genNotificationUri() string {
 	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/smf"
 }
 
+func (p *Processor) genAfAckUri(notifCorreID string) string {
+	// E.g. https://localhost:29505/nnef-callback/v1/notification/af-ack/{notifCorreId}
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/af-ack/" + notifCorreID
+}
+
+// resolveSupi translates GPSI to SUPI via UDM. An empty GPSI results in an empty SUPI.
+func (p *Processor) resolveSupi(gpsi string) (string, *HandlerResponse) {
+	if gpsi == "" {
+		return "", nil
+	}
+
+	rspStatus, rspBody := p.Consumer().SdmGetIdTranslationResult(gpsi)
+	if rspStatus != http.StatusOK {
+		return "", &HandlerResponse{rspStatus, nil, rspBody}
+	}
+	return rspBody.(*models.IdTranslationResult).Supi, nil
+}
+
+// resolveInterGroupId translates External Group ID to internal group ID via UDM
+func (p *Processor) resolveInterGroupId(tiSub *models_nef.TrafficInfluSub) (string, *HandlerResponse) {
+	if tiSub.AnyUeInd {
+		return "AnyUE", nil
+	}
+
+	rspStatus, rspBody := p.Consumer().SdmGetGroupIdentifiers(tiSub.ExternalGroupId)
+	if rspStatus != http.StatusOK {
+		return "", &HandlerResponse{rspStatus, nil, rspBody}
+	}
+	groupIds := rspBody.(*nef_models.GroupIdentifiers)
+	if groupIds.IntGroupId == "" {
+		pd := openapi.ProblemDetailsDataNotFound("Internal group ID is not found")
+		return "", &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return groupIds.IntGroupId, nil
+}
+
 func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 	tiSub *models_nef.TrafficInfluSub,
 	notifCorreID string,
+	supi string,
 ) *models.AppSessionContext {
 	asc := &models.AppSessionContext{
 		AscReqData: &models.AppSessionContextReqData{
@@ -308,6 +648,7 @@ func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 			AfRoutReq: &models.AfRoutingRequirement{
 				AppReloc:    tiSub.AppReloInd,
 				RouteToLocs: tiSub.TrafficRoutes,
+				SpVal:       p.geoZonesToSpatialValidity(tiSub.ValidGeoZoneIds),
 				TempVals:    tiSub.TempValidities,
 			},
 			UeIpv4:    tiSub.Ipv4Addr,
@@ -317,7 +658,8 @@ func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 			SuppFeat:  tiSub.SuppFeat,
 			Dnn:       tiSub.Dnn,
 			SliceInfo: tiSub.Snssai,
-			// Supi: ,
+			Supi:      supi,
+			Gpsi:      tiSub.Gpsi,
 		},
 	}
 
@@ -341,17 +683,22 @@ func (p *Processor) convertTrafficInfluSubPatchToAppSessionContextUpdateData(
 			TempVals:    tiSubPatch.TempValidities,
 		},
 	}
+	if spVal := p.geoZonesToSpatialValidity(tiSubPatch.ValidGeoZoneIds); spVal != nil {
+		ascUpdate.AfRoutReq.SpVal = (*models.SpatialValidityRm)(spVal)
+	}
 	return ascUpdate
 }
 
 func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
 	tiSub *models_nef.TrafficInfluSub,
 	notifCorreID string,
+	interGroupId string,
 ) *models.TrafficInfluData {
+	validStart, validEnd := tempValidityWindow(tiSub.TempValidities)
 	tiData := &models.TrafficInfluData{
-		AfAppId:    tiSub.AfAppId,
-		AppReloInd: tiSub.AppReloInd,
-		// Supi: ,
+		AfAppId:               tiSub.AfAppId,
+		AppReloInd:            tiSub.AppReloInd,
+		InterGroupId:          interGroupId,
 		DnaiChgType:           tiSub.DnaiChgType,
 		UpPathChgNotifUri:     p.genNotificationUri(),
 		UpPathChgNotifCorreId: notifCorreID,
@@ -361,17 +708,13 @@ func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
 		TrafficFilters:        tiSub.TrafficFilters,
 		TrafficRoutes:         tiSub.TrafficRoutes,
 		TraffCorreInd:         tiSub.TfcCorrInd,
-		// ValidStartTime: ,
-		// ValidEndTime: ,
-		TempValidities:    tiSub.TempValidities,
-		AfAckInd:          tiSub.AfAckInd,
-		AddrPreserInd:     tiSub.AddrPreserInd,
-		SupportedFeatures: tiSub.SuppFeat,
-	}
-
-	// TODO: handle ExternalGroupId
-	if tiSub.AnyUeInd {
-		tiData.InterGroupId = "AnyUE"
+		ValidStartTime:        validStart,
+		ValidEndTime:          validEnd,
+		TempValidities:        tiSub.TempValidities,
+		NwAreaInfo:            p.geoZonesToNetworkAreaInfo(tiSub.ValidGeoZoneIds),
+		AfAckInd:              tiSub.AfAckInd,
+		AddrPreserInd:         tiSub.AddrPreserInd,
+		SupportedFeatures:     tiSub.SuppFeat,
 	}
 
 	return tiData
@@ -385,6 +728,8 @@ func (p *Processor) convertTrafficInfluSubPatchToTrafficInfluDataPatch(
 		EthTrafficFilters: tiSubPatch.EthTrafficFilters,
 		TrafficFilters:    tiSubPatch.TrafficFilters,
 		TrafficRoutes:     tiSubPatch.TrafficRoutes,
+		NwAreaInfo:        p.geoZonesToNetworkAreaInfo(tiSubPatch.ValidGeoZoneIds),
 	}
+	tiDataPatch.ValidStartTime, tiDataPatch.ValidEndTime = tempValidityWindow(tiSubPatch.TempValidities)
 	return tiDataPatch
 }
@@ -3,7 +3,12 @@ package processor
 import (
 	"net/http"
 	"testing"
+	"time"
 
+	nef_context "github.com/free5gc/nef/internal/context"
+	nef_models "github.com/free5gc/nef/internal/models"
+	"github.com/free5gc/nef/internal/util"
+	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/models_nef"
 	"github.com/google/uuid"
@@ -348,6 +353,280 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 	nefCtx.ResetCorreID()
 }
 
+func TestPostTrafficInfluenceSubscriptionWithUdmTranslation(t *testing.T) {
+	initNRFDiscPCFStub()
+	initNRFDiscUDMStub("nudm-sdm")
+	initUDMSdmGetIdTranslationResultStub()
+	initUDMSdmGetGroupIdentifiersStub()
+	initUDRDrPutTiDataStubWithBody(`.*"interGroupId":"group-internal-1".*`)
+	initPCFPaPostAppSessionsStubWithBody(`.*"supi":"imsi-208930000000001".*`)
+	defer gock.Off()
+
+	tiSubGroup := tiSub1ForAf1
+	tiSubGroup.AnyUeInd = false
+	tiSubGroup.ExternalGroupId = "group1@example.com"
+	rspTiSubGroup := tiSubGroup
+	rspTiSubGroup.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "1")
+
+	tiSubGpsi := tiSub3ForAf1
+	tiSubGpsi.Gpsi = "msisdn-0900000001"
+	rspTiSubGpsi := tiSubGpsi
+	rspTiSubGpsi.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "2")
+
+	testCases := []struct {
+		description      string
+		afID             string
+		tiSub            *models_nef.TrafficInfluSub
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: External group subscription, should put tiData with internal group ID to UDR",
+			afID:        "af1",
+			tiSub:       &tiSubGroup,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSubGroup.Self},
+				},
+				Body: &rspTiSubGroup,
+			},
+		},
+		{
+			description: "TC2: GPSI subscription, should post AppSession with SUPI to PCF",
+			afID:        "af1",
+			tiSub:       &tiSubGpsi,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSubGpsi.Self},
+				},
+				Body: &rspTiSubGpsi,
+			},
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostTrafficInfluenceSubscription(tc.afID, tc.tiSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestPostTrafficInfluenceSubscriptionWithAfPolicy(t *testing.T) {
+	cfg := nefApp.Config()
+	cfg.Configuration.Afs = []factory.AfPolicy{
+		{
+			AfId: "af1",
+			Dnns: []string{"internet"},
+			Snssais: []models.Snssai{
+				{Sst: 1, Sd: "112233"},
+			},
+		},
+	}
+	defer func() {
+		cfg.Configuration.Afs = nil
+	}()
+
+	tiSubDnn := tiSub1ForAf1
+	tiSubDnn.Dnn = "ims"
+
+	testCases := []struct {
+		description      string
+		afID             string
+		tiSub            *models_nef.TrafficInfluSub
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: DNN is not allowed, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubDnn,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusForbidden,
+				Body:   util.ProblemDetailsForbidden("AF[af1] is not allowed to influence DNN[ims]"),
+			},
+		},
+		{
+			description: "TC2: S-NSSAI is not allowed, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSub1ForAf1,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusForbidden,
+				Body:   util.ProblemDetailsForbidden("AF[af1] is not allowed to influence S-NSSAI[1:010203]"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostTrafficInfluenceSubscription(tc.afID, tc.tiSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+}
+
+func TestPostTrafficInfluenceSubscriptionWithGeoZones(t *testing.T) {
+	initNRFDiscPCFStub()
+	initUDRDrPutTiDataStubWithBody(
+		`.*"nwAreaInfo":\{"ncgis":\[\{"plmnId":\{"mcc":"208","mnc":"93"\},"nrCellId":"000000010"\}\],` +
+			`"tais":\[\{"plmnId":\{"mcc":"208","mnc":"93"\},"tac":"000001"\}\]\}.*`)
+	initPCFPaPostAppSessionsStubWithBody(`.*"spVal":\{"presenceInfoList":\{"zone1":\{"praId":"zone1",` +
+		`"trackingAreaList":\[\{"plmnId":\{"mcc":"208","mnc":"93"\},"tac":"000001"\}\].*`)
+	defer gock.Off()
+
+	cfg := nefApp.Config()
+	cfg.Configuration.GeoZones = []factory.GeoZone{
+		{
+			ZoneId: "zone1",
+			Tais: []models.Tai{
+				{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000001"},
+			},
+			Ncgis: []models.Ncgi{
+				{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, NrCellId: "000000010"},
+			},
+		},
+	}
+	defer func() {
+		cfg.Configuration.GeoZones = nil
+	}()
+
+	tiSubUnknown := tiSub1ForAf1
+	tiSubUnknown.ValidGeoZoneIds = []string{"zone1", "zone2"}
+
+	tiSubAnyUe := tiSub1ForAf1
+	tiSubAnyUe.ValidGeoZoneIds = []string{"zone1"}
+	rspTiSubAnyUe := tiSubAnyUe
+	rspTiSubAnyUe.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "1")
+
+	tiSubUe := tiSub3ForAf1
+	tiSubUe.ValidGeoZoneIds = []string{"zone1"}
+	rspTiSubUe := tiSubUe
+	rspTiSubUe.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "2")
+
+	testCases := []struct {
+		description      string
+		afID             string
+		tiSub            *models_nef.TrafficInfluSub
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: Unknown geographic zone, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubUnknown,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: util.ProblemDetailsInvalidParams("Invalid validGeoZoneIds", []models.InvalidParam{
+					{Param: "/validGeoZoneIds/1", Reason: "Unknown geographic zone zone2"},
+				}),
+			},
+		},
+		{
+			description: "TC2: AnyUE subscription, should put tiData with network area to UDR",
+			afID:        "af1",
+			tiSub:       &tiSubAnyUe,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSubAnyUe.Self},
+				},
+				Body: &rspTiSubAnyUe,
+			},
+		},
+		{
+			description: "TC3: UEIPv4 subscription, should post AppSession with spatial validity to PCF",
+			afID:        "af1",
+			tiSub:       &tiSubUe,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSubUe.Self},
+				},
+				Body: &rspTiSubUe,
+			},
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostTrafficInfluenceSubscription(tc.afID, tc.tiSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
+func TestPostTrafficInfluenceSubscriptionWithDnaiMapping(t *testing.T) {
+	initUDRDrPutTiDataStubWithBody(`.*"trafficRoutes":\[\{"dnai":"mec2","routeInfo":\{"ipv4Addr":"10.60.1.1".*`)
+	defer gock.Off()
+
+	cfg := nefApp.Config()
+	cfg.Configuration.DnaiMappings = testDnaiMappings
+	defer func() {
+		cfg.Configuration.DnaiMappings = nil
+	}()
+
+	tiSubUnmapped := tiSub1ForAf1
+	tiSubUnmapped.TrafficRoutes = []models.RouteToLocation{
+		{RouteInfo: &models.RouteInformation{Ipv4Addr: "192.168.0.1"}},
+	}
+
+	tiSubMapped := tiSub1ForAf1
+	tiSubMapped.TrafficRoutes = []models.RouteToLocation{
+		{RouteInfo: &models.RouteInformation{Ipv4Addr: "10.60.1.1"}},
+	}
+	rspTiSubMapped := tiSubMapped
+	rspTiSubMapped.TrafficRoutes = []models.RouteToLocation{
+		{Dnai: "mec2", RouteInfo: &models.RouteInformation{Ipv4Addr: "10.60.1.1"}},
+	}
+	rspTiSubMapped.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "1")
+
+	testCases := []struct {
+		description      string
+		afID             string
+		tiSub            *models_nef.TrafficInfluSub
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description: "TC1: No DNAI is mapped to the route, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubUnmapped,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: util.ProblemDetailsInvalidParams("Invalid trafficRoutes", []models.InvalidParam{
+					{Param: "/trafficRoutes/0/dnai", Reason: "No DNAI is mapped to the routeInfo"},
+				}),
+			},
+		},
+		{
+			description: "TC2: Route without DNAI, should put tiData with the mapped DNAI to UDR",
+			afID:        "af1",
+			tiSub:       &tiSubMapped,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSubMapped.Self},
+				},
+				Body: &rspTiSubMapped,
+			},
+		},
+	}
+
+	nefCtx := nefApp.Context()
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rsp := nefApp.Processor().PostTrafficInfluenceSubscription(tc.afID, tc.tiSub)
+			require.Equal(t, tc.expectedResponse, rsp)
+		})
+	}
+	nefCtx.DeleteAf("af1")
+	nefCtx.ResetCorreID()
+}
+
 func TestDeleteIndividualTrafficInfluenceSubscription(t *testing.T) {
 	initNRFDiscPCFStub()
 	initUDRDrDeleteTiDataStub(http.StatusNoContent)
@@ -502,6 +781,8 @@ func TestPatchIndividualTrafficInfluenceSubscription(t *testing.T) {
 func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 	initNRFDiscPCFStub()
 	initUDRDrPutTiDataStub(http.StatusNoContent)
+	// Registered before the stub of post app session, which also matches the path of delete
+	initPCFPaDeleteAppSessionsStub(http.StatusNoContent)
 	initPCFPaPostAppSessionsStub(http.StatusCreated)
 	defer gock.Off()
 
@@ -602,6 +883,297 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 	nefCtx.ResetCorreID()
 }
 
+func TestTrafficInfluenceSubscriptionWithPcfBinding(t *testing.T) {
+	initNRFDiscBSFStub()
+	gock.New("http://127.0.0.16:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("ipv4Addr", tiSub3ForAf1.Ipv4Addr).
+		MatchParam("dnn", tiSub3ForAf1.Dnn).
+		Reply(http.StatusOK).
+		JSON(models.PcfBinding{
+			Ipv4Addr: tiSub3ForAf1.Ipv4Addr,
+			Dnn:      tiSub3ForAf1.Dnn,
+			Snssai:   tiSub3ForAf1.Snssai,
+			PcfIpEndPoints: []models.IpEndPoint{
+				{Ipv4Address: "127.0.0.17", Port: 8000},
+			},
+		})
+	gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.17:8000/npcf-policyauthorization/v1/app-sessions/12345").
+		JSON(models.AppSessionContext{})
+	gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Patch("/app-sessions/12345").
+		Reply(http.StatusNoContent)
+	gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/12345/delete").
+		Reply(http.StatusNoContent)
+	defer gock.Off()
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.SetNfCandidates(models.ServiceName_NBSF_MANAGEMENT, nil, 0)
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	// The app session is sent to the bound PCF, and its URI is kept for the following requests
+	tiSub := tiSub3ForAf1
+	rsp := nefApp.Processor().PostTrafficInfluenceSubscription("af1", &tiSub)
+	require.Equal(t, http.StatusCreated, rsp.Status)
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	afSub, ok := af.Subs["1"]
+	require.True(t, ok)
+	require.Equal(t, "12345", afSub.AppSessID)
+	require.Equal(t, "http://127.0.0.17:8000", afSub.PcfUri)
+
+	rsp = nefApp.Processor().PatchIndividualTrafficInfluenceSubscription("af1", "1",
+		&models_nef.TrafficInfluSubPatch{TrafficRoutes: tiSub3ForAf1.TrafficRoutes})
+	require.Equal(t, http.StatusOK, rsp.Status)
+
+	rsp = nefApp.Processor().DeleteIndividualTrafficInfluenceSubscription("af1", "1")
+	require.Equal(t, http.StatusNoContent, rsp.Status)
+}
+
+func TestTrafficInfluenceSubscriptionPcfOwner(t *testing.T) {
+	nefCtx := nefApp.Context()
+	srvName := models.ServiceName_NPCF_POLICYAUTHORIZATION
+	pcf1 := nef_context.NfCandidate{NfInstID: "pcf1", Uri: "http://127.0.0.18:8000"}
+	pcf2 := nef_context.NfCandidate{NfInstID: "pcf2", Uri: "http://127.0.0.19:8000"}
+	nefCtx.SetNfCandidates(srvName, []nef_context.NfCandidate{pcf1, pcf2}, 0)
+	defer func() {
+		nefCtx.SetNfCandidates(srvName, nil, 0)
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+	defer gock.Off()
+
+	// No PCF binding, pcf1 is unavailable and the app session is created in pcf2
+	gock.New("http://127.0.0.18:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions$").
+		Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable})
+	gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions$").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.19:8000/npcf-policyauthorization/v1/app-sessions/12345").
+		JSON(models.AppSessionContext{})
+
+	tiSub := tiSub3ForAf1
+	rsp := nefApp.Processor().PostTrafficInfluenceSubscription("af1", &tiSub)
+	require.Equal(t, http.StatusCreated, rsp.Status)
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	afSub, ok := af.Subs["1"]
+	require.True(t, ok)
+	require.Equal(t, "http://127.0.0.19:8000", afSub.PcfUri)
+
+	// The app session is only modified in pcf2, which doesn't fail over to pcf1
+	pcf1Patch := gock.New("http://127.0.0.18:8000").
+		Patch("/npcf-policyauthorization/v1/app-sessions/12345")
+	pcf1Patch.Reply(http.StatusNoContent)
+	gock.New("http://127.0.0.19:8000").
+		Patch("/npcf-policyauthorization/v1/app-sessions/12345").
+		Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable})
+	rsp = nefApp.Processor().PatchIndividualTrafficInfluenceSubscription("af1", "1",
+		&models_nef.TrafficInfluSubPatch{TrafficRoutes: tiSub3ForAf1.TrafficRoutes})
+	require.Equal(t, http.StatusServiceUnavailable, rsp.Status)
+	require.False(t, pcf1Patch.Mock.Done())
+	gock.Off()
+
+	// Put creates the new app session before deleting the old one in pcf2
+	pcf2Delete := gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions/12345/delete")
+	pcf2Delete.Reply(http.StatusNoContent)
+	gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions$").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.19:8000/npcf-policyauthorization/v1/app-sessions/67890").
+		JSON(models.AppSessionContext{})
+	tiSub = tiSub3ForAf1
+	rsp = nefApp.Processor().PutIndividualTrafficInfluenceSubscription("af1", "1", &tiSub)
+	require.Equal(t, http.StatusOK, rsp.Status)
+	require.True(t, pcf2Delete.Mock.Done())
+	require.Equal(t, "67890", afSub.AppSessID)
+	require.Equal(t, "http://127.0.0.19:8000", afSub.PcfUri)
+
+	// Put keeps the subscription and the old app session if the new app session is not created
+	pcf2Delete = gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions/67890/delete")
+	pcf2Delete.Reply(http.StatusNoContent)
+	for _, pcf := range []string{"http://127.0.0.19:8000", "http://127.0.0.18:8000"} {
+		gock.New(pcf).
+			Post("/npcf-policyauthorization/v1/app-sessions$").
+			Reply(http.StatusForbidden).
+			JSON(models.ProblemDetails{Status: http.StatusForbidden})
+	}
+	tiSub = tiSub3ForAf1
+	rsp = nefApp.Processor().PutIndividualTrafficInfluenceSubscription("af1", "1", &tiSub)
+	require.Equal(t, http.StatusForbidden, rsp.Status)
+	require.False(t, pcf2Delete.Mock.Done())
+	af.Mu.RLock()
+	require.Same(t, afSub, af.Subs["1"])
+	require.Equal(t, "67890", afSub.AppSessID)
+	require.Equal(t, "http://127.0.0.19:8000", afSub.PcfUri)
+	af.Mu.RUnlock()
+	gock.Off()
+
+	// Put deletes the new app session if the old one fails to be deleted
+	gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions/67890/delete").
+		Reply(http.StatusInternalServerError).
+		JSON(models.ProblemDetails{Status: http.StatusInternalServerError})
+	gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions$").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.19:8000/npcf-policyauthorization/v1/app-sessions/13579").
+		JSON(models.AppSessionContext{})
+	newDelete := gock.New("http://127.0.0.19:8000").
+		Post("/npcf-policyauthorization/v1/app-sessions/13579/delete")
+	newDelete.Reply(http.StatusNoContent)
+	tiSub = tiSub3ForAf1
+	rsp = nefApp.Processor().PutIndividualTrafficInfluenceSubscription("af1", "1", &tiSub)
+	require.Equal(t, http.StatusInternalServerError, rsp.Status)
+	require.True(t, newDelete.Mock.Done())
+	af.Mu.RLock()
+	require.Same(t, afSub, af.Subs["1"])
+	require.Equal(t, "67890", afSub.AppSessID)
+	af.Mu.RUnlock()
+}
+
+func TestTempValidityWindow(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t1.Add(2 * time.Hour)
+	t4 := t1.Add(3 * time.Hour)
+
+	testCases := []struct {
+		description   string
+		tempVals      []models.TemporalValidity
+		expectedStart *time.Time
+		expectedEnd   *time.Time
+	}{
+		{
+			description: "TC1: No temporal validity",
+		},
+		{
+			description: "TC2: From the earliest start to the latest stop",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &t3, StopTime: &t4},
+				{StartTime: &t1, StopTime: &t2},
+			},
+			expectedStart: &t1,
+			expectedEnd:   &t4,
+		},
+		{
+			description: "TC3: Open start and open stop",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &t2, StopTime: &t3},
+				{StopTime: &t2},
+				{StartTime: &t3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			start, end := tempValidityWindow(tc.tempVals)
+			require.Equal(t, tc.expectedStart, start)
+			require.Equal(t, tc.expectedEnd, end)
+		})
+	}
+}
+
+func TestTrafficInfluenceSubscriptionExpiry(t *testing.T) {
+	initUDRDrPutTiDataStubWithBody(`"validEndTime":`)
+	initUDRDrDeleteTiDataStub(http.StatusNoContent)
+	afNotif := gock.New("http://af1.example.com").
+		Post("/ti-notif").
+		BodyString(`"subscription":"` + nefApp.Processor().genTrafficInfluSubURI("af1", "1") + `"`).
+		Reply(http.StatusNoContent)
+	defer gock.Off()
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	// The subscription which has ended is rejected
+	past := time.Now().Add(-time.Minute)
+	tiSub := tiSub1ForAf1
+	tiSub.NotificationDestination = "http://af1.example.com/ti-notif"
+	tiSub.TempValidities = []models.TemporalValidity{{StopTime: &past}}
+	rsp := nefApp.Processor().PostTrafficInfluenceSubscription("af1", &tiSub)
+	require.Equal(t, &HandlerResponse{
+		Status: http.StatusBadRequest,
+		Body: util.ProblemDetailsInvalidParams("Invalid tempValidities", []models.InvalidParam{
+			{Param: "/tempValidities", Reason: "All temporal validities have ended"},
+		}),
+	}, rsp)
+
+	// The validity window is provided to UDR, and the subscription is removed when it ends
+	stop := time.Now().Add(300 * time.Millisecond)
+	tiSub.TempValidities = []models.TemporalValidity{{StopTime: &stop}}
+	rsp = nefApp.Processor().PostTrafficInfluenceSubscription("af1", &tiSub)
+	require.Equal(t, http.StatusCreated, rsp.Status)
+	require.True(t, nefApp.Notifier().ValidityScheduler.Pending(tiSubExpiryKey("af1", "1")))
+
+	af := nefCtx.GetAf("af1")
+	require.NotNil(t, af)
+	require.Eventually(t, func() bool {
+		af.Mu.RLock()
+		defer af.Mu.RUnlock()
+		_, ok := af.Subs["1"]
+		return !ok
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Eventually(t, afNotif.Mock.Done, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestScheduleTrafficInfluenceExpiry(t *testing.T) {
+	initUDRDrDeleteTiDataStub(http.StatusNoContent)
+	defer gock.Off()
+
+	nefCtx := nefApp.Context()
+	defer func() {
+		nefCtx.DeleteAf("af1")
+		nefCtx.ResetCorreID()
+	}()
+
+	// Restored subscriptions, one of which ended while NEF was down
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	af := nefCtx.NewAf("af1")
+	for _, stop := range []*time.Time{&past, &future, nil} {
+		tiSub := tiSub1ForAf1
+		tiSub.TempValidities = []models.TemporalValidity{{StopTime: stop}}
+		afSub := af.NewSub(nefCtx.NewCorreID(), &tiSub)
+		afSub.InfluID = uuid.New().String()
+		af.Subs[afSub.SubID] = afSub
+	}
+	nefCtx.AddAf(af)
+
+	nefApp.Processor().ScheduleTrafficInfluenceExpiry()
+	require.Eventually(t, func() bool {
+		af.Mu.RLock()
+		defer af.Mu.RUnlock()
+		_, ok := af.Subs["1"]
+		return !ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	scheduler := nefApp.Notifier().ValidityScheduler
+	require.True(t, scheduler.Pending(tiSubExpiryKey("af1", "2")))
+	require.False(t, scheduler.Pending(tiSubExpiryKey("af1", "3")))
+
+	rsp := nefApp.Processor().DeleteIndividualTrafficInfluenceSubscription("af1", "2")
+	require.Equal(t, http.StatusNoContent, rsp.Status)
+	require.False(t, scheduler.Pending(tiSubExpiryKey("af1", "2")))
+}
+
 func initUDRDrPutTiDataStub(statusCode int) {
 	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
 		Put("/application-data/influenceData/.*").
@@ -664,3 +1236,74 @@ func initPCFPaDeleteAppSessionsStub(statusCode int) {
 		Persist().
 		Reply(statusCode)
 }
+
+func initUDRDrPutTiDataStubWithBody(bodyRegex string) {
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		BodyString(bodyRegex).
+		Reply(http.StatusCreated)
+}
+
+func initPCFPaPostAppSessionsStubWithBody(bodyRegex string) {
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		BodyString(bodyRegex).
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.7:8000/npcf-policyauthorization/v1/app-sessions/12345").
+		JSON(models.AppSessionContext{})
+}
+
+func initNRFDiscBSFStub() {
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", "BSF").
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", "nbsf-management").
+		Reply(http.StatusOK).
+		JSON(&models.SearchResult{
+			ValidityPeriod: 100,
+			NfInstances: []models.NfProfile{
+				{
+					NfInstanceId: "bsf-unit-testing",
+					NfType:       "BSF",
+					NfStatus:     "REGISTERED",
+					NfServices: &[]models.NfService{
+						{
+							ServiceInstanceId: "1",
+							ServiceName:       "nbsf-management",
+							Scheme:            "http",
+							NfServiceStatus:   "REGISTERED",
+							IpEndPoints: &[]models.IpEndPoint{
+								{
+									Ipv4Address: "127.0.0.16",
+									Transport:   "TCP",
+									Port:        8000,
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+}
+
+func initUDMSdmGetIdTranslationResultStub() {
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v1").
+		Get("/msisdn-0900000001/id-translation-result").
+		Reply(http.StatusOK).
+		JSON(models.IdTranslationResult{
+			Supi: "imsi-208930000000001",
+			Gpsi: "msisdn-0900000001",
+		})
+}
+
+func initUDMSdmGetGroupIdentifiersStub() {
+	gock.New("http://127.0.0.3:8000").
+		Get("/nudm-sdm/v1/group-data/group-identifiers").
+		MatchParam("ext-group-id", "group1@example.com").
+		Reply(http.StatusOK).
+		JSON(nef_models.GroupIdentifiers{
+			ExtGroupId: "group1@example.com",
+			IntGroupId: "group-internal-1",
+		})
+}
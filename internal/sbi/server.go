@@ -8,9 +8,11 @@ import (
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/nef/internal/sbi/processor"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/util/httpwrapper"
 	logger_util "github.com/free5gc/util/logger"
 	"github.com/gin-contrib/cors"
@@ -55,6 +57,7 @@ type Server struct {
 
 	httpServer *http.Server
 	router     *gin.Engine
+	nrfKey     nrfPublicKey
 }
 
 func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
@@ -63,27 +66,68 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 	}
 
 	s.router = logger_util.NewGinWithLogrus(logger.GinLog)
+	s.router.Use(metrics.GinMiddleware())
 
 	endpoints := s.getTrafficInfluenceEndpoints()
 	group := s.router.Group(factory.TraffInfluResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServiceTraffInflu))
 	applyEndpoints(group, endpoints)
 
 	endpoints = s.getPFDManagementEndpoints()
 	group = s.router.Group(factory.PfdMngResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServicePfdMng))
+	applyEndpoints(group, endpoints)
+
+	endpoints = s.getMonitoringEventEndpoints()
+	group = s.router.Group(factory.MonEvtResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServiceMonEvt))
+	applyEndpoints(group, endpoints)
+
+	endpoints = s.getAsSessionWithQoSEndpoints()
+	group = s.router.Group(factory.AsQosResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServiceAsQos))
+	applyEndpoints(group, endpoints)
+
+	endpoints = s.getDeviceTriggeringEndpoints()
+	group = s.router.Group(factory.DevTrigResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServiceDevTrig))
+	applyEndpoints(group, endpoints)
+
+	endpoints = s.getDnaiMappingEndpoints()
+	group = s.router.Group(factory.DnaiMapResUriPrefix)
+	group.Use(s.identifyAf())
+	group.Use(s.authorizeAf(factory.ServiceDnaiMap))
 	applyEndpoints(group, endpoints)
 
 	endpoints = s.getPFDFEndpoints()
 	group = s.router.Group(factory.NefPfdMngResUriPrefix)
+	group.Use(s.authorizeNf(models.ServiceName_NNEF_PFDMANAGEMENT))
 	applyEndpoints(group, endpoints)
 
 	endpoints = s.getOamEndpoints()
 	group = s.router.Group(factory.NefOamResUriPrefix)
+	group.Use(s.authorizeNf(models.ServiceName(factory.ServiceNefOam)))
+	applyEndpoints(group, endpoints)
+
+	endpoints = s.getOamAdminEndpoints()
+	group = s.router.Group(factory.NefOamAdminResUriPrefix)
+	group.Use(s.authenticateAdmin())
 	applyEndpoints(group, endpoints)
 
 	endpoints = s.getCallbackEndpoints()
 	group = s.router.Group(factory.NefCallbackResUriPrefix)
 	applyEndpoints(group, endpoints)
 
+	endpoints = s.getAfAckEndpoints()
+	group = s.router.Group(factory.NefCallbackResUriPrefix + "/notification/af-ack")
+	group.Use(s.authorizeAfAck())
+	applyEndpoints(group, endpoints)
+
 	s.router.Use(cors.New(cors.Config{
 		AllowMethods: []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
 		AllowHeaders: []string{
@@ -139,8 +183,13 @@ func (s *Server) startServer(wg *sync.WaitGroup) {
 	if scheme == "http" {
 		err = s.httpServer.ListenAndServe()
 	} else if scheme == "https" {
-		// TODO: use config file to config path
-		err = s.httpServer.ListenAndServeTLS(s.Config().TLSPemPath(), s.Config().TLSKeyPath())
+		cfg := s.Config()
+		var reloader *tlsReloader
+		reloader, err = newTLSReloader(cfg.TLSPemPath(), cfg.TLSKeyPath(), cfg.TLSCaPath(), cfg.TLSClientAuth())
+		if err == nil {
+			s.httpServer.TLSConfig = reloader.TLSConfig(s.httpServer.TLSConfig)
+			err = s.httpServer.ListenAndServeTLS("", "")
+		}
 	} else {
 		err = fmt.Errorf("No support this scheme[%s]", scheme)
 	}
@@ -0,0 +1,199 @@
+package sbi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+// Files are checked for change at most once per interval during the TLS handshakes
+const tlsReloadCheckInterval = 5 * time.Second
+
+// tlsReloader serves the server certificate and the client CA pool,
+// and reloads them from the files once they are changed
+type tlsReloader struct {
+	pemPath    string
+	keyPath    string
+	caPath     string
+	clientAuth string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	caPool    *x509.CertPool
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+func newTLSReloader(pemPath, keyPath, caPath, clientAuth string) (*tlsReloader, error) {
+	r := &tlsReloader{
+		pemPath:    pemPath,
+		keyPath:    keyPath,
+		caPath:     caPath,
+		clientAuth: clientAuth,
+		modTimes:   make(map[string]time.Time),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the TLS config of SBI server based on the given one
+func (r *tlsReloader) TLSConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if base != nil {
+		cfg = base.Clone()
+	}
+
+	cfg.GetCertificate = r.getCertificate
+	// The client certificate is verified in VerifyConnection instead of by ClientCAs,
+	// so that the reloaded CA pool takes effect without restarting the server
+	switch r.clientAuth {
+	case factory.NefClientAuthRequest:
+		cfg.ClientAuth = tls.RequestClientCert
+		cfg.VerifyConnection = r.verifyConnection
+	case factory.NefClientAuthRequire:
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = r.verifyConnection
+	default:
+		cfg.ClientAuth = tls.NoClientCert
+	}
+	return cfg
+}
+
+func (r *tlsReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *tlsReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		// tls.RequireAnyClientCert has already rejected the connection without certificate
+		return nil
+	}
+
+	r.mu.RLock()
+	caPool := r.caPool
+	r.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		logger.SBILog.Warnf("Client certificate[%s] is rejected: %+v",
+			cs.PeerCertificates[0].Subject, err)
+	}
+	return err
+}
+
+func (r *tlsReloader) reloadIfChanged() {
+	r.mu.Lock()
+	if time.Since(r.lastCheck) < tlsReloadCheckInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	changed := r.isChanged()
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		logger.SBILog.Errorf("Reload TLS files failed, keep the current ones: %+v", err)
+		return
+	}
+	logger.SBILog.Infof("TLS files are reloaded")
+}
+
+// isChanged shall be called with r.mu held
+func (r *tlsReloader) isChanged() bool {
+	for _, path := range r.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *tlsReloader) paths() []string {
+	paths := []string{r.pemPath, r.keyPath}
+	if r.caPath != "" {
+		paths = append(paths, r.caPath)
+	}
+	return paths
+}
+
+func (r *tlsReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.pemPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("load certificate[%s] err: %w", r.pemPath, err)
+	}
+
+	var caPool *x509.CertPool
+	if r.caPath != "" {
+		caPem, err := os.ReadFile(r.caPath)
+		if err != nil {
+			return fmt.Errorf("read CA[%s] err: %w", r.caPath, err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caPem) {
+			return errors.New("no certificate found in CA " + r.caPath)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.caPool = caPool
+	r.modTimes = modTimes
+	return nil
+}
+
+// clientCertIdentity returns the identity in the SAN of the client certificate,
+// the URI is preferred to the DNS name
+func clientCertIdentity(cs *tls.ConnectionState) string {
+	if cs == nil || len(cs.PeerCertificates) == 0 {
+		return ""
+	}
+
+	cert := cs.PeerCertificates[0]
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package sbi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+	der  []byte
+}
+
+func genTestCert(t *testing.T, cn string, parent *testCert, mod func(*x509.Certificate)) *testCert {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if mod != nil {
+		mod(tmpl)
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func genTestCA(t *testing.T, cn string) *testCert {
+	return genTestCert(t, cn, nil, func(c *x509.Certificate) {
+		c.IsCA = true
+		c.BasicConstraintsValid = true
+		c.KeyUsage = x509.KeyUsageCertSign
+	})
+}
+
+func (c *testCert) writeFiles(t *testing.T, pemPath, keyPath string) {
+	err := os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+	require.NoError(t, err)
+	if keyPath != "" {
+		err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(c.key),
+		}), 0o600)
+		require.NoError(t, err)
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestTLSReloaderClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	pemPath := filepath.Join(dir, "nef.pem")
+	keyPath := filepath.Join(dir, "nef.key")
+	caPath := filepath.Join(dir, "ca.pem")
+
+	ca := genTestCA(t, "ca")
+	ca.writeFiles(t, caPath, "")
+	serverCert := genTestCert(t, "nef", ca, func(c *x509.Certificate) {
+		c.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	})
+	serverCert.writeFiles(t, pemPath, keyPath)
+
+	afURI, err := url.Parse("spiffe://operator.example/af1")
+	require.NoError(t, err)
+	afCert := genTestCert(t, "af1", ca, func(c *x509.Certificate) {
+		c.URIs = []*url.URL{afURI}
+		c.DNSNames = []string{"af1.example.com"}
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	rogueCA := genTestCA(t, "rogue")
+	rogueCert := genTestCert(t, "af1", rogueCA, func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+
+	reloader, err := newTLSReloader(pemPath, keyPath, caPath, factory.NefClientAuthRequire)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, clientCertIdentity(r.TLS))
+		}),
+		TLSConfig:         reloader.TLSConfig(nil),
+		ReadHeaderTimeout: time.Second,
+		ErrorLog:          log.New(io.Discard, "", 0),
+	}
+	go func() {
+		_ = srv.ServeTLS(listener, "", "")
+	}()
+	defer srv.Close()
+	srvURL := "https://" + listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCerts ...tls.Certificate) (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: clientCerts,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+		rsp, err := client.Get(srvURL)
+		if err != nil {
+			return "", err
+		}
+		defer rsp.Body.Close()
+		body, err := io.ReadAll(rsp.Body)
+		return string(body), err
+	}
+
+	t.Run("TC1: Client certificate signed by CA, should expose the URI SAN", func(t *testing.T) {
+		identity, err := get(afCert.tlsCertificate())
+		require.NoError(t, err)
+		require.Equal(t, afURI.String(), identity)
+	})
+	t.Run("TC2: Missing client certificate, should be rejected", func(t *testing.T) {
+		_, err := get()
+		require.Error(t, err)
+	})
+	t.Run("TC3: Client certificate of another CA, should be rejected", func(t *testing.T) {
+		_, err := get(rogueCert.tlsCertificate())
+		require.Error(t, err)
+	})
+	t.Run("TC4: Server certificate changed, should be reloaded", func(t *testing.T) {
+		newServerCert := genTestCert(t, "nef-renewed", ca, func(c *x509.Certificate) {
+			c.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+			c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		})
+		newServerCert.writeFiles(t, pemPath, keyPath)
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(pemPath, later, later))
+
+		reloader.mu.Lock()
+		reloader.lastCheck = time.Time{}
+		reloader.mu.Unlock()
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{afCert.tlsCertificate()},
+			MinVersion:   tls.VersionTLS12,
+		})
+		require.NoError(t, err)
+		defer conn.Close()
+		require.Equal(t, "nef-renewed", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+	})
+}
@@ -1 +1,33 @@
 package util
+
+import (
+	"net/http"
+
+	"github.com/free5gc/openapi/models"
+)
+
+func ProblemDetailsForbidden(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+		Detail: detail,
+		Cause:  "REQUEST_NOT_AUTHORIZED",
+	}
+}
+
+func ProblemDetailsUnauthorized(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+	}
+}
+
+func ProblemDetailsInvalidParams(detail string, invalidParams []models.InvalidParam) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Title:         "Invalid parameters",
+		Status:        http.StatusBadRequest,
+		Detail:        detail,
+		InvalidParams: invalidParams,
+	}
+}
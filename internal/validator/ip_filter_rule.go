@@ -0,0 +1,182 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	IPFilterDirectionIn  = "in"
+	IPFilterDirectionOut = "out"
+	// IPFilterProtoAny is the protocol of keyword "ip"
+	IPFilterProtoAny = -1
+)
+
+// IPFilterRule is a packet filter in IPFilterRule format of RFC 6733 with the restrictions
+// of TS 29.212 clause 5.4.2: only the action "permit" is used, while the invert modifier "!",
+// the keyword "assigned" and the options are not used. E.g.
+//
+//	permit out 17 from 10.60.0.0/16 80,8000-8080 to any
+type IPFilterRule struct {
+	Direction string
+	Proto     int
+	Src       IPFilterAddr
+	Dst       IPFilterAddr
+}
+
+type IPFilterAddr struct {
+	// Any is true for keyword "any", otherwise Prefix is valid
+	Any    bool
+	Prefix netip.Prefix
+	Ports  []PortRange
+}
+
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+func ParseIPFilterRule(rule string) (*IPFilterRule, error) {
+	if len(rule) > MaxFlowDescriptionLength {
+		return nil, fmt.Errorf("longer than %d characters", MaxFlowDescriptionLength)
+	}
+
+	fields := strings.Fields(rule)
+	if len(fields) < 7 {
+		return nil, errors.New("should be \"permit in|out <proto> from <src> [ports] to <dst> [ports]\"")
+	}
+	if fields[0] != "permit" {
+		return nil, fmt.Errorf("action[%s] should be permit", fields[0])
+	}
+
+	r := &IPFilterRule{}
+	switch fields[1] {
+	case IPFilterDirectionIn, IPFilterDirectionOut:
+		r.Direction = fields[1]
+	default:
+		return nil, fmt.Errorf("direction[%s] should be in or out", fields[1])
+	}
+
+	if fields[2] == "ip" {
+		r.Proto = IPFilterProtoAny
+	} else {
+		proto, err := strconv.Atoi(fields[2])
+		if err != nil || proto < 0 || proto > 255 {
+			return nil, fmt.Errorf("protocol[%s] should be ip or a number in 0-255", fields[2])
+		}
+		r.Proto = proto
+	}
+
+	if fields[3] != "from" {
+		return nil, fmt.Errorf("expect from but got %s", fields[3])
+	}
+	var err error
+	i := 4
+	if r.Src, i, err = parseIPFilterAddr(fields, i); err != nil {
+		return nil, fmt.Errorf("source %w", err)
+	}
+	if i >= len(fields) || fields[i] != "to" {
+		return nil, errors.New("missing to")
+	}
+	if r.Dst, i, err = parseIPFilterAddr(fields, i+1); err != nil {
+		return nil, fmt.Errorf("destination %w", err)
+	}
+	if i < len(fields) {
+		return nil, fmt.Errorf("options[%s] are not allowed", strings.Join(fields[i:], " "))
+	}
+
+	if !r.Src.Any && !r.Dst.Any && r.Src.Prefix.Addr().Is4() != r.Dst.Prefix.Addr().Is4() {
+		return nil, errors.New("source and destination should be of the same IP version")
+	}
+	return r, nil
+}
+
+// parseIPFilterAddr parses the address and the optional ports starting from fields[i],
+// and returns the index of the next field
+func parseIPFilterAddr(fields []string, i int) (IPFilterAddr, int, error) {
+	var addr IPFilterAddr
+	if i >= len(fields) {
+		return addr, i, errors.New("address is missing")
+	}
+
+	token := fields[i]
+	switch {
+	case token == "any":
+		addr.Any = true
+	case token == "assigned":
+		return addr, i, errors.New("address keyword assigned is not allowed")
+	case strings.HasPrefix(token, "!"):
+		return addr, i, errors.New("address invert modifier ! is not allowed")
+	default:
+		prefix, err := parsePrefix(token)
+		if err != nil {
+			return addr, i, err
+		}
+		addr.Prefix = prefix
+	}
+	i++
+
+	if i < len(fields) && fields[i] != "to" && isPortList(fields[i]) {
+		ports, err := parsePorts(fields[i])
+		if err != nil {
+			return addr, i, err
+		}
+		addr.Ports = ports
+		i++
+	}
+	return addr, i, nil
+}
+
+func parsePrefix(token string) (netip.Prefix, error) {
+	if strings.Contains(token, "/") {
+		prefix, err := netip.ParsePrefix(token)
+		if err != nil {
+			return prefix, fmt.Errorf("address[%s] is invalid", token)
+		}
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(token)
+	if err != nil || addr.Zone() != "" {
+		return netip.Prefix{}, fmt.Errorf("address[%s] is invalid", token)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func isPortList(token string) bool {
+	return strings.Trim(token, "0123456789,-") == ""
+}
+
+func parsePorts(token string) ([]PortRange, error) {
+	var ports []PortRange
+	for _, item := range strings.Split(token, ",") {
+		start, end, isRange := strings.Cut(item, "-")
+		if !isRange {
+			end = start
+		}
+		startPort, err := parsePort(start)
+		if err != nil {
+			return nil, err
+		}
+		endPort, err := parsePort(end)
+		if err != nil {
+			return nil, err
+		}
+		if startPort > endPort {
+			return nil, fmt.Errorf("port range[%s] is invalid", item)
+		}
+		ports = append(ports, PortRange{Start: startPort, End: endPort})
+	}
+	return ports, nil
+}
+
+func parsePort(token string) (uint16, error) {
+	port, err := strconv.ParseUint(token, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("port[%s] should be a number in 0-65535", token)
+	}
+	return uint16(port), nil
+}
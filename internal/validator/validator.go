@@ -0,0 +1,183 @@
+// Package validator checks the semantics of the attributes provided by AFs,
+// and reports the invalid ones as InvalidParams of ProblemDetails.
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/free5gc/openapi/models"
+)
+
+// Size limits of the attributes
+const (
+	MaxFlowDescriptionLength = 512
+	MaxUrlLength             = 2048
+	MaxDomainNameLength      = 253
+	MaxDomainLabelLength     = 63
+	// Maximum number of PFDs of an application
+	MaxPfdsPerApp = 64
+	// Maximum number of flow descriptions, URLs or domain names of a PFD
+	MaxPfdEntries = 64
+	// FlowInfo contains UL and/or DL flow descriptions, TS 29.214 clause 5.6.2.14
+	MaxFlowInfoDescriptions = 2
+)
+
+// JoinPointer appends the reference tokens to the JSON pointer, RFC 6901
+func JoinPointer(pointer string, tokens ...string) string {
+	var b strings.Builder
+	b.WriteString(pointer)
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// Pfd checks the flow descriptions, URLs and domain names of the PFD located at pointer
+func Pfd(pointer string, pfd *models.Pfd) []models.InvalidParam {
+	var invalidParams []models.InvalidParam
+	invalidParams = append(invalidParams, checkList(
+		JoinPointer(pointer, "flowDescriptions"), pfd.FlowDescriptions, MaxPfdEntries, FlowDescription)...)
+	invalidParams = append(invalidParams, checkList(
+		JoinPointer(pointer, "urls"), pfd.Urls, MaxPfdEntries, URL)...)
+	invalidParams = append(invalidParams, checkList(
+		JoinPointer(pointer, "domainNames"), pfd.DomainNames, MaxPfdEntries, DomainName)...)
+	return invalidParams
+}
+
+// FlowInfo checks the flow descriptions of the traffic filter located at pointer
+func FlowInfo(pointer string, flowInfo *models.FlowInfo) []models.InvalidParam {
+	return checkList(JoinPointer(pointer, "flowDescriptions"),
+		flowInfo.FlowDescriptions, MaxFlowInfoDescriptions, FlowDescription)
+}
+
+func checkList(pointer string, values []string, maxLen int, check func(string) error) []models.InvalidParam {
+	if len(values) > maxLen {
+		return []models.InvalidParam{{
+			Param:  pointer,
+			Reason: fmt.Sprintf("more than %d entries", maxLen),
+		}}
+	}
+
+	var invalidParams []models.InvalidParam
+	for i, value := range values {
+		if err := check(value); err != nil {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  JoinPointer(pointer, strconv.Itoa(i)),
+				Reason: err.Error(),
+			})
+		}
+	}
+	return invalidParams
+}
+
+// FlowDescription checks the flow description in IPFilterRule format
+func FlowDescription(flowDesc string) error {
+	_, err := ParseIPFilterRule(flowDesc)
+	return err
+}
+
+// URL checks the URL, or the regular expression matching the significant parts of URL
+// if it is anchored by ^ or $, TS 29.122 clause 5.14.2.1.4
+func URL(u string) error {
+	if err := checkString(u, MaxUrlLength); err != nil {
+		return err
+	}
+	if isRegexp(u) {
+		return checkRegexp(u)
+	}
+
+	// The scheme may be omitted in the significant parts of URL
+	if !strings.Contains(u, "://") {
+		u = "http://" + u
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("scheme[%s] should be http or https", parsed.Scheme)
+	}
+	host := parsed.Hostname()
+	if host == "" {
+		return errors.New("host is missing")
+	}
+	if _, err = netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	if err = DomainName(host); err != nil {
+		return fmt.Errorf("host[%s]: %w", host, err)
+	}
+	return nil
+}
+
+// DomainName checks the FQDN, which may start with the wildcard label "*",
+// or the regular expression matching the domain names if it is anchored by ^ or $
+func DomainName(name string) error {
+	if err := checkString(name, MaxDomainNameLength+1); err != nil {
+		return err
+	}
+	if isRegexp(name) {
+		return checkRegexp(name)
+	}
+
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > MaxDomainNameLength {
+		return fmt.Errorf("longer than %d characters", MaxDomainNameLength)
+	}
+	name = strings.TrimPrefix(name, "*.")
+	for _, label := range strings.Split(name, ".") {
+		if err := checkDomainLabel(label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkDomainLabel(label string) error {
+	if label == "" || len(label) > MaxDomainLabelLength {
+		return fmt.Errorf("label[%s] should be 1-%d characters", label, MaxDomainLabelLength)
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("label[%s] should not start or end with hyphen", label)
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return fmt.Errorf("label[%s] contains invalid character %q", label, c)
+		}
+	}
+	return nil
+}
+
+func checkString(s string, maxLen int) error {
+	if s == "" {
+		return errors.New("empty")
+	}
+	if len(s) > maxLen {
+		return fmt.Errorf("longer than %d characters", maxLen)
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return errors.New("contains whitespace or control characters")
+	}
+	return nil
+}
+
+func isRegexp(s string) bool {
+	return strings.HasPrefix(s, "^") || strings.HasSuffix(s, "$")
+}
+
+func checkRegexp(s string) error {
+	if _, err := regexp.Compile(s); err != nil {
+		return fmt.Errorf("invalid regular expression: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package validator
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPFilterRule(t *testing.T) {
+	testCases := []struct {
+		description  string
+		rule         string
+		expectedRule *IPFilterRule
+		expectedErr  string
+	}{
+		{
+			description: "TC1: Any protocol with ports, should be parsed",
+			rule:        "permit in ip from 10.68.28.39 80 to any",
+			expectedRule: &IPFilterRule{
+				Direction: IPFilterDirectionIn,
+				Proto:     IPFilterProtoAny,
+				Src: IPFilterAddr{
+					Prefix: netip.MustParsePrefix("10.68.28.39/32"),
+					Ports:  []PortRange{{Start: 80, End: 80}},
+				},
+				Dst: IPFilterAddr{Any: true},
+			},
+		},
+		{
+			description: "TC2: Protocol number with prefix and port ranges, should be parsed",
+			rule:        "permit out 17 from 2001:db8::/32 to 2001:db8:1::1 80,8000-8080",
+			expectedRule: &IPFilterRule{
+				Direction: IPFilterDirectionOut,
+				Proto:     17,
+				Src: IPFilterAddr{
+					Prefix: netip.MustParsePrefix("2001:db8::/32"),
+				},
+				Dst: IPFilterAddr{
+					Prefix: netip.MustParsePrefix("2001:db8:1::1/128"),
+					Ports:  []PortRange{{Start: 80, End: 80}, {Start: 8000, End: 8080}},
+				},
+			},
+		},
+		{
+			description: "TC3: Action deny, should fail",
+			rule:        "deny out ip from any to any",
+			expectedErr: "action[deny] should be permit",
+		},
+		{
+			description: "TC4: Invalid protocol, should fail",
+			rule:        "permit out tcp from any to any",
+			expectedErr: "protocol[tcp] should be ip or a number in 0-255",
+		},
+		{
+			description: "TC5: Invalid address, should fail",
+			rule:        "permit out ip from 10.0.0.256 to any",
+			expectedErr: "source address[10.0.0.256] is invalid",
+		},
+		{
+			description: "TC6: Invert modifier, should fail",
+			rule:        "permit out ip from !10.0.0.1 to any",
+			expectedErr: "source address invert modifier ! is not allowed",
+		},
+		{
+			description: "TC7: Keyword assigned, should fail",
+			rule:        "permit out ip from any to assigned",
+			expectedErr: "destination address keyword assigned is not allowed",
+		},
+		{
+			description: "TC8: Invalid port range, should fail",
+			rule:        "permit out ip from any 8080-80 to any",
+			expectedErr: "source port range[8080-80] is invalid",
+		},
+		{
+			description: "TC9: Options, should fail",
+			rule:        "permit out 6 from any to any established",
+			expectedErr: "options[established] are not allowed",
+		},
+		{
+			description: "TC10: Mixed IP versions, should fail",
+			rule:        "permit out ip from 10.0.0.1 to 2001:db8::1",
+			expectedErr: "source and destination should be of the same IP version",
+		},
+		{
+			description: "TC11: Too few fields, should fail",
+			rule:        "permit out ip from any",
+			expectedErr: "should be \"permit in|out <proto> from <src> [ports] to <dst> [ports]\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			rule, err := ParseIPFilterRule(tc.rule)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRule, rule)
+		})
+	}
+}
+
+func TestURLAndDomainName(t *testing.T) {
+	require.NoError(t, URL("http://test.example.com/path?q=1"))
+	require.NoError(t, URL("test.example.com/path"))
+	require.NoError(t, URL("https://10.0.0.1:8080/"))
+	require.NoError(t, URL("^http://test.example.com(/\\S*)?$"))
+	require.EqualError(t, URL("ftp://test.example.com"), "scheme[ftp] should be http or https")
+	require.EqualError(t, URL("http://test example.com"), "contains whitespace or control characters")
+	require.EqualError(t, URL("http://-test.example.com"),
+		"host[-test.example.com]: label[-test] should not start or end with hyphen")
+	require.ErrorContains(t, URL("^http://test.example.com(/\\S*$"), "invalid regular expression")
+	require.EqualError(t, URL("http://test.example.com/"+strings.Repeat("a", MaxUrlLength)),
+		"longer than 2048 characters")
+
+	require.NoError(t, DomainName("test.example.com"))
+	require.NoError(t, DomainName("test.example.com."))
+	require.NoError(t, DomainName("*.example.com"))
+	require.NoError(t, DomainName("^.*\\.example\\.com$"))
+	require.EqualError(t, DomainName(""), "empty")
+	require.EqualError(t, DomainName("test..example.com"), "label[] should be 1-63 characters")
+	require.EqualError(t, DomainName("test_1.example.com"), "label[test_1] contains invalid character '_'")
+	require.EqualError(t, DomainName(strings.Repeat("a", MaxDomainLabelLength+1)+".com"),
+		"label["+strings.Repeat("a", MaxDomainLabelLength+1)+"] should be 1-63 characters")
+}
+
+func TestPfd(t *testing.T) {
+	pfd := &models.Pfd{
+		PfdId: "pfd1",
+		FlowDescriptions: []string{
+			"permit out ip from 10.68.28.39 80 to any",
+			"permit out ip from 10.68.28.39 to",
+		},
+		Urls:        []string{"http://test.example.com"},
+		DomainNames: []string{"bad_name.example.com"},
+	}
+	require.Equal(t, []models.InvalidParam{
+		{
+			Param:  "/pfds/pfd~11/flowDescriptions/1",
+			Reason: "should be \"permit in|out <proto> from <src> [ports] to <dst> [ports]\"",
+		},
+		{
+			Param:  "/pfds/pfd~11/domainNames/0",
+			Reason: "label[bad_name] contains invalid character '_'",
+		},
+	}, Pfd(JoinPointer("/pfds", "pfd/1"), pfd))
+
+	pfd = &models.Pfd{
+		PfdId: "pfd1",
+		Urls:  make([]string, MaxPfdEntries+1),
+	}
+	require.Equal(t, []models.InvalidParam{
+		{
+			Param:  "/pfds/pfd1/urls",
+			Reason: "more than 64 entries",
+		},
+	}, Pfd("/pfds/pfd1", pfd))
+}
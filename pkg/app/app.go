@@ -2,23 +2,30 @@ package app
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"os/signal"
 	"runtime/debug"
 	"sync"
 	"syscall"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/metrics"
 	"github.com/free5gc/nef/internal/sbi"
 	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/internal/sbi/notifier"
 	"github.com/free5gc/nef/internal/sbi/processor"
 	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
 	"github.com/sirupsen/logrus"
 )
 
+// Interval of checking the config file for change
+const configWatchInterval = 5 * time.Second
+
 type NefApp struct {
 	ctx       context.Context
 	wg        sync.WaitGroup
@@ -28,11 +35,20 @@ type NefApp struct {
 	notifier  *notifier.Notifier
 	proc      *processor.Processor
 	sbiServer *sbi.Server
+	// nil if metrics is disabled
+	metricsServer *metrics.Server
+
+	reloadMu sync.Mutex
+	// Re-registration requests to the heartbeat goroutine, which is the only one registering to NRF
+	reregisterCh chan struct{}
 }
 
 func NewApp(cfg *factory.Config, tlsKeyLogPath string) (*NefApp, error) {
 	var err error
-	nef := &NefApp{cfg: cfg}
+	nef := &NefApp{
+		cfg:          cfg,
+		reregisterCh: make(chan struct{}, 1),
+	}
 	nef.SetLogEnable(cfg.GetLogEnable())
 	nef.SetLogLevel(cfg.GetLogLevel())
 	nef.SetReportCaller(cfg.GetLogReportCaller())
@@ -43,7 +59,7 @@ func NewApp(cfg *factory.Config, tlsKeyLogPath string) (*NefApp, error) {
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(cfg, nef.nefCtx.Store()); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = processor.NewProcessor(nef); err != nil {
@@ -52,6 +68,13 @@ func NewApp(cfg *factory.Config, tlsKeyLogPath string) (*NefApp, error) {
 	if nef.sbiServer, err = sbi.NewServer(nef, tlsKeyLogPath); err != nil {
 		return nil, err
 	}
+	if cfg.MetricsEnable() {
+		nef.metricsServer = metrics.NewServer(cfg.MetricsBindingAddr())
+		metrics.SetActiveCountFunc(func() metrics.ActiveCounts {
+			numAfs, numSubs, numPfdTrans := nef.nefCtx.ActiveCounts()
+			return metrics.ActiveCounts{Afs: numAfs, TiSubs: numSubs, PfdTrans: numPfdTrans}
+		})
+	}
 	return nef, nil
 }
 
@@ -135,14 +158,34 @@ func (a *NefApp) Run() error {
 		return err
 	}
 
-	if err := a.consumer.RegisterNFInstance(); err != nil {
+	if a.metricsServer != nil {
+		a.wg.Add(1)
+		go a.runMetricsServer()
+	}
+
+	if err := a.consumer.RegisterNFInstance(a.ctx); err != nil {
 		return err
 	}
+	a.proc.ScheduleTrafficInfluenceExpiry()
+
+	a.wg.Add(1)
+	go a.runNrfHeartbeat()
+
+	a.wg.Add(1)
+	go a.watchConfigFile()
 
-	// Wait for interrupt signal to gracefully shutdown UPF
+	// Wait for interrupt signal to gracefully shutdown NEF, and reload config on SIGHUP
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	<-sigCh
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigCh {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		logger.MainLog.Infof("Receive SIGHUP, reload config")
+		if _, err := a.ReloadConfig(); err != nil {
+			logger.MainLog.Errorf("Reload config err: %+v", err)
+		}
+	}
 
 	// Receive the interrupt signal
 	logger.MainLog.Infof("Shutdown NEF ...")
@@ -165,6 +208,150 @@ func (a *NefApp) listenShutdownEvent() {
 
 	<-a.ctx.Done()
 	a.sbiServer.Stop()
+	if a.metricsServer != nil {
+		a.metricsServer.Stop()
+	}
+}
+
+func (a *NefApp) runMetricsServer() {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.MetricsLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+
+		a.wg.Done()
+	}()
+
+	a.metricsServer.Run()
+}
+
+// ReloadConfig reloads the config file and applies the changes which are safe at runtime.
+// NEF re-registers to NRF if the services or NRF are changed.
+func (a *NefApp) ReloadConfig() (*factory.ReloadResult, error) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	result, err := factory.ReloadConfig(a.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.IsApplied("logger") {
+		a.SetLogEnable(a.cfg.GetLogEnable())
+		a.SetLogLevel(a.cfg.GetLogLevel())
+		a.SetReportCaller(a.cfg.GetLogReportCaller())
+	}
+	if result.IsApplied("serviceList") || result.IsApplied("nrfUri") {
+		a.requestReregister()
+	}
+	for _, field := range result.RestartRequired {
+		logger.MainLog.Warnf("Config [%s] is changed, restart NEF to apply it", field)
+	}
+	return result, nil
+}
+
+// requestReregister asks the heartbeat goroutine to re-register the NF profile to NRF.
+// The request is merged into the pending one if it's not handled yet.
+func (a *NefApp) requestReregister() {
+	select {
+	case a.reregisterCh <- struct{}{}:
+	default:
+	}
+}
+
+// reregisterNFInstance registers the NF profile to NRF again and subscribes to the NF status,
+// it's only called by the heartbeat goroutine
+func (a *NefApp) reregisterNFInstance() {
+	logger.MainLog.Infof("Re-register NF profile to NRF[%s]", a.cfg.NrfUri())
+	if err := a.consumer.RegisterNFInstance(a.ctx); err != nil {
+		logger.MainLog.Errorf("Re-register to NRF err: %+v", err)
+		return
+	}
+	a.subscribeNfStatus()
+}
+
+// runNrfHeartbeat sends heartbeats to NRF per the heartBeatTimer, re-registers if NRF doesn't know
+// the NF instance or it's requested, and renews the NF status subscriptions before they expire
+func (a *NefApp) runNrfHeartbeat() {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.MainLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+
+		a.wg.Done()
+	}()
+
+	a.subscribeNfStatus()
+	for {
+		interval := a.nefCtx.HeartBeatTimer()
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.reregisterCh:
+			a.reregisterNFInstance()
+			continue
+		case <-time.After(interval):
+		}
+
+		err := a.consumer.SendHeartbeat()
+		switch {
+		case errors.Is(err, consumer.ErrNfInstanceNotFound):
+			logger.MainLog.Warnf("NF instance is not found in NRF[%s], re-register", a.cfg.NrfUri())
+			a.reregisterNFInstance()
+		case err != nil:
+			logger.MainLog.Warnf("Heartbeat to NRF err: %+v", err)
+		default:
+			if err = a.consumer.RenewNFStatusSubscriptions(time.Now().Add(2 * interval)); err != nil {
+				logger.MainLog.Errorf("Renew NF status subscriptions err: %+v", err)
+			}
+		}
+	}
+}
+
+// subscribeNfStatus subscribes to the NF types whose URIs are cached in NefContext,
+// so that the URIs are invalidated when the instances deregister
+func (a *NefApp) subscribeNfStatus() {
+	if err := a.consumer.SubscribeNFStatus(models.NfType_PCF, models.NfType_UDR); err != nil {
+		logger.MainLog.Errorf("Subscribe NF status err: %+v", err)
+	}
+}
+
+func (a *NefApp) watchConfigFile() {
+	defer func() {
+		if p := recover(); p != nil {
+			// Print stack for panic to log. Fatalf() will let program exit.
+			logger.MainLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+		}
+
+		a.wg.Done()
+	}()
+
+	path := a.cfg.Path()
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = info.ModTime()
+			logger.MainLog.Infof("Config file [%s] is changed, reload config", path)
+			if _, err := a.ReloadConfig(); err != nil {
+				logger.MainLog.Errorf("Reload config err: %+v", err)
+			}
+		}
+	}
 }
 
 func (a *NefApp) WaitRoutineStopped() {
@@ -181,11 +368,23 @@ func (a *NefApp) Start() {
 func (a *NefApp) Terminate() {
 	logger.MainLog.Infof("Terminating NEF...")
 
+	if err := a.consumer.UnsubscribeNFStatus(); err != nil {
+		logger.MainLog.Errorf("Unsubscribe NF status err: %+v", err)
+	}
+
 	// deregister with NRF
 	if err := a.consumer.DeregisterNFInstance(); err != nil {
 		logger.MainLog.Error(err)
 	} else {
 		logger.MainLog.Infof("Deregister from NRF successfully")
 	}
+
+	if err := a.notifier.Close(); err != nil {
+		logger.MainLog.Errorf("Close NEF notifier err: %+v", err)
+	}
+
+	if err := a.nefCtx.Close(); err != nil {
+		logger.MainLog.Errorf("Close NEF context err: %+v", err)
+	}
 	logger.MainLog.Infof("NEF terminated")
 }
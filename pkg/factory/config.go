@@ -7,7 +7,10 @@ package factory
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,6 +24,10 @@ import (
 const (
 	ServiceTraffInflu  string = "3gpp-traffic-influence"
 	ServicePfdMng      string = "3gpp-pfd-management"
+	ServiceMonEvt      string = "3gpp-monitoring-event"
+	ServiceAsQos       string = "3gpp-as-session-with-qos"
+	ServiceDevTrig     string = "3gpp-device-triggering"
+	ServiceDnaiMap     string = "3gpp-dnai-mapping"
 	ServiceNefPfd      string = string(models.ServiceName_NNEF_PFDMANAGEMENT)
 	ServiceNefOam      string = "nnef-oam"
 	ServiceNefCallback string = "nnef-callback"
@@ -36,18 +43,45 @@ const (
 	NefSbiDefaultPort        = 8000
 	NefSbiDefaultScheme      = "https"
 	NefDefaultNrfUri         = "https://127.0.0.10:8000"
+	NefDefaultStorePath      = "./data/nef.db"
+	NefStoreTypeMemory       = "memory"
+	NefStoreTypeFile         = "file"
+	NefDefaultTriggerPath    = "./log/nef_trigger.log"
+	NefTriggerLoopback       = "loopback"
+	NefTriggerFile           = "file"
+	NefClientAuthNone        = "none"
+	NefClientAuthRequest     = "request"
+	NefClientAuthRequire     = "require"
 	TraffInfluResUriPrefix   = "/" + ServiceTraffInflu + "/v1"
 	PfdMngResUriPrefix       = "/" + ServicePfdMng + "/v1"
+	MonEvtResUriPrefix       = "/" + ServiceMonEvt + "/v1"
+	AsQosResUriPrefix        = "/" + ServiceAsQos + "/v1"
+	DevTrigResUriPrefix      = "/" + ServiceDevTrig + "/v1"
+	DnaiMapResUriPrefix      = "/" + ServiceDnaiMap + "/v1"
 	NefPfdMngResUriPrefix    = "/" + ServiceNefPfd + "/v1"
 	NefOamResUriPrefix       = "/" + ServiceNefOam + "/v1"
+	NefOamAdminResUriPrefix  = NefOamResUriPrefix + "/admin"
+	NefMetricsDefaultPort    = 9091
 	NefCallbackResUriPrefix  = "/" + ServiceNefCallback + "/v1"
 )
 
+// TS 29.571 BitRate, e.g. "10 Mbps"
+var bitRateRegex = regexp.MustCompile(`^\d+(\.\d+)? (bps|Kbps|Mbps|Gbps|Tbps)$`)
+
+// TS 29.571 Tac and NrCellId
+var (
+	tacRegex      = regexp.MustCompile(`^([A-Fa-f0-9]{4}|[A-Fa-f0-9]{6})$`)
+	nrCellIdRegex = regexp.MustCompile(`^[A-Fa-f0-9]{9}$`)
+)
+
 type Config struct {
 	Info          *Info          `yaml:"info" valid:"required"`
 	Configuration *Configuration `yaml:"configuration" valid:"required"`
 	Logger        *Logger        `yaml:"logger" valid:"required"`
 	sync.RWMutex
+
+	// Path of the config file, used to reload the config
+	path string
 }
 
 func (c *Config) Validate() (bool, error) {
@@ -78,6 +112,45 @@ type Configuration struct {
 	NrfUri      string    `yaml:"nrfUri,omitempty" valid:"required"`
 	NrfCertPem  string    `yaml:"nrfCertPem,omitempty" valid:"optional"`
 	ServiceList []Service `yaml:"serviceList,omitempty" valid:"required"`
+	Store       *Store    `yaml:"store,omitempty" valid:"optional"`
+	// QoS references pre-agreed with AFs for AsSessionWithQoS
+	QosReferences []QosReference `yaml:"qosReferences,omitempty" valid:"optional"`
+	// Delivery backend of device trigger messages
+	DeviceTrigger *DeviceTrigger `yaml:"deviceTrigger,omitempty" valid:"optional"`
+	// AFs allowed to access the northbound APIs. Any AF is allowed if empty.
+	Afs []AfPolicy `yaml:"afs,omitempty" valid:"optional"`
+	Oam *Oam       `yaml:"oam,omitempty" valid:"optional"`
+	// Prometheus metrics exposed at /metrics
+	Metrics *Metrics `yaml:"metrics,omitempty" valid:"optional"`
+	// PFD management provided by 3gpp-pfd-management and nnef-pfdmanagement
+	PfdManagement *PfdManagement `yaml:"pfdManagement,omitempty" valid:"optional"`
+	// Locality of NEF, the discovered NF instances in the same locality are preferred
+	Locality string `yaml:"locality,omitempty" valid:"optional"`
+	// Geographic zones which AFs refer to by validGeoZoneIds in traffic influence
+	GeoZones []GeoZone `yaml:"geoZones,omitempty" valid:"optional"`
+	// DNAIs of the edge application servers, provided to AFs by 3gpp-dnai-mapping
+	// and used to fill in the DNAI of traffic routes
+	DnaiMappings []DnaiMapping `yaml:"dnaiMappings,omitempty" valid:"optional"`
+}
+
+type PfdManagement struct {
+	// Caching time in seconds of the PFDs retrieved by SMF. No caching time is provided if 0.
+	// PFDs with an allowed delay shorter than it are rejected with SHORT_DELAY.
+	CachingTime int32 `yaml:"cachingTime,omitempty" valid:"range(0|2147483647),optional"`
+	// All-or-nothing PFD management transactions: if any application fails, the UDR changes
+	// of the transaction are rolled back and no notification is sent.
+	AtomicTransaction bool `yaml:"atomicTransaction,omitempty" valid:"optional"`
+}
+
+type Metrics struct {
+	Enable      bool   `yaml:"enable" valid:"type(bool)"`
+	BindingIPv4 string `yaml:"bindingIPv4,omitempty" valid:"host,optional"`
+	Port        int    `yaml:"port,omitempty" valid:"port,optional"`
+}
+
+type Oam struct {
+	// Bearer token of the OAM admin APIs. The admin APIs are disabled if empty.
+	AdminToken string `yaml:"adminToken,omitempty" valid:"type(string),optional"`
 }
 
 type Logger struct {
@@ -92,6 +165,54 @@ func (c *Configuration) validate() (bool, error) {
 			return result, err
 		}
 	}
+	if store := c.Store; store != nil {
+		if result, err := store.validate(); err != nil {
+			return result, err
+		}
+	}
+	if devTrig := c.DeviceTrigger; devTrig != nil {
+		if result, err := devTrig.validate(); err != nil {
+			return result, err
+		}
+	}
+	for i := range c.QosReferences {
+		if result, err := c.QosReferences[i].validate(); err != nil {
+			return result, err
+		}
+	}
+	for i := range c.Afs {
+		if result, err := c.Afs[i].validate(); err != nil {
+			return result, err
+		}
+	}
+	zoneIDs := make(map[string]bool)
+	for i := range c.GeoZones {
+		if result, err := c.GeoZones[i].validate(); err != nil {
+			return result, err
+		}
+		if zoneIDs[c.GeoZones[i].ZoneId] {
+			err := errors.New("Invalid geoZones[" + strconv.Itoa(i) + "]: duplicate zoneId " + c.GeoZones[i].ZoneId)
+			return false, appendInvalid(err)
+		}
+		zoneIDs[c.GeoZones[i].ZoneId] = true
+	}
+	dnais := make(map[string]bool)
+	for i := range c.DnaiMappings {
+		if result, err := c.DnaiMappings[i].validate(); err != nil {
+			return result, err
+		}
+		if dnais[c.DnaiMappings[i].Dnai] {
+			err := errors.New("Invalid dnaiMappings[" + strconv.Itoa(i) + "]: duplicate dnai " + c.DnaiMappings[i].Dnai)
+			return false, appendInvalid(err)
+		}
+		dnais[c.DnaiMappings[i].Dnai] = true
+		for _, zoneID := range c.DnaiMappings[i].GeoZoneIds {
+			if !zoneIDs[zoneID] {
+				err := errors.New("Invalid dnaiMappings[" + c.DnaiMappings[i].Dnai + "].geoZoneIds: unknown " + zoneID)
+				return false, appendInvalid(err)
+			}
+		}
+	}
 	for i, s := range c.ServiceList {
 		switch {
 		case s.ServiceName == ServiceNefPfd:
@@ -132,17 +253,215 @@ func (s *Sbi) validate() (bool, error) {
 
 type Service struct {
 	ServiceName string `yaml:"serviceName"`
-	SuppFeat    string `yaml:"suppFeat,omitempty"`
+	SuppFeat    string `yaml:"suppFeat,omitempty" valid:"hexadecimal,optional"`
 }
 
 type Tls struct {
 	Pem string `yaml:"pem,omitempty" valid:"type(string),minstringlength(1),required"`
 	Key string `yaml:"key,omitempty" valid:"type(string),minstringlength(1),required"`
+	// CA bundle used to verify the client certificates
+	Ca string `yaml:"ca,omitempty" valid:"type(string),optional"`
+	// none: no client certificate is asked for,
+	// request: a client certificate is verified if given,
+	// require: a valid client certificate is required
+	ClientAuth string `yaml:"clientAuth,omitempty" valid:"in(none|request|require),optional"`
 }
 
 func (t *Tls) validate() (bool, error) {
 	result, err := govalidator.ValidateStruct(t)
-	return result, err
+	if err != nil {
+		return result, err
+	}
+
+	if t.ClientAuth != "" && t.ClientAuth != NefClientAuthNone && t.Ca == "" {
+		return false, fmt.Errorf("tls.ca is required for clientAuth[%s]", t.ClientAuth)
+	}
+	return true, nil
+}
+
+type Store struct {
+	Type string `yaml:"type" valid:"required,in(memory|file)"`
+	Path string `yaml:"path,omitempty" valid:"type(string),optional"`
+}
+
+func (s *Store) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(s)
+	return result, appendInvalid(err)
+}
+
+type QosReference struct {
+	QosReference string `yaml:"qosReference" valid:"type(string),minstringlength(1),required"`
+	MedType      string `yaml:"medType,omitempty" valid:"optional,in(AUDIO|VIDEO|DATA|APPLICATION|CONTROL|TEXT|MESSAGE|OTHER)"`
+	MarBwDl      string `yaml:"marBwDl,omitempty" valid:"bitrate,optional"`
+	MarBwUl      string `yaml:"marBwUl,omitempty" valid:"bitrate,optional"`
+	MirBwDl      string `yaml:"mirBwDl,omitempty" valid:"bitrate,optional"`
+	MirBwUl      string `yaml:"mirBwUl,omitempty" valid:"bitrate,optional"`
+}
+
+func (q *QosReference) validate() (bool, error) {
+	govalidator.TagMap["bitrate"] = govalidator.Validator(func(str string) bool {
+		return bitRateRegex.MatchString(str)
+	})
+
+	result, err := govalidator.ValidateStruct(q)
+	return result, appendInvalid(err)
+}
+
+type DeviceTrigger struct {
+	Deliverer string `yaml:"deliverer" valid:"required,in(loopback|file)"`
+	Path      string `yaml:"path,omitempty" valid:"type(string),optional"`
+}
+
+func (d *DeviceTrigger) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(d)
+	return result, appendInvalid(err)
+}
+
+type AfPolicy struct {
+	AfId string `yaml:"afId" valid:"type(string),minstringlength(1),required"`
+	// Northbound APIs the AF may use. Empty list means no restriction, and so do the following.
+	Apis      []string        `yaml:"apis,omitempty" valid:"optional"`
+	Dnns      []string        `yaml:"dnns,omitempty" valid:"optional"`
+	Snssais   []models.Snssai `yaml:"snssais,omitempty" valid:"optional"`
+	ExtAppIds []string        `yaml:"extAppIds,omitempty" valid:"optional"`
+}
+
+func (a *AfPolicy) validate() (bool, error) {
+	for _, api := range a.Apis {
+		switch api {
+		case ServiceTraffInflu, ServicePfdMng, ServiceMonEvt, ServiceAsQos, ServiceDevTrig, ServiceDnaiMap:
+		default:
+			err := errors.New("Invalid afs[" + a.AfId + "].apis: " + api)
+			return false, appendInvalid(err)
+		}
+	}
+
+	result, err := govalidator.ValidateStruct(a)
+	return result, appendInvalid(err)
+}
+
+func (a *AfPolicy) IsApiAllowed(api string) bool {
+	return len(a.Apis) == 0 || containsString(a.Apis, api)
+}
+
+func (a *AfPolicy) IsDnnAllowed(dnn string) bool {
+	return len(a.Dnns) == 0 || dnn == "" || containsString(a.Dnns, dnn)
+}
+
+func (a *AfPolicy) IsSnssaiAllowed(snssai *models.Snssai) bool {
+	if len(a.Snssais) == 0 || snssai == nil {
+		return true
+	}
+	for _, s := range a.Snssais {
+		if s.Sst == snssai.Sst && strings.EqualFold(s.Sd, snssai.Sd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AfPolicy) IsExtAppIdAllowed(appID string) bool {
+	return len(a.ExtAppIds) == 0 || appID == "" || containsString(a.ExtAppIds, appID)
+}
+
+// GeoZone maps a geographic zone ID agreed with AFs to the tracking areas and NR cells of the zone
+type GeoZone struct {
+	ZoneId string        `yaml:"zoneId" valid:"type(string),minstringlength(1),required"`
+	Tais   []models.Tai  `yaml:"tais,omitempty" valid:"optional"`
+	Ncgis  []models.Ncgi `yaml:"ncgis,omitempty" valid:"optional"`
+}
+
+func (g *GeoZone) validate() (bool, error) {
+	if len(g.Tais) == 0 && len(g.Ncgis) == 0 {
+		err := errors.New("Invalid geoZones[" + g.ZoneId + "]: neither tais nor ncgis")
+		return false, appendInvalid(err)
+	}
+	for i, tai := range g.Tais {
+		if tai.PlmnId == nil || !tacRegex.MatchString(tai.Tac) {
+			err := errors.New("Invalid geoZones[" + g.ZoneId + "].tais[" + strconv.Itoa(i) + "]")
+			return false, appendInvalid(err)
+		}
+	}
+	for i, ncgi := range g.Ncgis {
+		if ncgi.PlmnId == nil || !nrCellIdRegex.MatchString(ncgi.NrCellId) {
+			err := errors.New("Invalid geoZones[" + g.ZoneId + "].ncgis[" + strconv.Itoa(i) + "]")
+			return false, appendInvalid(err)
+		}
+	}
+
+	result, err := govalidator.ValidateStruct(g)
+	return result, appendInvalid(err)
+}
+
+// DnaiMapping maps the edge application servers (EAS) reached by their addresses or FQDNs to a DNAI
+type DnaiMapping struct {
+	Dnai string `yaml:"dnai" valid:"type(string),minstringlength(1),required"`
+	// IPv4 address ranges and IPv6 prefixes of the EASs in CIDR notation, e.g. 10.60.0.0/24
+	EasIpAddrRanges []string `yaml:"easIpAddrRanges,omitempty" valid:"optional"`
+	EasFqdns        []string `yaml:"easFqdns,omitempty" valid:"optional"`
+	// Geographic zones served by the DNAI, which refer to geoZones
+	GeoZoneIds []string `yaml:"geoZoneIds,omitempty" valid:"optional"`
+}
+
+func (d *DnaiMapping) validate() (bool, error) {
+	if len(d.EasIpAddrRanges) == 0 && len(d.EasFqdns) == 0 {
+		err := errors.New("Invalid dnaiMappings[" + d.Dnai + "]: neither easIpAddrRanges nor easFqdns")
+		return false, appendInvalid(err)
+	}
+	for _, ipRange := range d.EasIpAddrRanges {
+		if _, _, err := net.ParseCIDR(ipRange); err != nil {
+			err = errors.New("Invalid dnaiMappings[" + d.Dnai + "].easIpAddrRanges: " + ipRange)
+			return false, appendInvalid(err)
+		}
+	}
+	for _, fqdn := range d.EasFqdns {
+		if !govalidator.IsDNSName(fqdn) {
+			err := errors.New("Invalid dnaiMappings[" + d.Dnai + "].easFqdns: " + fqdn)
+			return false, appendInvalid(err)
+		}
+	}
+
+	result, err := govalidator.ValidateStruct(d)
+	return result, appendInvalid(err)
+}
+
+// matchEasIpAddr returns the prefix length of the longest range containing the IP address, -1 if none
+func (d *DnaiMapping) matchEasIpAddr(ip net.IP) int {
+	longest := -1
+	for _, ipRange := range d.EasIpAddrRanges {
+		_, ipNet, err := net.ParseCIDR(ipRange)
+		if err != nil || !ipNet.Contains(ip) {
+			continue
+		}
+		if ones, _ := ipNet.Mask.Size(); ones > longest {
+			longest = ones
+		}
+	}
+	return longest
+}
+
+// IsEasFqdnMatched reports whether the FQDN is one of the EASs, which is case-insensitive
+func (d *DnaiMapping) IsEasFqdnMatched(fqdn string) bool {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	for _, easFqdn := range d.EasFqdns {
+		if strings.EqualFold(strings.TrimSuffix(easFqdn, "."), fqdn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DnaiMapping) IsGeoZoneServed(zoneID string) bool {
+	return containsString(d.GeoZoneIds, zoneID)
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
 }
 
 func appendInvalid(err error) error {
@@ -161,6 +480,58 @@ func appendInvalid(err error) error {
 	return error(errs)
 }
 
+func (c *Config) Path() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.path != "" {
+		return c.path
+	}
+	return NefDefaultConfigPath
+}
+
+// apply updates the fields which are safe to change at runtime from newCfg,
+// and reports the other changed fields as restart required
+func (c *Config) apply(newCfg *Config) *ReloadResult {
+	c.Lock()
+	defer c.Unlock()
+
+	result := &ReloadResult{}
+	cur, next := c.Configuration, newCfg.Configuration
+	update := func(field string, changed bool, set func()) {
+		if changed {
+			set()
+			result.Applied = append(result.Applied, field)
+		}
+	}
+	update("logger", !reflect.DeepEqual(c.Logger, newCfg.Logger), func() { c.Logger = newCfg.Logger })
+	update("serviceList", !reflect.DeepEqual(cur.ServiceList, next.ServiceList),
+		func() { cur.ServiceList = next.ServiceList })
+	update("nrfUri", cur.NrfUri != next.NrfUri, func() { cur.NrfUri = next.NrfUri })
+	update("nrfCertPem", cur.NrfCertPem != next.NrfCertPem, func() { cur.NrfCertPem = next.NrfCertPem })
+	update("qosReferences", !reflect.DeepEqual(cur.QosReferences, next.QosReferences),
+		func() { cur.QosReferences = next.QosReferences })
+	update("afs", !reflect.DeepEqual(cur.Afs, next.Afs), func() { cur.Afs = next.Afs })
+	update("oam", !reflect.DeepEqual(cur.Oam, next.Oam), func() { cur.Oam = next.Oam })
+	update("pfdManagement", !reflect.DeepEqual(cur.PfdManagement, next.PfdManagement),
+		func() { cur.PfdManagement = next.PfdManagement })
+	update("locality", cur.Locality != next.Locality, func() { cur.Locality = next.Locality })
+	update("geoZones", !reflect.DeepEqual(cur.GeoZones, next.GeoZones), func() { cur.GeoZones = next.GeoZones })
+	update("dnaiMappings", !reflect.DeepEqual(cur.DnaiMappings, next.DnaiMappings),
+		func() { cur.DnaiMappings = next.DnaiMappings })
+
+	restartRequired := func(field string, changed bool) {
+		if changed {
+			result.RestartRequired = append(result.RestartRequired, field)
+		}
+	}
+	restartRequired("sbi", !reflect.DeepEqual(cur.Sbi, next.Sbi))
+	restartRequired("store", !reflect.DeepEqual(cur.Store, next.Store))
+	restartRequired("deviceTrigger", !reflect.DeepEqual(cur.DeviceTrigger, next.DeviceTrigger))
+	restartRequired("metrics", !reflect.DeepEqual(cur.Metrics, next.Metrics))
+	return result
+}
+
 func (c *Config) Print() {
 	c.RLock()
 	defer c.RUnlock()
@@ -333,6 +704,13 @@ func (c *Config) NrfCertPem() string {
 	return "" // havn't setup in config
 }
 
+func (c *Config) Locality() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Locality
+}
+
 func (c *Config) ServiceList() []Service {
 	c.RLock()
 	defer c.RUnlock()
@@ -343,6 +721,191 @@ func (c *Config) ServiceList() []Service {
 	return nil
 }
 
+// ServiceSuppFeat returns the configured supported features of the service,
+// empty if the service is not provided or supports no feature
+func (c *Config) ServiceSuppFeat(serviceName string) string {
+	for _, s := range c.ServiceList() {
+		if s.ServiceName == serviceName {
+			return s.SuppFeat
+		}
+	}
+	return ""
+}
+
+func (c *Config) StoreType() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Store != nil && c.Configuration.Store.Type != "" {
+		return c.Configuration.Store.Type
+	}
+	return NefStoreTypeMemory
+}
+
+func (c *Config) StorePath() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Store != nil && c.Configuration.Store.Path != "" {
+		return c.Configuration.Store.Path
+	}
+	return NefDefaultStorePath
+}
+
+func (c *Config) QosReference(qosRef string) *QosReference {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := range c.Configuration.QosReferences {
+		if c.Configuration.QosReferences[i].QosReference == qosRef {
+			ref := c.Configuration.QosReferences[i]
+			return &ref
+		}
+	}
+	return nil
+}
+
+func (c *Config) TriggerDeliverer() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.DeviceTrigger != nil && c.Configuration.DeviceTrigger.Deliverer != "" {
+		return c.Configuration.DeviceTrigger.Deliverer
+	}
+	return NefTriggerLoopback
+}
+
+func (c *Config) TriggerPath() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.DeviceTrigger != nil && c.Configuration.DeviceTrigger.Path != "" {
+		return c.Configuration.DeviceTrigger.Path
+	}
+	return NefDefaultTriggerPath
+}
+
+// IsAfAuthzEnabled reports whether the access of AFs is restricted by AfPolicy
+func (c *Config) IsAfAuthzEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return len(c.Configuration.Afs) > 0
+}
+
+// AfPolicy returns the policy of the AF, or nil if the AF is not configured
+func (c *Config) AfPolicy(afID string) *AfPolicy {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := range c.Configuration.Afs {
+		if c.Configuration.Afs[i].AfId == afID {
+			policy := c.Configuration.Afs[i]
+			return &policy
+		}
+	}
+	return nil
+}
+
+// GeoZone returns the geographic zone of the zone ID, or nil if the zone is not configured
+func (c *Config) GeoZone(zoneID string) *GeoZone {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := range c.Configuration.GeoZones {
+		if c.Configuration.GeoZones[i].ZoneId == zoneID {
+			zone := c.Configuration.GeoZones[i]
+			return &zone
+		}
+	}
+	return nil
+}
+
+// DnaiMappings returns the configured DNAI mappings
+func (c *Config) DnaiMappings() []DnaiMapping {
+	c.RLock()
+	defer c.RUnlock()
+
+	return append([]DnaiMapping(nil), c.Configuration.DnaiMappings...)
+}
+
+// DnaiOfEasIpAddr returns the DNAI of the EAS with the IP address by the longest matched range,
+// or empty if the address is not in any range
+func (c *Config) DnaiOfEasIpAddr(ipAddr string) string {
+	ip := net.ParseIP(ipAddr)
+	if ip == nil {
+		return ""
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	dnai, longest := "", -1
+	for i := range c.Configuration.DnaiMappings {
+		if ones := c.Configuration.DnaiMappings[i].matchEasIpAddr(ip); ones > longest {
+			dnai, longest = c.Configuration.DnaiMappings[i].Dnai, ones
+		}
+	}
+	return dnai
+}
+
+func (c *Config) OamAdminToken() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Oam != nil {
+		return c.Configuration.Oam.AdminToken
+	}
+	return ""
+}
+
+// PfdCachingTime returns the caching time in seconds of PFDs, 0 if not configured
+func (c *Config) PfdCachingTime() int32 {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.PfdManagement != nil {
+		return c.Configuration.PfdManagement.CachingTime
+	}
+	return 0
+}
+
+// PfdAtomicTransaction reports whether PFD management transactions are all-or-nothing
+func (c *Config) PfdAtomicTransaction() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.PfdManagement != nil {
+		return c.Configuration.PfdManagement.AtomicTransaction
+	}
+	return false
+}
+
+func (c *Config) MetricsEnable() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Metrics != nil && c.Configuration.Metrics.Enable
+}
+
+// MetricsBindingAddr returns the address of metrics server, which defaults to the SBI binding IP
+func (c *Config) MetricsBindingAddr() string {
+	ip, port := "", NefMetricsDefaultPort
+	c.RLock()
+	if m := c.Configuration.Metrics; m != nil {
+		ip = m.BindingIPv4
+		if m.Port != 0 {
+			port = m.Port
+		}
+	}
+	c.RUnlock()
+
+	if ip == "" {
+		ip = c.SbiBindingIP()
+	}
+	return ip + ":" + strconv.Itoa(port)
+}
+
 func (c *Config) TLSPemPath() string {
 	c.RLock()
 	defer c.RUnlock()
@@ -363,6 +926,26 @@ func (c *Config) TLSKeyPath() string {
 	return NefDefaultPrivateKeyPath
 }
 
+func (c *Config) TLSCaPath() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Sbi.Tls != nil {
+		return c.Configuration.Sbi.Tls.Ca
+	}
+	return ""
+}
+
+func (c *Config) TLSClientAuth() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.Sbi.Tls != nil && c.Configuration.Sbi.Tls.ClientAuth != "" {
+		return c.Configuration.Sbi.Tls.ClientAuth
+	}
+	return NefClientAuthNone
+}
+
 func (c *Config) NFServices() []models.NfService {
 	versions := strings.Split(c.Version(), ".")
 	majorVersionUri := "v" + versions[0]
@@ -400,6 +983,14 @@ func (c *Config) ServiceUri(name string) string {
 		return c.SbiUri() + TraffInfluResUriPrefix
 	case ServicePfdMng:
 		return c.SbiUri() + PfdMngResUriPrefix
+	case ServiceMonEvt:
+		return c.SbiUri() + MonEvtResUriPrefix
+	case ServiceAsQos:
+		return c.SbiUri() + AsQosResUriPrefix
+	case ServiceDevTrig:
+		return c.SbiUri() + DevTrigResUriPrefix
+	case ServiceDnaiMap:
+		return c.SbiUri() + DnaiMapResUriPrefix
 	case ServiceNefPfd:
 		return c.SbiUri() + NefPfdMngResUriPrefix
 	case ServiceNefOam:
@@ -13,7 +13,6 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-// TODO: Support configuration update from REST api
 func InitConfigFactory(f string, cfg *Config) error {
 	if f == "" {
 		// Use default config path
@@ -33,7 +32,21 @@ func InitConfigFactory(f string, cfg *Config) error {
 }
 
 func ReadConfig(cfgPath string) (*Config, error) {
-	cfg := &Config{}
+	cfg, err := loadConfig(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Print()
+	return cfg, nil
+}
+
+func loadConfig(cfgPath string) (*Config, error) {
+	if cfgPath == "" {
+		cfgPath = NefDefaultConfigPath
+	}
+
+	cfg := &Config{path: cfgPath}
 	if err := InitConfigFactory(cfgPath, cfg); err != nil {
 		return nil, fmt.Errorf("ReadConfig [%s] Error: %+v", cfgPath, err)
 	}
@@ -45,7 +58,36 @@ func ReadConfig(cfgPath string) (*Config, error) {
 		logger.CfgLog.Errorf("[-- PLEASE REFER TO SAMPLE CONFIG FILE COMMENTS --]")
 		return nil, fmt.Errorf("Config validate Error")
 	}
-
-	cfg.Print()
 	return cfg, nil
 }
+
+// ReloadResult reports the configuration fields changed by a reload
+type ReloadResult struct {
+	// Fields which have been applied at runtime
+	Applied []string `json:"applied,omitempty"`
+	// Fields which are changed in the file but take effect only after restart
+	RestartRequired []string `json:"restartRequired,omitempty"`
+}
+
+func (r *ReloadResult) IsApplied(field string) bool {
+	for _, f := range r.Applied {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadConfig reads and validates the config file of cfg again,
+// and applies the fields which are safe to change at runtime
+func ReloadConfig(cfg *Config) (*ReloadResult, error) {
+	newCfg, err := loadConfig(cfg.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	result := cfg.apply(newCfg)
+	logger.CfgLog.Infof("Config reloaded, applied: %v, restart required: %v",
+		result.Applied, result.RestartRequired)
+	return result, nil
+}
@@ -0,0 +1,192 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfig(t *testing.T) {
+	sample, err := os.ReadFile("../../config/nefcfg.yaml")
+	require.NoError(t, err)
+
+	cfgPath := filepath.Join(t.TempDir(), "nefcfg.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, sample, 0o600))
+
+	cfg, err := ReadConfig(cfgPath)
+	require.NoError(t, err)
+	require.Equal(t, cfgPath, cfg.Path())
+
+	testCases := []struct {
+		description    string
+		replacer       *strings.Replacer
+		expectedResult *ReloadResult
+		expectedErr    bool
+	}{
+		{
+			description:    "TC1: Nothing changed, should apply nothing",
+			replacer:       strings.NewReplacer(),
+			expectedResult: &ReloadResult{},
+		},
+		{
+			description: "TC2: Live fields changed, should be applied",
+			replacer: strings.NewReplacer(
+				"level: info", "level: debug",
+				"nrfUri: http://127.0.0.10:8000", "nrfUri: http://127.0.0.11:8000"),
+			expectedResult: &ReloadResult{
+				Applied: []string{"logger", "nrfUri"},
+			},
+		},
+		{
+			description: "TC3: SBI port changed, should require restart",
+			replacer: strings.NewReplacer(
+				"level: info", "level: debug",
+				"nrfUri: http://127.0.0.10:8000", "nrfUri: http://127.0.0.11:8000",
+				"port: 8000", "port: 8001"),
+			expectedResult: &ReloadResult{
+				RestartRequired: []string{"sbi"},
+			},
+		},
+		{
+			description: "TC4: Invalid config, should keep the current one",
+			replacer:    strings.NewReplacer("level: info", "level: verbose"),
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(cfgPath, []byte(tc.replacer.Replace(string(sample))), 0o600))
+			result, err := ReloadConfig(cfg)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResult, result)
+		})
+	}
+
+	require.Equal(t, "debug", cfg.GetLogLevel())
+	require.Equal(t, "http://127.0.0.11:8000", cfg.NrfUri())
+	require.Equal(t, 8000, cfg.SbiPort())
+}
+
+func TestGeoZonesValidate(t *testing.T) {
+	plmnID := &models.PlmnId{Mcc: "208", Mnc: "93"}
+
+	testCases := []struct {
+		description string
+		geoZones    []GeoZone
+		expectedErr bool
+	}{
+		{
+			description: "TC1: Zones of tracking areas and NR cells",
+			geoZones: []GeoZone{
+				{ZoneId: "zone1", Tais: []models.Tai{{PlmnId: plmnID, Tac: "000001"}}},
+				{ZoneId: "zone2", Ncgis: []models.Ncgi{{PlmnId: plmnID, NrCellId: "000000010"}}},
+			},
+		},
+		{
+			description: "TC2: Zone without tracking area or cell",
+			geoZones:    []GeoZone{{ZoneId: "zone1"}},
+			expectedErr: true,
+		},
+		{
+			description: "TC3: Invalid TAC",
+			geoZones: []GeoZone{
+				{ZoneId: "zone1", Tais: []models.Tai{{PlmnId: plmnID, Tac: "1"}}},
+			},
+			expectedErr: true,
+		},
+		{
+			description: "TC4: Duplicate zone ID",
+			geoZones: []GeoZone{
+				{ZoneId: "zone1", Tais: []models.Tai{{PlmnId: plmnID, Tac: "000001"}}},
+				{ZoneId: "zone1", Tais: []models.Tai{{PlmnId: plmnID, Tac: "000002"}}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := ReadConfig("../../config/nefcfg.yaml")
+			require.NoError(t, err)
+
+			cfg.Configuration.GeoZones = tc.geoZones
+			_, err = cfg.Validate()
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDnaiMappings(t *testing.T) {
+	plmnID := &models.PlmnId{Mcc: "208", Mnc: "93"}
+
+	testCases := []struct {
+		description  string
+		dnaiMappings []DnaiMapping
+		expectedErr  bool
+	}{
+		{
+			description: "TC1: Mappings of address ranges and FQDNs",
+			dnaiMappings: []DnaiMapping{
+				{Dnai: "mec1", EasIpAddrRanges: []string{"10.60.0.0/16", "2001:db8::/32"}, GeoZoneIds: []string{"zone1"}},
+				{Dnai: "mec2", EasIpAddrRanges: []string{"10.60.1.0/24"}, EasFqdns: []string{"eas.mec2.example.com"}},
+			},
+		},
+		{
+			description:  "TC2: Invalid address range",
+			dnaiMappings: []DnaiMapping{{Dnai: "mec1", EasIpAddrRanges: []string{"10.60.0.0"}}},
+			expectedErr:  true,
+		},
+		{
+			description: "TC3: Unknown geographic zone",
+			dnaiMappings: []DnaiMapping{
+				{Dnai: "mec1", EasIpAddrRanges: []string{"10.60.0.0/16"}, GeoZoneIds: []string{"zone2"}},
+			},
+			expectedErr: true,
+		},
+		{
+			description: "TC4: Duplicate DNAI",
+			dnaiMappings: []DnaiMapping{
+				{Dnai: "mec1", EasIpAddrRanges: []string{"10.60.0.0/16"}},
+				{Dnai: "mec1", EasFqdns: []string{"eas.mec1.example.com"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := ReadConfig("../../config/nefcfg.yaml")
+			require.NoError(t, err)
+
+			cfg.Configuration.GeoZones = []GeoZone{
+				{ZoneId: "zone1", Tais: []models.Tai{{PlmnId: plmnID, Tac: "000001"}}},
+			}
+			cfg.Configuration.DnaiMappings = tc.dnaiMappings
+			_, err = cfg.Validate()
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// The longest matched range takes precedence
+			require.Equal(t, "mec2", cfg.DnaiOfEasIpAddr("10.60.1.1"))
+			require.Equal(t, "mec1", cfg.DnaiOfEasIpAddr("10.60.2.1"))
+			require.Equal(t, "mec1", cfg.DnaiOfEasIpAddr("2001:db8::1"))
+			require.Equal(t, "", cfg.DnaiOfEasIpAddr("192.168.0.1"))
+		})
+	}
+}